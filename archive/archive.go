@@ -1,7 +1,9 @@
 package archive
 
 import (
+	"context"
 	"fmt"
+	"os/exec"
 	"path"
 	"path/filepath"
 
@@ -11,8 +13,8 @@ import (
 )
 
 // Run archive
-func Run(model config.ModelConfig) error {
-	logger := logger.Tag("Archive")
+func Run(ctx context.Context, model config.ModelConfig) error {
+	logger := logger.Tag("Archive").WithContext(ctx)
 
 	if model.Archive == nil {
 		return nil
@@ -36,10 +38,26 @@ func Run(model config.ModelConfig) error {
 
 	opts := options(model.DumpPath, excludes, includes)
 
-	_, err := helper.Exec("tar", opts...)
+	_, err := helper.ExecContext(ctx, "tar", opts...)
 	return err
 }
 
+// Check archive config of model
+func Check(model config.ModelConfig) (errs []error) {
+	if model.Archive == nil {
+		return nil
+	}
+
+	if len(cleanPaths(model.Archive.GetStringSlice("includes"))) == 0 {
+		errs = append(errs, fmt.Errorf("archive.includes have no config"))
+	}
+	if _, err := exec.LookPath("tar"); err != nil {
+		errs = append(errs, fmt.Errorf("archive: tar cannot be found in $PATH"))
+	}
+
+	return
+}
+
 func options(dumpPath string, excludes, includes []string) (opts []string) {
 	tarPath := path.Join(dumpPath, "archive.tar")
 	if helper.IsGnuTar {
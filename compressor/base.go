@@ -1,9 +1,10 @@
 package compressor
 
 import (
+	"context"
 	"fmt"
 	"github.com/gobackup/gobackup/helper"
-	"os"
+	"os/exec"
 	"path/filepath"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 
 // Base compressor
 type Base struct {
+	ctx             context.Context
 	name            string
 	ext             string
 	parallelProgram string
@@ -33,6 +35,7 @@ func (c *Base) archiveFilePath(ext string) string {
 
 func newBase(model config.ModelConfig) (base Base) {
 	base = Base{
+		ctx:   context.Background(),
 		name:  model.Name,
 		model: model,
 		viper: model.CompressWith.Viper,
@@ -40,21 +43,9 @@ func newBase(model config.ModelConfig) (base Base) {
 	return
 }
 
-// Run compressor, return archive path
-func Run(model config.ModelConfig) (string, error) {
-	logger := logger.Tag("Compressor")
-
-	// Skip compression if type is not set
-	if model.CompressWith.Type == "" {
-		logger.Info("=> Compress | skipped (no compression type specified)")
-		return model.DumpPath, nil
-	}
-
-	base := newBase(model)
-
-	var c Compressor
-	var ext, parallelProgram string
-	switch model.CompressWith.Type {
+// extension returns the archive extension and the parallel program of compress type
+func extension(compressType string) (ext, parallelProgram string, err error) {
+	switch compressType {
 	case "gz", "tgz", "taz", "tar.gz":
 		ext = ".tar.gz"
 		parallelProgram = "pigz"
@@ -77,9 +68,48 @@ func Run(model config.ModelConfig) (string, error) {
 	case "tar":
 		ext = ".tar"
 	default:
-		return "", fmt.Errorf("Unsupported compress type: %s", model.CompressWith.Type)
+		err = fmt.Errorf("Unsupported compress type: %s", compressType)
+	}
+
+	return
+}
+
+// Check compress_with config of model
+func Check(model config.ModelConfig) (errs []error) {
+	if model.CompressWith.Type == "" {
+		return nil
+	}
+
+	if _, _, err := extension(model.CompressWith.Type); err != nil {
+		errs = append(errs, fmt.Errorf("compress_with: %v", err))
+	}
+
+	if _, err := exec.LookPath("tar"); err != nil {
+		errs = append(errs, fmt.Errorf("compress_with: tar cannot be found in $PATH"))
 	}
 
+	return
+}
+
+// Run compressor, return archive path
+func Run(ctx context.Context, model config.ModelConfig) (string, error) {
+	logger := logger.Tag("Compressor").WithContext(ctx)
+
+	// Skip compression if type is not set
+	if model.CompressWith.Type == "" {
+		logger.Info("=> Compress | skipped (no compression type specified)")
+		return model.DumpPath, nil
+	}
+
+	base := newBase(model)
+	base.ctx = ctx
+
+	ext, parallelProgram, err := extension(model.CompressWith.Type)
+	if err != nil {
+		return "", err
+	}
+
+	var c Compressor
 	// save Extension
 	model.Viper.Set("Ext", ext)
 
@@ -94,11 +124,6 @@ func Run(model config.ModelConfig) (string, error) {
 		return "", err
 	}
 
-	// set workdir
-	if err := os.Chdir(filepath.Join(model.DumpPath, "../")); err != nil {
-		return "", fmt.Errorf("chdir to dump path: %s: %w", model.DumpPath, err)
-	}
-
 	archivePath, err := c.perform()
 	if err != nil {
 		return "", err
@@ -1,6 +1,7 @@
 package compressor
 
 import (
+	"context"
 	"path"
 	"strings"
 	"testing"
@@ -64,7 +65,7 @@ func TestRun_EmptyType(t *testing.T) {
 		Viper: viper.New(),
 	}
 
-	archivePath, err := Run(model)
+	archivePath, err := Run(context.Background(), model)
 	assert.Nil(t, err)
 	assert.Equal(t, archivePath, "/tmp/test_dump")
 }
@@ -2,8 +2,10 @@ package compressor
 
 import (
 	"os/exec"
+	"path/filepath"
 
 	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/progress"
 )
 
 type Tar struct {
@@ -15,10 +17,15 @@ func (tar *Tar) perform() (archivePath string, err error) {
 
 	opts := tar.options()
 	opts = append(opts, filePath)
+	// archive the dump path relative to its parent, without chdir of the process,
+	// so that models can be performed concurrently
+	opts = append(opts, "-C", filepath.Dir(tar.model.DumpPath))
 	opts = append(opts, tar.name)
 	archivePath = filePath
 
-	_, err = helper.Exec("tar", opts...)
+	stop := progress.Watch(tar.ctx, progress.KindCompress, filePath, progress.Size(tar.model.DumpPath))
+	_, err = helper.ExecContext(tar.ctx, "tar", opts...)
+	stop(err)
 
 	return
 }
@@ -4,16 +4,21 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/joho/godotenv"
+	"github.com/spf13/cast"
 	"github.com/spf13/viper"
 
 	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/leader"
 	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/metrics"
+	"github.com/gobackup/gobackup/tracing"
 )
 
 var (
@@ -27,6 +32,19 @@ var (
 	PidFilePath string = filepath.Join(GoBackupDir, "gobackup.pid")
 	LogFilePath string = filepath.Join(GoBackupDir, "gobackup.log")
 	Web         WebConfig
+	Log         LogConfig
+	Metrics     MetricsConfig
+	Tracing     TracingConfig
+	// LeaderElection of the replicas in Kubernetes, disabled if nil
+	LeaderElection *leader.Config
+
+	// MaxConcurrentModels is the number of models can be performed at the same time, default: 1
+	MaxConcurrentModels int
+	// LockFile to use file locks in GoBackupDir for models, so that a model
+	// will not be performed by multiple gobackup processes at the same time
+	LockFile bool
+	// ShutdownTimeout is the max time to wait for the performing models on graceful shutdown, 0 means no limit
+	ShutdownTimeout time.Duration
 
 	wLock = sync.Mutex{}
 
@@ -34,14 +52,44 @@ var (
 	UpdatedAt time.Time
 
 	onConfigChanges = make([]func(fsnotify.Event), 0)
+
+	// includePatterns are the absolute `include` patterns of the loaded config
+	includePatterns []string
+	// includeWatcher watches the dirs of includePatterns, the main config file is watched by viper
+	includeWatcher *fsnotify.Watcher
 )
 
-type WebConfig struct {
-	Host     string
-	Port     string
-	Username string
-	Password string
-	Enabled  bool
+type LogConfig struct {
+	// Format of log: text (default), json
+	Format string
+	// MaxSize in megabytes of gobackup.log before it gets rotated, default: 100
+	MaxSize int
+	// MaxBackups is the number of rotated log files to keep, default: 10
+	MaxBackups int
+	// MaxAge in days to keep the rotated log files, default: 0 (no limit)
+	MaxAge int
+	// Compress the rotated log files with gzip
+	Compress bool
+}
+
+type MetricsConfig struct {
+	// PushGateway to push the metrics of model after `gobackup perform`
+	PushGateway *metrics.PushGateway
+	// Textfile to write the metrics for the textfile collector of node_exporter after `gobackup perform`
+	Textfile string
+}
+
+type TracingConfig struct {
+	// Exporter of traces: otlp_grpc, otlp_http, stdout, tracing is disabled if empty
+	Exporter string
+	// Endpoint of OTLP exporter, host:port, default by the OTEL_EXPORTER_OTLP_* env
+	Endpoint string
+	// Insecure to export without TLS
+	Insecure bool
+	// Headers of OTLP requests, e.g. authorization
+	Headers map[string]string
+	// ServiceName of traces, default: gobackup
+	ServiceName string
 }
 
 type ScheduleConfig struct {
@@ -52,6 +100,8 @@ type ScheduleConfig struct {
 	Every string `json:"every,omitempty"`
 	// At time
 	At string `json:"at,omitempty"`
+	// Grace after the expected time of next backup, before it's reported as missed
+	Grace time.Duration `json:"grace,omitempty"`
 }
 
 func (sc ScheduleConfig) String() string {
@@ -90,6 +140,10 @@ type ModelConfig struct {
 	Viper          *viper.Viper
 	BeforeScript   string
 	AfterScript    string
+	// OnOverlap policy when the model is performing: skip, queue, cancel_previous
+	OnOverlap string
+	// Timeout of performing, 0 means no timeout
+	Timeout time.Duration
 }
 
 func getGoBackupDir() string {
@@ -139,12 +193,27 @@ func Init(configFile string) error {
 	viper.OnConfigChange(func(in fsnotify.Event) {
 		logger.Info("Config file changed:", in.Name)
 		defer onConfigChanged(in)
-		if err := loadConfig(); err != nil {
+		if err := reloadConfig(); err != nil {
 			logger.Error(err.Error())
 		}
+		watchIncludes()
 	})
 
-	return loadConfig()
+	err := reloadConfig()
+	watchIncludes()
+	return err
+}
+
+// reloadConfig loads config and records the result in metrics
+func reloadConfig() error {
+	if err := loadConfig(); err != nil {
+		metrics.ConfigReloadSuccess.Set(0)
+		return err
+	}
+
+	metrics.ConfigReloadSuccess.Set(1)
+	metrics.ConfigReloadTimestamp.Set(float64(time.Now().Unix()))
+	return nil
 }
 
 // OnConfigChange add callback when config changed
@@ -191,11 +260,25 @@ func loadConfig() error {
 	}
 
 	cfg, _ := os.ReadFile(viperConfigFile)
-	if err := viper.ReadConfig(strings.NewReader(os.ExpandEnv(string(cfg)))); err != nil {
+	expanded, err := expandConfig(string(cfg))
+	if err != nil {
+		logger.Errorf("Expand config failed: %v", err)
+		return err
+	}
+	if err := viper.ReadConfig(strings.NewReader(expanded)); err != nil {
 		logger.Errorf("Load expanded config failed: %v", err)
 		return err
 	}
 
+	if err := loadIncludes(filepath.Dir(viperConfigFile)); err != nil {
+		logger.Errorf("Load include config failed: %v", err)
+		return err
+	}
+
+	if err := loadLogConfig(); err != nil {
+		return err
+	}
+
 	// TODO: Here the `useTempWorkDir` and `workdir`, is not in config document. We need removed it.
 	viper.Set("useTempWorkDir", false)
 	if workdir := viper.GetString("workdir"); len(workdir) == 0 {
@@ -224,16 +307,31 @@ func loadConfig() error {
 		return fmt.Errorf("no model found in %s", viperConfigFile)
 	}
 
-	// Load web config
-	Web = WebConfig{}
-	viper.SetDefault("web.host", "0.0.0.0")
-	viper.SetDefault("web.port", 2703)
-	viper.SetDefault("web.enabled", true)
-	Web.Host = viper.GetString("web.host")
-	Web.Port = viper.GetString("web.port")
-	Web.Username = viper.GetString("web.username")
-	Web.Password = viper.GetString("web.password")
-	Web.Enabled = viper.GetBool("web.enabled")
+	viper.SetDefault("max_concurrent_models", 1)
+	MaxConcurrentModels = viper.GetInt("max_concurrent_models")
+	if MaxConcurrentModels < 1 {
+		return fmt.Errorf("max_concurrent_models must be greater than 0")
+	}
+	LockFile = viper.GetBool("lock_file")
+	if ShutdownTimeout, err = helper.ParseTimeout(viper.GetString("shutdown_timeout")); err != nil {
+		return fmt.Errorf("shutdown_timeout: %v", err)
+	}
+
+	if err := loadWebConfig(); err != nil {
+		return err
+	}
+
+	if err := loadMetricsConfig(); err != nil {
+		return err
+	}
+
+	if err := loadTracingConfig(); err != nil {
+		return err
+	}
+
+	if err := loadLeaderElectionConfig(); err != nil {
+		return err
+	}
 
 	UpdatedAt = time.Now()
 	logger.Infof("Config loaded, found %d models.", len(Models))
@@ -241,6 +339,254 @@ func loadConfig() error {
 	return nil
 }
 
+// loadLogConfig loads `log` config and applies it to logger
+func loadLogConfig() error {
+	viper.SetDefault("log.max_size", 100)
+	viper.SetDefault("log.max_backups", 10)
+
+	Log = LogConfig{}
+	Log.Format = viper.GetString("log.format")
+	Log.MaxSize = viper.GetInt("log.max_size")
+	Log.MaxBackups = viper.GetInt("log.max_backups")
+	Log.MaxAge = viper.GetInt("log.max_age")
+	Log.Compress = viper.GetBool("log.compress")
+
+	if err := logger.SetFormat(Log.Format); err != nil {
+		return fmt.Errorf("log.format: %v", err)
+	}
+	if Log.MaxSize < 1 {
+		return fmt.Errorf("log.max_size must be greater than 0")
+	}
+	if Log.MaxBackups < 0 || Log.MaxAge < 0 {
+		return fmt.Errorf("log.max_backups and log.max_age must not be negative")
+	}
+	logger.SetRotation(logger.Rotation{
+		MaxSize:    Log.MaxSize,
+		MaxBackups: Log.MaxBackups,
+		MaxAge:     Log.MaxAge,
+		Compress:   Log.Compress,
+	})
+
+	return nil
+}
+
+// loadMetricsConfig loads `metrics` config
+func loadMetricsConfig() error {
+	Metrics = MetricsConfig{}
+	Metrics.Textfile = viper.GetString("metrics.textfile")
+	if len(Metrics.Textfile) > 0 {
+		Metrics.Textfile = helper.AbsolutePath(Metrics.Textfile)
+		if filepath.Ext(Metrics.Textfile) != ".prom" {
+			return fmt.Errorf("metrics.textfile must have the .prom extension: %s", Metrics.Textfile)
+		}
+	}
+
+	if viper.IsSet("metrics.push_gateway") {
+		Metrics.PushGateway = &metrics.PushGateway{
+			URL:      viper.GetString("metrics.push_gateway.url"),
+			Job:      viper.GetString("metrics.push_gateway.job"),
+			Grouping: viper.GetStringMapString("metrics.push_gateway.grouping"),
+			Username: viper.GetString("metrics.push_gateway.username"),
+			Password: viper.GetString("metrics.push_gateway.password"),
+		}
+		if len(Metrics.PushGateway.URL) == 0 {
+			return fmt.Errorf("metrics.push_gateway.url is required")
+		}
+		if _, ok := Metrics.PushGateway.Grouping["model"]; ok {
+			return fmt.Errorf("metrics.push_gateway.grouping: model is reserved")
+		}
+	}
+
+	return nil
+}
+
+// loadTracingConfig loads `tracing` config and applies it to tracing
+func loadTracingConfig() error {
+	Tracing = TracingConfig{}
+	Tracing.Exporter = viper.GetString("tracing.exporter")
+	Tracing.Endpoint = viper.GetString("tracing.endpoint")
+	Tracing.Insecure = viper.GetBool("tracing.insecure")
+	Tracing.Headers = viper.GetStringMapString("tracing.headers")
+	Tracing.ServiceName = viper.GetString("tracing.service_name")
+
+	switch Tracing.Exporter {
+	case "", tracing.ExporterOTLPGRPC, tracing.ExporterOTLPHTTP, tracing.ExporterStdout:
+	default:
+		return fmt.Errorf("tracing.exporter must be one of %s, %s, %s", tracing.ExporterOTLPGRPC, tracing.ExporterOTLPHTTP, tracing.ExporterStdout)
+	}
+
+	if err := tracing.Setup(tracing.Exporter{
+		Type:        Tracing.Exporter,
+		Endpoint:    Tracing.Endpoint,
+		Insecure:    Tracing.Insecure,
+		Headers:     Tracing.Headers,
+		ServiceName: Tracing.ServiceName,
+	}); err != nil {
+		return fmt.Errorf("tracing: %v", err)
+	}
+
+	return nil
+}
+
+// loadLeaderElectionConfig loads `leader_election` config, it's applied by scheduler
+func loadLeaderElectionConfig() error {
+	LeaderElection = nil
+	if !viper.IsSet("leader_election") {
+		return nil
+	}
+
+	LeaderElection = &leader.Config{
+		Lease:     viper.GetString("leader_election.lease"),
+		Namespace: viper.GetString("leader_election.namespace"),
+		Identity:  viper.GetString("leader_election.identity"),
+	}
+	durations := map[string]*time.Duration{
+		"lease_duration": &LeaderElection.LeaseDuration,
+		"renew_deadline": &LeaderElection.RenewDeadline,
+		"retry_period":   &LeaderElection.RetryPeriod,
+	}
+	for key, duration := range durations {
+		value, err := helper.ParseTimeout(viper.GetString("leader_election." + key))
+		if err != nil {
+			return fmt.Errorf("leader_election.%s: %v", key, err)
+		}
+		if value < 0 {
+			return fmt.Errorf("leader_election.%s must not be negative", key)
+		}
+		*duration = value
+	}
+
+	return nil
+}
+
+// loadIncludes merges the config files matched the `include` patterns into config,
+// relative patterns are relative to the dir of main config file.
+//
+//	include:
+//	  - conf.d/*.yml
+func loadIncludes(dir string) error {
+	logger := logger.Tag("Config")
+
+	includePatterns = nil
+	for _, pattern := range viper.GetStringSlice("include") {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, helper.ExplandHome(pattern))
+		}
+		includePatterns = append(includePatterns, pattern)
+
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("include %s: %v", pattern, err)
+		}
+
+		for _, file := range files {
+			logger.Info("Include config:", file)
+
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			expanded, err := expandConfig(string(content))
+			if err != nil {
+				return fmt.Errorf("%s: %v", file, err)
+			}
+			if err := viper.MergeConfig(strings.NewReader(expanded)); err != nil {
+				return fmt.Errorf("%s: %v", file, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// watchIncludes watches the dirs of the `include` patterns, the config is reloaded when the included files changed
+func watchIncludes() {
+	wLock.Lock()
+	defer wLock.Unlock()
+
+	logger := logger.Tag("Config")
+
+	if includeWatcher == nil {
+		if len(includePatterns) == 0 {
+			return
+		}
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			logger.Errorf("Watch include config failed: %v", err)
+			return
+		}
+		includeWatcher = watcher
+		go handleIncludeEvents(watcher)
+	}
+
+	dirs := map[string]bool{}
+	for _, pattern := range includePatterns {
+		dir := filepath.Dir(pattern)
+		if !strings.ContainsAny(dir, "*?[") {
+			dirs[dir] = true
+			continue
+		}
+		// The dirs matched the pattern, the new dirs are watched after reload
+		matches, _ := filepath.Glob(pattern)
+		for _, file := range matches {
+			dirs[filepath.Dir(file)] = true
+		}
+	}
+
+	for _, dir := range includeWatcher.WatchList() {
+		if !dirs[dir] {
+			_ = includeWatcher.Remove(dir)
+		}
+	}
+	for dir := range dirs {
+		if err := includeWatcher.Add(dir); err != nil {
+			logger.Warnf("Watch include config %s failed: %v", dir, err)
+		}
+	}
+}
+
+// handleIncludeEvents reloads the config when the files matched the `include` patterns changed
+func handleIncludeEvents(watcher *fsnotify.Watcher) {
+	logger := logger.Tag("Config")
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 || !isIncluded(event.Name) {
+				continue
+			}
+
+			logger.Info("Include config file changed:", event.Name)
+			if err := reloadConfig(); err != nil {
+				logger.Error(err.Error())
+			}
+			watchIncludes()
+			onConfigChanged(event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Error(err)
+		}
+	}
+}
+
+// isIncluded returns true when the file matches any of the `include` patterns
+func isIncluded(file string) bool {
+	wLock.Lock()
+	defer wLock.Unlock()
+
+	for _, pattern := range includePatterns {
+		if ok, _ := filepath.Match(pattern, file); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func loadModel(key string) (ModelConfig, error) {
 	var model ModelConfig
 	model.Name = key
@@ -280,9 +626,27 @@ func loadModel(key string) (ModelConfig, error) {
 	model.BeforeScript = model.Viper.GetString("before_script")
 	model.AfterScript = model.Viper.GetString("after_script")
 
-	loadScheduleConfig(&model)
+	model.Viper.SetDefault("on_overlap", "queue")
+	model.OnOverlap = model.Viper.GetString("on_overlap")
+	switch model.OnOverlap {
+	case "skip", "queue", "cancel_previous":
+	default:
+		return ModelConfig{}, fmt.Errorf("model %s on_overlap must be one of skip, queue, cancel_previous", model.Name)
+	}
+
+	timeout, err := helper.ParseTimeout(model.Viper.GetString("timeout"))
+	if err != nil {
+		return ModelConfig{}, fmt.Errorf("model %s %v", model.Name, err)
+	}
+	model.Timeout = timeout
+
+	if err := loadScheduleConfig(&model); err != nil {
+		return ModelConfig{}, err
+	}
 	loadDatabasesConfig(&model)
-	loadStoragesConfig(&model)
+	if err := loadStoragesConfig(&model); err != nil {
+		return ModelConfig{}, err
+	}
 
 	if len(model.Databases) == 0 && model.Archive == nil {
 		return ModelConfig{}, fmt.Errorf("model %s must configure databases or archive", model.Name)
@@ -292,16 +656,24 @@ func loadModel(key string) (ModelConfig, error) {
 		return ModelConfig{}, fmt.Errorf("no storage found in model %s", model.Name)
 	}
 
-	loadNotifiersConfig(&model)
+	if err := loadNotifiersConfig(&model); err != nil {
+		return ModelConfig{}, err
+	}
 
 	return model, nil
 }
 
-func loadScheduleConfig(model *ModelConfig) {
+func loadScheduleConfig(model *ModelConfig) error {
 	subViper := model.Viper.Sub("schedule")
 	model.Schedule = ScheduleConfig{Enabled: false}
 	if subViper == nil {
-		return
+		return nil
+	}
+
+	subViper.SetDefault("grace", "1h")
+	grace, err := time.ParseDuration(subViper.GetString("grace"))
+	if err != nil || grace < 0 {
+		return fmt.Errorf("model %s invalid schedule.grace: %s", model.Name, subViper.GetString("grace"))
 	}
 
 	model.Schedule = ScheduleConfig{
@@ -309,7 +681,9 @@ func loadScheduleConfig(model *ModelConfig) {
 		Cron:    subViper.GetString("cron"),
 		Every:   subViper.GetString("every"),
 		At:      subViper.GetString("at"),
+		Grace:   grace,
 	}
+	return nil
 }
 
 func loadDatabasesConfig(model *ModelConfig) {
@@ -328,18 +702,17 @@ func loadDatabasesConfig(model *ModelConfig) {
 	}
 }
 
-func loadStoragesConfig(model *ModelConfig) {
-	storageConfigs := map[string]SubConfig{}
-
+func loadStoragesConfig(model *ModelConfig) error {
 	model.DefaultStorage = model.Viper.GetString("default_storage")
 
-	subViper := model.Viper.Sub("storages")
-	if subViper == nil {
-		model.Storages = storageConfigs
-		return
+	names, storageVipers, err := loadTemplatedConfigs(model.Viper, "storages")
+	if err != nil {
+		return fmt.Errorf("model %s: %v", model.Name, err)
 	}
-	for key := range model.Viper.GetStringMap("storages") {
-		storageViper := subViper.Sub(key)
+
+	storageConfigs := map[string]SubConfig{}
+	for _, key := range names {
+		storageViper := storageVipers[key]
 		storageConfigs[key] = SubConfig{
 			Name:  key,
 			Type:  storageViper.GetString("type"),
@@ -353,22 +726,97 @@ func loadStoragesConfig(model *ModelConfig) {
 	}
 	model.Storages = storageConfigs
 
+	return nil
 }
 
-func loadNotifiersConfig(model *ModelConfig) {
-	subViper := model.Viper.Sub("notifiers")
-	if subViper == nil {
-		return
+func loadNotifiersConfig(model *ModelConfig) error {
+	names, notifierVipers, err := loadTemplatedConfigs(model.Viper, "notifiers")
+	if err != nil {
+		return fmt.Errorf("model %s: %v", model.Name, err)
 	}
+	if len(names) == 0 {
+		return nil
+	}
+
 	model.Notifiers = map[string]SubConfig{}
-	for key := range model.Viper.GetStringMap("notifiers") {
-		dbViper := subViper.Sub(key)
+	for _, key := range names {
+		notifierViper := notifierVipers[key]
 		model.Notifiers[key] = SubConfig{
 			Name:  key,
-			Type:  dbViper.GetString("type"),
-			Viper: dbViper,
+			Type:  notifierViper.GetString("type"),
+			Viper: notifierViper,
+		}
+	}
+
+	return nil
+}
+
+// loadTemplatedConfigs loads the `storages` or `notifiers` of model,
+// they can reference the top-level definitions (templates) of the same key by name:
+//
+//	storages: [offsite_s3, local]
+//
+// or override the keys of the template in a map:
+//
+//	storages:
+//	  offsite_s3:
+//	    path: backups/app
+//
+// It returns the names in the order of config, and the merged viper of each name.
+func loadTemplatedConfigs(modelViper *viper.Viper, key string) ([]string, map[string]*viper.Viper, error) {
+	var names []string
+	overrides := map[string]map[string]any{}
+
+	switch value := modelViper.Get(key).(type) {
+	case nil:
+		return nil, nil, nil
+	case []any:
+		for _, name := range value {
+			names = append(names, strings.ToLower(cast.ToString(name)))
+		}
+	case map[string]any:
+		for name, entry := range value {
+			names = append(names, name)
+			if entry != nil {
+				entryMap, err := cast.ToStringMapE(entry)
+				if err != nil {
+					return nil, nil, fmt.Errorf("%s.%s must be a map", key, name)
+				}
+				overrides[name] = entryMap
+			}
 		}
+		sort.Strings(names)
+	default:
+		return nil, nil, fmt.Errorf("%s must be a list or a map", key)
 	}
+
+	vipers := map[string]*viper.Viper{}
+	for _, name := range names {
+		v := viper.New()
+		template := viper.Get(key + "." + name)
+		if template == nil && overrides[name] == nil {
+			return nil, nil, fmt.Errorf("%s.%s is not defined in model or top-level %s", key, name, key)
+		}
+
+		if template != nil {
+			templateMap, err := cast.ToStringMapE(template)
+			if err != nil {
+				return nil, nil, fmt.Errorf("top-level %s.%s must be a map", key, name)
+			}
+			if err := v.MergeConfigMap(templateMap); err != nil {
+				return nil, nil, err
+			}
+		}
+		if overrides[name] != nil {
+			if err := v.MergeConfigMap(overrides[name]); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		vipers[name] = v
+	}
+
+	return names, vipers, nil
 }
 
 // GetModelConfigByName get model config by name
@@ -2,10 +2,16 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/longbridgeapp/assert"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+
+	"github.com/gobackup/gobackup/metrics"
+	"github.com/gobackup/gobackup/tracing"
 )
 
 var (
@@ -25,6 +31,11 @@ func TestModelsLength(t *testing.T) {
 	assert.Equal(t, len(Models), 5)
 }
 
+func Test_reloadConfig(t *testing.T) {
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.ConfigReloadSuccess))
+	assert.True(t, testutil.ToFloat64(metrics.ConfigReloadTimestamp) > 0)
+}
+
 func TestModel(t *testing.T) {
 	model := GetModelConfigByName("base_test")
 
@@ -88,6 +99,12 @@ func TestModel(t *testing.T) {
 	schedule := model.Schedule
 	assert.Equal(t, true, schedule.Enabled)
 	assert.Equal(t, "5 4 * * sun", schedule.Cron)
+	assert.Equal(t, 30*time.Minute, schedule.Grace)
+
+	assert.Equal(t, "queue", model.OnOverlap)
+	assert.Equal(t, 1, MaxConcurrentModels)
+	assert.Equal(t, false, LockFile)
+	assert.Equal(t, time.Duration(0), ShutdownTimeout)
 }
 
 func Test_otherModels(t *testing.T) {
@@ -102,6 +119,7 @@ func Test_otherModels(t *testing.T) {
 	assert.Equal(t, "", schedule.Cron)
 	assert.Equal(t, "1day", schedule.Every)
 	assert.Equal(t, "0:30", schedule.At)
+	assert.Equal(t, time.Hour, schedule.Grace)
 
 	model = GetModelConfigByName("test_model")
 	assert.Equal(t, false, model.Schedule.Enabled)
@@ -149,6 +167,60 @@ func TestWebConfig(t *testing.T) {
 	assert.Equal(t, Web.Password, "123456")
 }
 
+func TestWebAuthConfig(t *testing.T) {
+	assert.True(t, Web.AuthEnabled())
+	assert.Equal(t, 2, len(Web.Users))
+	assert.Equal(t, WebUser{Name: "gobackup", Password: "123456", Scopes: []string{ScopeAdmin}}, Web.Users[0])
+	assert.Equal(t, WebUser{Name: "oncall", Password: "oncall-password", Scopes: []string{ScopeRead}}, Web.Users[1])
+	assert.Equal(t, []WebUser{{Name: "ci", Token: "ci-token", Scopes: []string{ScopePerform, ScopeRead}, Models: []string{"test_model"}}}, Web.Tokens)
+	assert.Nil(t, Web.OIDC)
+
+	assert.True(t, Web.Users[0].HasScope(ScopeDownload))
+	assert.False(t, Web.Users[1].HasScope(ScopeDownload))
+	assert.True(t, Web.Tokens[0].CanAccess("test_model"))
+	assert.False(t, Web.Tokens[0].CanAccess("base_test"))
+	assert.False(t, Web.Tokens[0].CanAccessAll())
+
+	assert.EqualError(t, checkScopes("web.tokens.ci", nil), "web.tokens.ci: scopes is required")
+	assert.EqualError(t, checkScopes("web.tokens.ci", []string{"write"}), `web.tokens.ci: invalid scope "write", must be one of [read perform download admin]`)
+}
+
+func Test_loadWebTLSConfig(t *testing.T) {
+	v := viper.New()
+	v.Set("cert_file", "/etc/gobackup/cert.pem")
+	v.Set("key_file", "/etc/gobackup/key.pem")
+	tlsConfig, err := loadWebTLSConfig(v)
+	assert.NoError(t, err)
+	assert.Equal(t, &WebTLSConfig{CertFile: "/etc/gobackup/cert.pem", KeyFile: "/etc/gobackup/key.pem", ClientAuth: "require", Clients: []WebUser{}}, tlsConfig)
+
+	v.Set("clients", map[string]any{"platform": map[string]any{"scopes": []string{"read"}, "models": []string{"app"}}})
+	_, err = loadWebTLSConfig(v)
+	assert.EqualError(t, err, "web.tls.client_ca_file is required by web.tls.clients")
+
+	v.Set("client_ca_file", "/etc/gobackup/ca.pem")
+	tlsConfig, err = loadWebTLSConfig(v)
+	assert.NoError(t, err)
+	assert.Equal(t, []WebUser{{Name: "platform", Scopes: []string{ScopeRead}, Models: []string{"app"}}}, tlsConfig.Clients)
+
+	v.Set("client_auth", "optional")
+	_, err = loadWebTLSConfig(v)
+	assert.EqualError(t, err, "web.tls.client_auth must be one of require, verify_if_given")
+
+	v = viper.New()
+	v.Set("cert_file", "/etc/gobackup/cert.pem")
+	_, err = loadWebTLSConfig(v)
+	assert.EqualError(t, err, "web.tls.cert_file and web.tls.key_file must be set together")
+
+	_, err = loadWebTLSConfig(nil)
+	assert.EqualError(t, err, "web.tls.cert_file and web.tls.key_file are required, or enable web.tls.self_signed")
+
+	v = viper.New()
+	v.Set("self_signed", true)
+	tlsConfig, err = loadWebTLSConfig(v)
+	assert.NoError(t, err)
+	assert.True(t, tlsConfig.SelfSigned)
+}
+
 func TestInitWithNotExistsConfigFile(t *testing.T) {
 	err := Init("config/path/not-exist.yml")
 	assert.NotNil(t, err)
@@ -263,6 +335,21 @@ func TestInitWithEmptyDatabasesOrStorages(t *testing.T) {
 			expectErr:          true,
 			expectedErrContain: "model myjob must configure databases or archive",
 		},
+		{
+			name: "invalid_on_overlap",
+			configContent: `models:
+  myjob:
+    on_overlap: wait
+    storages:
+      local:
+        type: local
+    archive:
+      includes:
+        - /etc/hosts
+`,
+			expectErr:          true,
+			expectedErrContain: "model myjob on_overlap must be one of skip, queue, cancel_previous",
+		},
 		{
 			name: "archive_only_with_storage",
 			configContent: `models:
@@ -338,3 +425,180 @@ func updateFile(path string) error {
 
 	return nil
 }
+
+func TestInitWithIncludesAndTemplates(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "conf.d"), 0755))
+
+	err := os.WriteFile(filepath.Join(dir, "gobackup.yml"), []byte(`include:
+  - conf.d/*.yml
+storages:
+  offsite_s3:
+    type: s3
+    bucket: backups
+    path: default
+    keep: 10
+  local:
+    type: local
+    path: /tmp/backups
+notifiers:
+  slack:
+    type: slack
+    url: https://hooks.slack.com/services/xxx
+models:
+  app:
+    archive:
+      includes:
+        - /etc/hosts
+    storages: [offsite_s3, local]
+    notifiers: [slack]
+`), 0644)
+	assert.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(dir, "conf.d", "team.yml"), []byte(`models:
+  team:
+    archive:
+      includes:
+        - /etc/hosts
+    storages:
+      offsite_s3:
+        path: team
+      other:
+        type: local
+        path: /tmp/other
+`), 0644)
+	assert.NoError(t, err)
+
+	err = Init(filepath.Join(dir, "gobackup.yml"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(Models))
+
+	model := GetModelConfigByName("app")
+	assert.Equal(t, "offsite_s3", model.DefaultStorage)
+	assert.Equal(t, "s3", model.Storages["offsite_s3"].Type)
+	assert.Equal(t, "default", model.Storages["offsite_s3"].Viper.GetString("path"))
+	assert.Equal(t, "local", model.Storages["local"].Type)
+	assert.Equal(t, "slack", model.Notifiers["slack"].Type)
+
+	model = GetModelConfigByName("team")
+	assert.Equal(t, "s3", model.Storages["offsite_s3"].Type)
+	assert.Equal(t, "team", model.Storages["offsite_s3"].Viper.GetString("path"))
+	assert.Equal(t, 10, model.Storages["offsite_s3"].Viper.GetInt("keep"))
+	assert.Equal(t, "/tmp/other", model.Storages["other"].Viper.GetString("path"))
+
+	// Template must be defined
+	err = os.WriteFile(filepath.Join(dir, "conf.d", "team.yml"), []byte(`models:
+  team:
+    archive:
+      includes:
+        - /etc/hosts
+    storages: [not_exist]
+`), 0644)
+	assert.NoError(t, err)
+
+	err = Init(filepath.Join(dir, "gobackup.yml"))
+	assert.EqualError(t, err, "load model team: model team: storages.not_exist is not defined in model or top-level storages")
+}
+
+func TestWatchIncludesToReload(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "conf.d"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "gobackup.yml"), []byte(`include:
+  - conf.d/*.yml
+models:
+  app:
+    archive:
+      includes:
+        - /etc/hosts
+    storages:
+      local:
+        type: local
+        path: /tmp/backups
+`), 0644))
+
+	assert.NoError(t, Init(filepath.Join(dir, "gobackup.yml")))
+	assert.Equal(t, 1, len(Models))
+	assert.True(t, isIncluded(filepath.Join(dir, "conf.d", "team.yml")))
+	assert.False(t, isIncluded(filepath.Join(dir, "gobackup.yml")))
+
+	// The new included file is loaded
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "conf.d", "team.yml"), []byte(`models:
+  team:
+    archive:
+      includes:
+        - /etc/hosts
+    storages:
+      local:
+        type: local
+        path: /tmp/team
+`), 0644))
+
+	for i := 0; i < 100 && GetModelConfigByName("team") == nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NotNil(t, GetModelConfigByName("team"))
+}
+
+func Test_loadTracingConfig(t *testing.T) {
+	defer viper.Set("tracing", nil)
+
+	viper.Set("tracing.exporter", "zipkin")
+	assert.EqualError(t, loadTracingConfig(), "tracing.exporter must be one of otlp_grpc, otlp_http, stdout")
+
+	viper.Set("tracing.exporter", "otlp_http")
+	viper.Set("tracing.endpoint", "otel-collector:4318")
+	viper.Set("tracing.insecure", true)
+	viper.Set("tracing.headers", map[string]string{"authorization": "Bearer xxx"})
+	assert.NoError(t, loadTracingConfig())
+	defer tracing.Shutdown()
+	assert.Equal(t, "otlp_http", Tracing.Exporter)
+	assert.Equal(t, "otel-collector:4318", Tracing.Endpoint)
+	assert.True(t, Tracing.Insecure)
+	assert.Equal(t, "Bearer xxx", Tracing.Headers["authorization"])
+}
+
+func Test_loadMetricsConfig(t *testing.T) {
+	defer viper.Set("metrics", nil)
+
+	assert.NoError(t, loadMetricsConfig())
+	assert.Nil(t, Metrics.PushGateway)
+	assert.Equal(t, "", Metrics.Textfile)
+
+	viper.Set("metrics.textfile", "/var/lib/node_exporter/gobackup.txt")
+	assert.EqualError(t, loadMetricsConfig(), "metrics.textfile must have the .prom extension: /var/lib/node_exporter/gobackup.txt")
+
+	viper.Set("metrics.textfile", "/var/lib/node_exporter/gobackup.prom")
+	viper.Set("metrics.push_gateway.job", "backup")
+	assert.EqualError(t, loadMetricsConfig(), "metrics.push_gateway.url is required")
+
+	viper.Set("metrics.push_gateway.url", "http://pushgateway:9091")
+	viper.Set("metrics.push_gateway.grouping", map[string]string{"model": "foo"})
+	assert.EqualError(t, loadMetricsConfig(), "metrics.push_gateway.grouping: model is reserved")
+
+	viper.Set("metrics.push_gateway.grouping", map[string]string{"cluster": "prod"})
+	assert.NoError(t, loadMetricsConfig())
+	assert.Equal(t, "/var/lib/node_exporter/gobackup.prom", Metrics.Textfile)
+	assert.Equal(t, "http://pushgateway:9091", Metrics.PushGateway.URL)
+	assert.Equal(t, "backup", Metrics.PushGateway.Job)
+	assert.Equal(t, "prod", Metrics.PushGateway.Grouping["cluster"])
+}
+
+func Test_loadLeaderElectionConfig(t *testing.T) {
+	defer viper.Set("leader_election", nil)
+
+	assert.NoError(t, loadLeaderElectionConfig())
+	assert.Nil(t, LeaderElection)
+
+	viper.Set("leader_election.lease_duration", "soon")
+	assert.EqualError(t, loadLeaderElectionConfig(), "leader_election.lease_duration: invalid timeout: soon")
+
+	viper.Set("leader_election.lease", "gobackup-prod")
+	viper.Set("leader_election.lease_duration", 30)
+	viper.Set("leader_election.renew_deadline", "20s")
+	assert.NoError(t, loadLeaderElectionConfig())
+	assert.Equal(t, "gobackup-prod", LeaderElection.Lease)
+	assert.Equal(t, "", LeaderElection.Namespace)
+	assert.Equal(t, 30*time.Second, LeaderElection.LeaseDuration)
+	assert.Equal(t, 20*time.Second, LeaderElection.RenewDeadline)
+	assert.Equal(t, time.Duration(0), LeaderElection.RetryPeriod)
+}
@@ -0,0 +1,593 @@
+package config
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+)
+
+// SchemaID is the $id of the gobackup.yml JSON Schema
+const SchemaID = "https://gobackup.github.io/schema/gobackup.json"
+
+type schemaKeys map[string]map[string]any
+
+func stringKey(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+func boolKey(description string) map[string]any {
+	return map[string]any{"type": "boolean", "description": description}
+}
+
+func intKey(description string) map[string]any {
+	return map[string]any{"type": "integer", "description": description}
+}
+
+// portKey allows integer or string, e.g. `port: 5432` or `port: $PG_PORT`
+func portKey(description string) map[string]any {
+	return map[string]any{"type": []string{"integer", "string"}, "description": description}
+}
+
+// durationKey allows seconds or duration string, e.g. `timeout: 3600` or `timeout: 1h30m`
+func durationKey(description string) map[string]any {
+	return map[string]any{"type": []string{"integer", "string"}, "description": description}
+}
+
+func listKey(description string) map[string]any {
+	return map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": description}
+}
+
+func enumKey(description string, values ...string) map[string]any {
+	return map[string]any{"type": "string", "enum": values, "description": description}
+}
+
+// scopesKey of web users and tokens
+var scopesKey = map[string]any{
+	"type":        "array",
+	"items":       map[string]any{"type": "string", "enum": scopes},
+	"description": "read, perform, download, admin",
+}
+
+func mapKey(description string) map[string]any {
+	return map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}, "description": description}
+}
+
+var (
+	databaseCommonKeys = schemaKeys{
+		"before_script": stringKey("Script to run before dump"),
+		"after_script":  stringKey("Script to run after dump succeeded"),
+		"on_exit":       stringKey("Script to run after dump, whether it succeeded or not"),
+		"verify":        boolKey("Verify the dump before archiving, default: true"),
+		"min_size":      stringKey("Minimum size of the dump when verify, e.g. 1KB"),
+		"timeout":       durationKey("Timeout of dump, seconds or duration, e.g. 30m"),
+	}
+
+	databaseKeys = map[string]schemaKeys{
+		"mysql": {
+			"host":           stringKey("Default: 127.0.0.1"),
+			"port":           portKey("Default: 3306"),
+			"socket":         stringKey("Unix socket path"),
+			"database":       stringKey("Database name"),
+			"username":       stringKey("Default: root"),
+			"password":       stringKey(""),
+			"tables":         listKey("Only dump these tables"),
+			"exclude_tables": listKey("Skip these tables"),
+			"all_databases":  boolKey("Dump all databases"),
+			"args":           stringKey("Extra arguments of mysqldump"),
+		},
+		"mariadb": {
+			"host":          stringKey("Default: 127.0.0.1"),
+			"port":          portKey("Default: 3306"),
+			"socket":        stringKey("Unix socket path"),
+			"database":      stringKey("Database name"),
+			"username":      stringKey("Default: root"),
+			"password":      stringKey(""),
+			"all_databases": boolKey("Backup all databases"),
+			"args":          stringKey("Extra arguments of mariadb-backup"),
+		},
+		"postgresql": {
+			"host":           stringKey("Default: localhost"),
+			"port":           portKey("Default: 5432"),
+			"socket":         stringKey("Unix socket directory"),
+			"database":       stringKey("Database name"),
+			"username":       stringKey(""),
+			"password":       stringKey(""),
+			"tables":         listKey("Only dump these tables"),
+			"exclude_tables": listKey("Skip these tables"),
+			"all_databases":  boolKey("Dump all databases with pg_dumpall"),
+			"compress":       boolKey("Use pg_dump custom format"),
+			"args":           stringKey("Extra arguments of pg_dump"),
+		},
+		"redis": {
+			"mode":        enumKey("Default: copy", "copy", "sync"),
+			"host":        stringKey("Default: 127.0.0.1"),
+			"port":        portKey("Default: 6379"),
+			"socket":      stringKey("Unix socket path"),
+			"password":    stringKey(""),
+			"rdb_path":    stringKey("RDB file path for copy mode"),
+			"invoke_save": boolKey("Run SAVE before copy"),
+			"args":        stringKey("Extra arguments of redis-cli"),
+		},
+		"mongodb": {
+			"uri":                   stringKey("Connection string, overrides host, port and auth"),
+			"host":                  stringKey("Default: 127.0.0.1"),
+			"port":                  portKey("Default: 27017"),
+			"database":              stringKey("Database name"),
+			"username":              stringKey(""),
+			"password":              stringKey(""),
+			"authdb":                stringKey("Authentication database"),
+			"exclude_tables":        listKey("Skip these collections"),
+			"exclude_tables_prefix": listKey("Skip collections with these prefixes"),
+			"oplog":                 boolKey("Use --oplog"),
+			"all_databases":         boolKey("Dump all databases"),
+			"args":                  stringKey("Extra arguments of mongodump"),
+		},
+		"sqlite": {
+			"path":       stringKey("Database file path"),
+			"mode":       enumKey("Default: dump", "dump", "backup", "vacuum"),
+			"checkpoint": enumKey("WAL checkpoint before backup", "passive", "full", "restart", "truncate"),
+		},
+		"mssql": {
+			"host":                     stringKey("Default: 127.0.0.1"),
+			"port":                     portKey("Default: 1433"),
+			"database":                 stringKey("Database name"),
+			"username":                 stringKey("Default: sa"),
+			"password":                 stringKey(""),
+			"trust_server_certificate": boolKey(""),
+			"all_databases":            boolKey("Export all databases"),
+			"skip_databases":           listKey("Skip these databases when all_databases"),
+			"args":                     stringKey("Extra arguments of sqlpackage"),
+		},
+		"influxdb2": {
+			"host":          stringKey("e.g. http://127.0.0.1:8086"),
+			"token":         stringKey("API token"),
+			"org":           stringKey(""),
+			"org_id":        stringKey(""),
+			"bucket":        stringKey(""),
+			"bucket_id":     stringKey(""),
+			"all_databases": boolKey(""),
+			"skip_verify":   boolKey("Skip TLS verification"),
+			"http_debug":    boolKey(""),
+		},
+		"etcd": {
+			"endpoint":  stringKey(""),
+			"endpoints": listKey(""),
+			"args":      stringKey("Extra arguments of etcdctl"),
+		},
+		"firebird": {
+			"host":     stringKey("Default: 127.0.0.1"),
+			"port":     portKey("Default: 3050"),
+			"database": stringKey("Database path or alias"),
+			"username": stringKey("Default: sysdba"),
+			"password": stringKey("Default: masterkey"),
+			"role":     stringKey(""),
+			"args":     stringKey("Extra arguments of gbak"),
+		},
+		"clickhouse": {
+			"mode":        enumKey("Default: backup", "backup", "clickhouse-backup"),
+			"host":        stringKey("Default: 127.0.0.1"),
+			"port":        portKey("Default: 9000"),
+			"username":    stringKey("Default: default"),
+			"password":    stringKey(""),
+			"database":    stringKey("Database name"),
+			"disk":        stringKey("Backup disk for backup mode"),
+			"disk_path":   stringKey("Local path of the backup disk"),
+			"backup_path": stringKey("Local backup path of clickhouse-backup"),
+			"args":        stringKey("Extra arguments"),
+		},
+		"cassandra": {
+			"host":      stringKey("Default: 127.0.0.1"),
+			"port":      portKey("JMX port, default: 7199"),
+			"username":  stringKey(""),
+			"password":  stringKey(""),
+			"keyspaces": listKey("Empty to snapshot all keyspaces"),
+			"data_dir":  stringKey("Default: /var/lib/cassandra/data"),
+			"args":      stringKey("Extra arguments of nodetool"),
+		},
+		"elasticsearch": {
+			"url":               stringKey("Default: http://127.0.0.1:9200"),
+			"username":          stringKey(""),
+			"password":          stringKey(""),
+			"repository":        stringKey("Snapshot repository, default: gobackup"),
+			"repository_path":   stringKey("Path of the repository, must be in path.repo"),
+			"create_repository": boolKey("Default: true"),
+			"indices":           stringKey("Default: *"),
+			"keep_snapshot":     boolKey("Keep the snapshot in repository after copy"),
+			"request_timeout":   durationKey("Timeout of HTTP requests, default: 1h"),
+		},
+	}
+
+	s3Keys = schemaKeys{
+		"bucket":            stringKey(""),
+		"region":            stringKey(""),
+		"path":              stringKey("Path prefix in bucket"),
+		"endpoint":          stringKey("Custom endpoint of S3 compatible service"),
+		"account_id":        stringKey("Account ID of Cloudflare R2"),
+		"access_key_id":     stringKey(""),
+		"secret_access_key": stringKey(""),
+		"access_key_secret": stringKey("Alias of secret_access_key"),
+		"token":             stringKey("Session token"),
+		"storage_class":     stringKey(""),
+		"max_retries":       intKey("Default: 3"),
+		"timeout":           intKey("Seconds, default: 300"),
+		"force_path_style":  boolKey(""),
+	}
+
+	sshKeys = schemaKeys{
+		"host":        stringKey(""),
+		"port":        portKey("Default: 22"),
+		"path":        stringKey(""),
+		"username":    stringKey("Default: current user"),
+		"password":    stringKey(""),
+		"private_key": stringKey("Default: ~/.ssh/id_rsa"),
+		"passphrase":  stringKey("Passphrase of private_key"),
+		"timeout":     portKey("Default: 300"),
+	}
+
+	storageCommonKeys = schemaKeys{
+		"keep": intKey("Number of backups to keep, 0 to keep all"),
+	}
+
+	storageKeys = map[string]schemaKeys{
+		"local": {
+			"path": stringKey("Directory to store backups"),
+		},
+		"ftp": {
+			"host":                 stringKey(""),
+			"port":                 portKey("Default: 21"),
+			"path":                 stringKey(""),
+			"username":             stringKey(""),
+			"password":             stringKey(""),
+			"timeout":              portKey("Default: 300"),
+			"tls":                  boolKey("Use implicit TLS"),
+			"explicit_tls":         boolKey("Use explicit TLS"),
+			"no_check_certificate": boolKey(""),
+		},
+		"scp":  sshKeys,
+		"sftp": sshKeys,
+		"webdav": {
+			"root":     stringKey("WebDAV server URL"),
+			"path":     stringKey(""),
+			"username": stringKey(""),
+			"password": stringKey(""),
+		},
+		"gcs": {
+			"bucket":           stringKey(""),
+			"path":             stringKey(""),
+			"credentials":      stringKey("Service account JSON"),
+			"credentials_file": stringKey("Service account JSON file"),
+			"timeout":          intKey("Seconds, default: 300"),
+		},
+		"azure": {
+			"account":       stringKey("Storage account name"),
+			"bucket":        stringKey("Alias of account"),
+			"container":     stringKey("Default: gobackup"),
+			"path":          stringKey(""),
+			"tenant_id":     stringKey(""),
+			"client_id":     stringKey(""),
+			"client_secret": stringKey(""),
+			"timeout":       intKey("Seconds, default: 300"),
+		},
+	}
+
+	// All S3 compatible services
+	s3StorageTypes = []string{"s3", "oss", "minio", "b2", "us3", "cos", "kodo", "r2", "spaces", "bos", "obs", "tos", "upyun"}
+
+	notifierCommonKeys = schemaKeys{
+		"on_success":       boolKey("Default: true"),
+		"on_failure":       boolKey("Default: true"),
+		"title_template":   stringKey("Go text/template of the notification title, with the report of run"),
+		"message_template": stringKey("Go text/template of the notification message, with the report of run"),
+		"notify_on":        enumKey("always: notify every run, change: notify the first failure and the recovery only. Default: always", "always", "change"),
+		"digest":           enumKey("daily: send the successful runs in one summary per day", "daily"),
+		"escalate_after":   intKey("Notify only after the number of consecutive failures, and the recovery from them"),
+	}
+
+	webhookKeys = schemaKeys{
+		"url": stringKey("Webhook URL"),
+	}
+
+	mailKeys = schemaKeys{
+		"from":  stringKey(""),
+		"to":    stringKey("Comma separated recipients"),
+		"token": stringKey("API token"),
+	}
+
+	notifierKeys = map[string]schemaKeys{
+		"mail": {
+			"from":       stringKey(""),
+			"to":         stringKey("Comma separated recipients"),
+			"host":       stringKey("SMTP host"),
+			"port":       portKey("Default: 25"),
+			"username":   stringKey(""),
+			"password":   stringKey(""),
+			"tls":        boolKey(""),
+			"attach_log": boolKey("Attach the log of run to the failure notification"),
+		},
+		"webhook": {
+			"url":     stringKey(""),
+			"method":  enumKey("Default: POST", "GET", "POST", "PUT", "PATCH"),
+			"headers": mapKey("HTTP headers"),
+		},
+		"googlechat": {
+			"url":     stringKey(""),
+			"method":  enumKey("Default: POST", "GET", "POST", "PUT", "PATCH"),
+			"headers": mapKey("HTTP headers"),
+		},
+		"feishu":       webhookKeys,
+		"dingtalk":     webhookKeys,
+		"discord":      webhookKeys,
+		"slack":        webhookKeys,
+		"wxwork":       webhookKeys,
+		"healthchecks": webhookKeys,
+		"github": {
+			"url":   stringKey("Issue comments API URL"),
+			"token": stringKey("Personal access token"),
+		},
+		"telegram": {
+			"token":             stringKey("Bot token"),
+			"chat_id":           stringKey(""),
+			"message_thread_id": stringKey(""),
+			"endpoint":          stringKey("Default: https://api.telegram.org"),
+		},
+		"postmark": mailKeys,
+		"sendgrid": mailKeys,
+		"resend":   mailKeys,
+		"ses": {
+			"from":              stringKey(""),
+			"to":                stringKey("Comma separated recipients"),
+			"region":            stringKey(""),
+			"access_key_id":     stringKey(""),
+			"secret_access_key": stringKey(""),
+			"token":             stringKey("Session token"),
+		},
+	}
+
+	compressTypes = []string{
+		"tar",
+		"gz", "tgz", "taz", "tar.gz",
+		"Z", "taZ", "tar.Z",
+		"bz2", "tbz", "tbz2", "tar.bz2",
+		"lz", "tar.lz",
+		"lzma", "tlz", "tar.lzma",
+		"lzo", "tar.lzo",
+		"xz", "txz", "tar.xz",
+		"zst", "tzst", "tar.zst",
+	}
+)
+
+func init() {
+	databaseKeys["scylladb"] = databaseKeys["cassandra"]
+	databaseKeys["opensearch"] = databaseKeys["elasticsearch"]
+
+	for _, storageType := range s3StorageTypes {
+		storageKeys[storageType] = s3Keys
+	}
+}
+
+// adapterSchema returns the schema of a `type` switched config,
+// each type only allows the common keys and its own keys.
+func adapterSchema(common schemaKeys, types map[string]schemaKeys) map[string]any {
+	names := slices.Sorted(maps.Keys(types))
+
+	allOf := []any{}
+	for _, name := range names {
+		properties := map[string]any{"type": map[string]any{"const": name}}
+		for key, value := range common {
+			properties[key] = value
+		}
+		for key, value := range types[name] {
+			properties[key] = value
+		}
+
+		allOf = append(allOf, map[string]any{
+			"if": map[string]any{
+				"properties": map[string]any{"type": map[string]any{"const": name}},
+				"required":   []string{"type"},
+			},
+			"then": map[string]any{
+				"properties":           properties,
+				"additionalProperties": false,
+			},
+		})
+	}
+
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"type": map[string]any{"type": "string", "enum": names},
+		},
+		"allOf": allOf,
+	}
+}
+
+// requireType returns the schema of adapter that must set `type`
+func requireType(schema map[string]any) map[string]any {
+	result := maps.Clone(schema)
+	result["required"] = []string{"type"}
+	return result
+}
+
+// templatedMap allows a list of top-level template names,
+// or a map of configs, the `type` can be omitted when overriding a template with the same name.
+func templatedMap(description string, item map[string]any) map[string]any {
+	return map[string]any{
+		"description": description,
+		"anyOf": []any{
+			listKey("Names of top-level templates"),
+			namedMap("", item),
+		},
+	}
+}
+
+func namedMap(description string, item map[string]any) map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"description":          description,
+		"additionalProperties": item,
+	}
+}
+
+func closedObject(description string, properties schemaKeys) map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"description":          description,
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// Schema returns the JSON Schema of gobackup.yml
+func Schema() map[string]any {
+	storage := adapterSchema(storageCommonKeys, storageKeys)
+	notifier := adapterSchema(notifierCommonKeys, notifierKeys)
+
+	model := map[string]any{
+		"type":     "object",
+		"required": []string{"storages"},
+		"properties": map[string]any{
+			"description":     stringKey(""),
+			"before_script":   stringKey("Script to run before backup"),
+			"after_script":    stringKey("Script to run after backup"),
+			"default_storage": stringKey("Default: the first storage"),
+			"on_overlap":      enumKey("When the model is performing, default: queue", "skip", "queue", "cancel_previous"),
+			"timeout":         durationKey("Timeout of the whole backup, seconds or duration, e.g. 2h"),
+			"schedule": closedObject("Backup schedule", schemaKeys{
+				"cron":  stringKey("Cron expression, e.g. 0 0 * * *"),
+				"every": stringKey("Interval, e.g. 1day, 12h"),
+				"at":    stringKey("Time of day with every, e.g. 04:05"),
+				"grace": stringKey("Grace after the expected time of next backup before it's reported as missed, default: 1h"),
+			}),
+			"databases": namedMap("Databases to backup", requireType(adapterSchema(databaseCommonKeys, databaseKeys))),
+			"storages":  templatedMap("Storages to upload the backup", storage),
+			"notifiers": templatedMap("Notifiers of backup result", notifier),
+			"compress_with": closedObject("", schemaKeys{
+				"type":            enumKey("Default: tar", compressTypes...),
+				"filename_format": stringKey("Go time layout, default: 2006.01.02.15.04.05"),
+				"args":            stringKey("Extra arguments of tar"),
+			}),
+			"encrypt_with": closedObject("", schemaKeys{
+				"type":     enumKey("", "openssl"),
+				"password": stringKey(""),
+				"chiper":   stringKey("Default: aes-256-cbc"),
+				"salt":     boolKey("Default: true"),
+				"base64":   boolKey("Default: false"),
+				"args":     stringKey("Extra arguments of openssl"),
+			}),
+			"split_with": closedObject("", schemaKeys{
+				"chunk_size":       stringKey("e.g. 1G"),
+				"suffix_length":    intKey("Default: 3"),
+				"numeric_suffixes": boolKey("Default: true"),
+			}),
+			"archive": closedObject("Files to backup", schemaKeys{
+				"includes": listKey(""),
+				"excludes": listKey(""),
+			}),
+		},
+		"additionalProperties": false,
+	}
+
+	return map[string]any{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"$id":     SchemaID,
+		"title":   "GoBackup config",
+		"type":    "object",
+		"required": []string{
+			"models",
+		},
+		"properties": map[string]any{
+			"workdir":               stringKey("Working directory for temp files, default: system temp dir"),
+			"max_concurrent_models": intKey("Number of models can be performed at the same time, default: 1"),
+			"lock_file":             boolKey("Use file locks in ~/.gobackup/locks, to prevent performing a model by multiple processes"),
+			"shutdown_timeout":      durationKey("Max time to wait for the performing models on `quit`, default: no limit"),
+			"include": map[string]any{
+				"description": "Glob patterns of config files to merge, relative to this file",
+				"anyOf":       []any{stringKey(""), listKey("")},
+			},
+			"storages":  namedMap("Storage templates for models", requireType(storage)),
+			"notifiers": namedMap("Notifier templates for models", requireType(notifier)),
+			"models":    namedMap("Backup models", model),
+			"web": closedObject("Web UI and API", schemaKeys{
+				"enabled":  boolKey("Default: true"),
+				"host":     stringKey("Default: 0.0.0.0"),
+				"port":     portKey("Default: 2703"),
+				"username": stringKey("Basic auth username of admin user"),
+				"password": stringKey("Basic auth password of admin user"),
+				"users": namedMap("Users of basic auth", closedObject("", schemaKeys{
+					"password": stringKey("Plain text or bcrypt hash"),
+					"scopes":   scopesKey,
+					"models":   listKey("Models can be accessed, default: all"),
+				})),
+				"tokens": namedMap("API tokens, in `Authorization: Bearer <token>` header", closedObject("", schemaKeys{
+					"token":  stringKey("Token"),
+					"scopes": scopesKey,
+					"models": listKey("Models can be accessed, default: all"),
+				})),
+				"oidc": closedObject("OpenID Connect login of web UI", schemaKeys{
+					"issuer":        stringKey("Issuer URL, e.g. https://accounts.google.com"),
+					"client_id":     stringKey(""),
+					"client_secret": stringKey(""),
+					"redirect_url":  stringKey("URL of /auth/callback, e.g. https://backup.example.com/auth/callback"),
+					"users": map[string]any{
+						"type":        "array",
+						"description": "Users can login by email",
+						"items": closedObject("", schemaKeys{
+							"email":  stringKey(""),
+							"scopes": scopesKey,
+							"models": listKey("Models can be accessed, default: all"),
+						}),
+					},
+				}),
+				"tls": closedObject("Serve over HTTPS", schemaKeys{
+					"cert_file":      stringKey("PEM certificate, reloaded when changed"),
+					"key_file":       stringKey("PEM private key, reloaded when changed"),
+					"self_signed":    boolKey("Generate a self-signed certificate in ~/.gobackup/tls when cert_file is not set"),
+					"client_ca_file": stringKey("PEM CA to verify the client certificates (mutual TLS)"),
+					"client_auth":    enumKey("Default: require", "require", "verify_if_given"),
+					"clients": namedMap("Clients by the Common Name of certificate", closedObject("", schemaKeys{
+						"scopes": scopesKey,
+						"models": listKey("Models can be accessed, default: all"),
+					})),
+				}),
+			}),
+			"log": closedObject("Logging", schemaKeys{
+				"format":      enumKey("Default: text", "text", "json"),
+				"max_size":    intKey("Max size in megabytes of gobackup.log before it gets rotated, default: 100"),
+				"max_backups": intKey("Number of rotated log files to keep, 0 to keep all, default: 10"),
+				"max_age":     intKey("Days to keep the rotated log files, 0 to keep all, default: 0"),
+				"compress":    boolKey("Compress the rotated log files with gzip"),
+			}),
+			"metrics": closedObject("Export metrics of `gobackup perform`", schemaKeys{
+				"push_gateway": closedObject("Push the metrics of model to Prometheus Pushgateway", schemaKeys{
+					"url":      stringKey("e.g. http://pushgateway:9091"),
+					"job":      stringKey("Default: gobackup"),
+					"grouping": mapKey("Grouping labels besides the model"),
+					"username": stringKey("Basic auth"),
+					"password": stringKey("Basic auth"),
+				}),
+				"textfile": stringKey("*.prom file for the textfile collector of node_exporter, the series of each model are written to <name>-<model>.prom beside it, e.g. /var/lib/node_exporter/gobackup.prom"),
+			}),
+			"tracing": closedObject("OpenTelemetry tracing of backups", schemaKeys{
+				"exporter":     enumKey("Tracing is disabled if not set", "otlp_grpc", "otlp_http", "stdout"),
+				"endpoint":     stringKey("host:port of OTLP, default by the OTEL_EXPORTER_OTLP_* env"),
+				"insecure":     boolKey("Export without TLS"),
+				"headers":      mapKey("Headers of OTLP requests, e.g. authorization"),
+				"service_name": stringKey("Default: gobackup"),
+			}),
+			"leader_election": closedObject("Only the leader of the replicas in Kubernetes runs the schedules, by a Lease", schemaKeys{
+				"lease":          stringKey("Name of Lease, default: gobackup"),
+				"namespace":      stringKey("Namespace of Lease, default: the namespace of pod"),
+				"identity":       stringKey("Identity of this instance, default: the hostname"),
+				"lease_duration": durationKey("Default: 15s"),
+				"renew_deadline": durationKey("Default: 10s"),
+				"retry_period":   durationKey("Default: 2s"),
+			}),
+		},
+		"additionalProperties": false,
+	}
+}
+
+// SchemaJSON returns the indented JSON of Schema
+func SchemaJSON() ([]byte, error) {
+	return json.MarshalIndent(Schema(), "", "  ")
+}
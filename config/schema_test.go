@@ -0,0 +1,166 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+// unknownKeys walks value with the properties, additionalProperties and if/then of schema,
+// returns the paths of keys that are not allowed.
+func unknownKeys(schema map[string]any, value any, path string) (keys []string) {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	// Use the map alternative of templated storages / notifiers
+	anyOf, _ := schema["anyOf"].([]any)
+	for _, sub := range anyOf {
+		if sub := sub.(map[string]any); sub["type"] == "object" {
+			return unknownKeys(sub, value, path)
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	additional := schema["additionalProperties"]
+
+	allOf, _ := schema["allOf"].([]any)
+	for _, sub := range allOf {
+		cond := sub.(map[string]any)
+		typeConst := cond["if"].(map[string]any)["properties"].(map[string]any)["type"].(map[string]any)["const"]
+		if object["type"] == typeConst {
+			keys = append(keys, unknownKeys(cond["then"].(map[string]any), value, path)...)
+			return
+		}
+	}
+
+	for key, child := range object {
+		childPath := strings.TrimPrefix(path+"."+key, ".")
+		if childSchema, ok := properties[key].(map[string]any); ok {
+			keys = append(keys, unknownKeys(childSchema, child, childPath)...)
+			continue
+		}
+
+		switch additional := additional.(type) {
+		case bool:
+			if !additional {
+				keys = append(keys, childPath)
+			}
+		case map[string]any:
+			keys = append(keys, unknownKeys(additional, child, childPath)...)
+		}
+	}
+
+	sort.Strings(keys)
+	return
+}
+
+func validateSchema(t *testing.T, content string) []string {
+	t.Helper()
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	assert.NoError(t, v.ReadConfig(strings.NewReader(content)))
+
+	// Use JSON round trip to get the same schema as served
+	data, err := SchemaJSON()
+	assert.NoError(t, err)
+	schema := map[string]any{}
+	assert.NoError(t, json.Unmarshal(data, &schema))
+
+	return unknownKeys(schema, v.AllSettings(), "")
+}
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+	assert.Equal(t, "http://json-schema.org/draft-07/schema#", schema["$schema"])
+
+	models := schema["properties"].(map[string]any)["models"].(map[string]any)
+	model := models["additionalProperties"].(map[string]any)
+	databases := model["properties"].(map[string]any)["databases"].(map[string]any)["additionalProperties"].(map[string]any)
+	types := databases["properties"].(map[string]any)["type"].(map[string]any)["enum"].([]string)
+	for _, dbType := range []string{"mysql", "postgresql", "sqlite", "clickhouse", "scylladb", "opensearch"} {
+		assert.True(t, slices.Contains(types, dbType), fmt.Sprintf("database type %s", dbType))
+	}
+
+	storages := schema["properties"].(map[string]any)["storages"].(map[string]any)["additionalProperties"].(map[string]any)
+	assert.Equal(t, []string{"type"}, storages["required"])
+	types = storages["properties"].(map[string]any)["type"].(map[string]any)["enum"].([]string)
+	assert.True(t, slices.Contains(types, "r2"))
+	assert.True(t, slices.Contains(types, "azure"))
+}
+
+func TestSchema_validate(t *testing.T) {
+	keys := validateSchema(t, `
+web:
+  port: 2703
+models:
+  app:
+    schedule:
+      every: 1day
+    databases:
+      pg:
+        type: postgresql
+        database: app
+        exclude_tables: [logs]
+        verify: true
+      mongo:
+        type: mongodb
+        exclude_tables_prefix: [tmp_]
+    encrypt_with:
+      type: openssl
+      password: secret
+      chiper: aes-256-cbc
+    split_with:
+      chunk_size: 1G
+    storages:
+      s3:
+        type: s3
+        bucket: backups
+        keep: 10
+    notifiers: [slack]
+notifiers:
+  slack:
+    type: slack
+    url: https://hooks.slack.com/services/xxx
+`)
+	assert.Equal(t, 0, len(keys))
+
+	keys = validateSchema(t, `
+webs:
+  port: 2703
+storages:
+  offsite:
+    type: s3
+    bucket: backups
+    region: us-east-1
+    prefix: app
+models:
+  app:
+    databases:
+      mongo:
+        type: mongodb
+        exclude_table_prefix: [tmp_]
+    encrypt_with:
+      type: openssl
+      cipher: aes-256-cbc
+    storages:
+      s3:
+        type: s3
+        buckets: backups
+`)
+	assert.Equal(t, []string{
+		"models.app.databases.mongo.exclude_table_prefix",
+		"models.app.encrypt_with.cipher",
+		"models.app.storages.s3.buckets",
+		"storages.offsite.prefix",
+		"webs",
+	}, keys)
+}
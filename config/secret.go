@@ -0,0 +1,270 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gobackup/gobackup/helper"
+)
+
+// SecretProvider resolves the secret reference in config values.
+//
+// A reference is written as `${<provider>:<ref>}`, for example:
+//
+//	password: ${vault:secret/data/db#password}
+//	password: ${file:/run/secrets/pg}
+//	password: ${env:PG_PASSWORD}
+//	secret_access_key: ${cmd:pass show backup/s3}
+type SecretProvider interface {
+	Resolve(ref string) (string, error)
+}
+
+var (
+	secretProviders = map[string]SecretProvider{
+		"env":   envSecretProvider{},
+		"file":  fileSecretProvider{},
+		"cmd":   cmdSecretProvider{},
+		"vault": VaultSecretProvider{},
+	}
+	secretProvidersLock = sync.RWMutex{}
+)
+
+// RegisterSecretProvider add or replace a secret provider with the name
+func RegisterSecretProvider(name string, provider SecretProvider) {
+	secretProvidersLock.Lock()
+	defer secretProvidersLock.Unlock()
+
+	secretProviders[name] = provider
+}
+
+func getSecretProvider(name string) SecretProvider {
+	secretProvidersLock.RLock()
+	defer secretProvidersLock.RUnlock()
+
+	return secretProviders[name]
+}
+
+// secretReference matches `${<provider>:<ref>}`
+var secretReference = regexp.MustCompile(`\$\{([a-zA-Z0-9_]+):([^}]*)\}`)
+
+// expandConfig expand `$VAR`, `${VAR}` in the config text with environment variables,
+// then parses the YAML and resolves `${<provider>:<ref>}` in the string values with the secret providers,
+// so that a secret with `#`, `: `, quotes or newlines can't break the YAML, and the references in comments are ignored.
+func expandConfig(content string) (string, error) {
+	content = os.Expand(content, func(key string) string {
+		// Keep the secret references to be resolved after parsing
+		if strings.Contains(key, ":") {
+			return "${" + key + "}"
+		}
+		return os.Getenv(key)
+	})
+	if !secretReference.MatchString(content) {
+		return content, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return "", err
+	}
+
+	resolver := secretResolver{resolved: map[string]string{}}
+	resolver.walk(&doc)
+	if resolver.err != nil {
+		return "", resolver.err
+	}
+
+	var expanded strings.Builder
+	encoder := yaml.NewEncoder(&expanded)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return "", err
+	}
+	return expanded.String(), encoder.Close()
+}
+
+// secretResolver resolves the secret references in the string values of YAML nodes
+type secretResolver struct {
+	// resolved values by reference, each secret is resolved once
+	resolved map[string]string
+	// err is the first error
+	err error
+}
+
+func (r *secretResolver) walk(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		// Only the values, not the keys
+		for i := 1; i < len(node.Content); i += 2 {
+			r.walk(node.Content[i])
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			r.walk(child)
+		}
+	case yaml.ScalarNode:
+		if node.Tag == "!!str" && secretReference.MatchString(node.Value) {
+			node.Value = secretReference.ReplaceAllStringFunc(node.Value, r.resolve)
+			// Let the encoder quote the value as needed
+			node.Style = 0
+		}
+	}
+}
+
+// resolve the reference `${<provider>:<ref>}`
+func (r *secretResolver) resolve(reference string) string {
+	key := reference[2 : len(reference)-1]
+	if value, ok := r.resolved[key]; ok {
+		return value
+	}
+
+	name, ref, _ := strings.Cut(key, ":")
+	provider := getSecretProvider(name)
+	if provider == nil {
+		if r.err == nil {
+			r.err = fmt.Errorf("secret provider %q is not supported in ${%s}", name, key)
+		}
+		return ""
+	}
+
+	value, err := provider.Resolve(ref)
+	if err != nil {
+		if r.err == nil {
+			r.err = fmt.Errorf("resolve secret ${%s} failed: %v", key, err)
+		}
+		return ""
+	}
+
+	r.resolved[key] = value
+	return value
+}
+
+// envSecretProvider reads the secret from environment variable
+type envSecretProvider struct{}
+
+func (envSecretProvider) Resolve(ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return value, nil
+}
+
+// fileSecretProvider reads the secret from file, e.g. Docker or Kubernetes secrets
+type fileSecretProvider struct{}
+
+func (fileSecretProvider) Resolve(ref string) (string, error) {
+	data, err := os.ReadFile(helper.ExplandHome(ref))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// cmdSecretProvider reads the secret from the stdout of a shell command
+type cmdSecretProvider struct{}
+
+func (cmdSecretProvider) Resolve(ref string) (string, error) {
+	output, err := helper.ExecScript(ref)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", ref, strings.TrimSpace(err.Error()))
+	}
+	return strings.TrimRight(output, "\r\n"), nil
+}
+
+// VaultSecretProvider reads the secret from HashiCorp Vault KV secrets engine (v1 and v2).
+//
+// The reference is `<path>#<key>`, e.g. `secret/data/db#password` for KV v2.
+// It uses the same environment variables as the vault CLI:
+//
+//   - VAULT_ADDR: http://127.0.0.1:8200
+//   - VAULT_TOKEN: or the token in ~/.vault-token
+//   - VAULT_NAMESPACE:
+type VaultSecretProvider struct {
+	Client *http.Client
+}
+
+func (p VaultSecretProvider) Resolve(ref string) (string, error) {
+	secretPath, key, found := strings.Cut(ref, "#")
+	if !found || len(key) == 0 {
+		return "", fmt.Errorf("vault secret reference must be <path>#<key>, got %q", ref)
+	}
+
+	addr := os.Getenv("VAULT_ADDR")
+	if len(addr) == 0 {
+		addr = "http://127.0.0.1:8200"
+	}
+
+	token := os.Getenv("VAULT_TOKEN")
+	if len(token) == 0 {
+		data, err := os.ReadFile(filepath.Join(os.Getenv("HOME"), ".vault-token"))
+		if err != nil {
+			return "", fmt.Errorf("VAULT_TOKEN is not set")
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(secretPath, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); len(namespace) > 0 {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("vault %s status: %d, body: %s", secretPath, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", err
+	}
+
+	data := result.Data
+	// KV v2 nests the secret in data.data along with data.metadata
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found in vault secret %s", key, secretPath)
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
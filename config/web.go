@@ -0,0 +1,263 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/spf13/viper"
+)
+
+// Scopes of web users and API tokens
+const (
+	// ScopeRead to view models, files, runs and logs
+	ScopeRead = "read"
+	// ScopePerform to perform and cancel backups
+	ScopePerform = "perform"
+	// ScopeDownload to download backup files
+	ScopeDownload = "download"
+	// ScopeAdmin for all scopes, and to pause schedules and delete backups
+	ScopeAdmin = "admin"
+)
+
+var scopes = []string{ScopeRead, ScopePerform, ScopeDownload, ScopeAdmin}
+
+type WebConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	Enabled  bool
+	// Users of basic auth, `username` and `password` is an admin user
+	Users []WebUser
+	// Tokens of API, in `Authorization: Bearer <token>` header
+	Tokens []WebUser
+	// OIDC login for web UI
+	OIDC *OIDCConfig
+	// TLS of web server, plain HTTP if nil
+	TLS *WebTLSConfig
+}
+
+// WebTLSConfig of web server
+type WebTLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// SelfSigned generates a self-signed certificate in GoBackupDir/tls when cert_file is not set
+	SelfSigned bool
+	// ClientCAFile to verify the client certificates, enables mutual TLS
+	ClientCAFile string
+	// ClientAuth of mutual TLS: require (default), verify_if_given
+	ClientAuth string
+	// Clients by the Common Name of client certificate
+	Clients []WebUser
+}
+
+// WebUser is a user or an API token of web, with the scopes and the models can access
+type WebUser struct {
+	// Name of user or token, the email of OIDC user
+	Name string `mapstructure:"email"`
+	// Password of user, plain text or bcrypt hash
+	Password string `mapstructure:"password"`
+	// Token of API token
+	Token  string   `mapstructure:"token"`
+	Scopes []string `mapstructure:"scopes"`
+	// Models can be accessed, all models if empty
+	Models []string `mapstructure:"models"`
+}
+
+// OIDCConfig of OpenID Connect login
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the URL of `/auth/callback`, e.g. https://backup.example.com/auth/callback
+	RedirectURL string
+	// Users can login by email
+	Users []WebUser
+}
+
+// AuthEnabled returns true when any of users, tokens, OIDC or TLS clients is configured
+func (web WebConfig) AuthEnabled() bool {
+	return len(web.Users) > 0 || len(web.Tokens) > 0 || web.OIDC != nil || (web.TLS != nil && len(web.TLS.Clients) > 0)
+}
+
+// HasScope returns true when user has the scope, admin has all scopes
+func (user WebUser) HasScope(scope string) bool {
+	return slices.Contains(user.Scopes, ScopeAdmin) || slices.Contains(user.Scopes, scope)
+}
+
+// CanAccess returns true when user can access the model
+func (user WebUser) CanAccess(model string) bool {
+	return len(user.Models) == 0 || slices.Contains(user.Models, model)
+}
+
+// CanAccessAll returns true when user can access all models
+func (user WebUser) CanAccessAll() bool {
+	return len(user.Models) == 0
+}
+
+// loadWebConfig loads `web` config
+//
+//	web:
+//	  users:
+//	    oncall:
+//	      password: xxx
+//	      scopes: [read]
+//	  tokens:
+//	    ci:
+//	      token: ${env:CI_TOKEN}
+//	      scopes: [perform]
+//	      models: [app]
+//	  oidc:
+//	    issuer: https://accounts.google.com
+//	    users:
+//	      - email: alice@example.com
+//	        scopes: [admin]
+func loadWebConfig() error {
+	Web = WebConfig{}
+	viper.SetDefault("web.host", "0.0.0.0")
+	viper.SetDefault("web.port", 2703)
+	viper.SetDefault("web.enabled", true)
+	Web.Host = viper.GetString("web.host")
+	Web.Port = viper.GetString("web.port")
+	Web.Username = viper.GetString("web.username")
+	Web.Password = viper.GetString("web.password")
+	Web.Enabled = viper.GetBool("web.enabled")
+
+	if len(Web.Username) > 0 && len(Web.Password) > 0 {
+		Web.Users = append(Web.Users, WebUser{Name: Web.Username, Password: Web.Password, Scopes: []string{ScopeAdmin}})
+	}
+
+	users, err := loadWebUsers(viper.GetViper(), "web.users", "password")
+	if err != nil {
+		return err
+	}
+	Web.Users = append(Web.Users, users...)
+
+	if Web.Tokens, err = loadWebUsers(viper.GetViper(), "web.tokens", "token"); err != nil {
+		return err
+	}
+
+	if viper.IsSet("web.oidc") {
+		Web.OIDC = &OIDCConfig{
+			Issuer:       viper.GetString("web.oidc.issuer"),
+			ClientID:     viper.GetString("web.oidc.client_id"),
+			ClientSecret: viper.GetString("web.oidc.client_secret"),
+			RedirectURL:  viper.GetString("web.oidc.redirect_url"),
+		}
+		for _, key := range []string{"issuer", "client_id", "redirect_url"} {
+			if len(viper.GetString("web.oidc."+key)) == 0 {
+				return fmt.Errorf("web.oidc.%s is required", key)
+			}
+		}
+
+		if err := viper.UnmarshalKey("web.oidc.users", &Web.OIDC.Users); err != nil {
+			return fmt.Errorf("web.oidc.users: %v", err)
+		}
+		for i, user := range Web.OIDC.Users {
+			if len(user.Name) == 0 {
+				return fmt.Errorf("web.oidc.users[%d]: email is required", i)
+			}
+			if err := checkScopes(fmt.Sprintf("web.oidc.users[%d]", i), user.Scopes); err != nil {
+				return err
+			}
+		}
+	}
+
+	if viper.IsSet("web.tls") {
+		if Web.TLS, err = loadWebTLSConfig(viper.Sub("web.tls")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadWebTLSConfig loads `web.tls` config
+//
+//	web:
+//	  tls:
+//	    cert_file: /etc/gobackup/cert.pem
+//	    key_file: /etc/gobackup/key.pem
+//	    client_ca_file: /etc/gobackup/ca.pem
+//	    clients:
+//	      platform:
+//	        scopes: [read, perform]
+func loadWebTLSConfig(v *viper.Viper) (*WebTLSConfig, error) {
+	if v == nil {
+		v = viper.New()
+	}
+	v.SetDefault("client_auth", "require")
+
+	tlsConfig := &WebTLSConfig{
+		CertFile:     v.GetString("cert_file"),
+		KeyFile:      v.GetString("key_file"),
+		SelfSigned:   v.GetBool("self_signed"),
+		ClientCAFile: v.GetString("client_ca_file"),
+		ClientAuth:   v.GetString("client_auth"),
+	}
+
+	if len(tlsConfig.CertFile) > 0 != (len(tlsConfig.KeyFile) > 0) {
+		return nil, fmt.Errorf("web.tls.cert_file and web.tls.key_file must be set together")
+	}
+	if len(tlsConfig.CertFile) == 0 && !tlsConfig.SelfSigned {
+		return nil, fmt.Errorf("web.tls.cert_file and web.tls.key_file are required, or enable web.tls.self_signed")
+	}
+	switch tlsConfig.ClientAuth {
+	case "require", "verify_if_given":
+	default:
+		return nil, fmt.Errorf("web.tls.client_auth must be one of require, verify_if_given")
+	}
+
+	clients, err := loadWebUsers(v, "clients", "")
+	if err != nil {
+		return nil, fmt.Errorf("web.tls.%v", err)
+	}
+	if len(clients) > 0 && len(tlsConfig.ClientCAFile) == 0 {
+		return nil, fmt.Errorf("web.tls.client_ca_file is required by web.tls.clients")
+	}
+	tlsConfig.Clients = clients
+
+	return tlsConfig, nil
+}
+
+// loadWebUsers loads the users or tokens by name, the secretKey is required if it's not empty
+func loadWebUsers(v *viper.Viper, key, secretKey string) ([]WebUser, error) {
+	var configs map[string]WebUser
+	if err := v.UnmarshalKey(key, &configs); err != nil {
+		return nil, fmt.Errorf("%s: %v", key, err)
+	}
+
+	users := make([]WebUser, 0, len(configs))
+	for name, user := range configs {
+		user.Name = name
+		secret := user.Password
+		if secretKey == "token" {
+			secret = user.Token
+		}
+		if len(secretKey) > 0 && len(secret) == 0 {
+			return nil, fmt.Errorf("%s.%s: %s is required", key, name, secretKey)
+		}
+		if err := checkScopes(fmt.Sprintf("%s.%s", key, name), user.Scopes); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Name < users[j].Name
+	})
+
+	return users, nil
+}
+
+func checkScopes(key string, values []string) error {
+	if len(values) == 0 {
+		return fmt.Errorf("%s: scopes is required", key)
+	}
+	for _, scope := range values {
+		if !slices.Contains(scopes, scope) {
+			return fmt.Errorf("%s: invalid scope %q, must be one of %v", key, scope, scopes)
+		}
+	}
+	return nil
+}
@@ -1,18 +1,28 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"path"
+	"sort"
+	"time"
 
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/gobackup/gobackup/config"
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/metrics"
+	"github.com/gobackup/gobackup/progress"
+	"github.com/gobackup/gobackup/tracing"
 )
 
 // Base database
 type Base struct {
+	// ctx of the dump, commands will be killed when it's done
+	ctx      context.Context
 	model    config.ModelConfig
 	dbConfig config.SubConfig
 	viper    *viper.Viper
@@ -30,6 +40,7 @@ type Database interface {
 
 func newBase(model config.ModelConfig, dbConfig config.SubConfig) (base Base) {
 	base = Base{
+		ctx:      context.Background(),
 		model:    model,
 		dbConfig: dbConfig,
 		viper:    dbConfig.Viper,
@@ -43,26 +54,25 @@ func newBase(model config.ModelConfig, dbConfig config.SubConfig) (base Base) {
 	return
 }
 
-func runHook(action, script string) error {
-	logger := logger.Tag("Database")
+func runHook(ctx context.Context, action, script string) error {
+	logger := logger.Tag("Database").WithContext(ctx)
 	if len(script) == 0 {
 		return nil
 	}
 	logger.Infof("Run %s", action)
 
-	if _, err := helper.ExecScriptWithStdio(script, true); err != nil {
-		return fmt.Errorf("Run %s failed: %v", action, err)
+	if _, err := helper.ExecScriptWithStdioContext(ctx, script, true); err != nil {
+		return fmt.Errorf("Run %s failed: %w", action, err)
 	}
 
 	logger.Infof("Run %s succeeded", action)
 	return nil
 }
 
-// New - initialize Database
-func runModel(model config.ModelConfig, dbConfig config.SubConfig) (err error) {
-	logger := logger.Tag("Database")
-
+// newDatabase returns the Database of dbConfig.Type
+func newDatabase(ctx context.Context, model config.ModelConfig, dbConfig config.SubConfig) (Database, Base, error) {
 	base := newBase(model, dbConfig)
+	base.ctx = ctx
 	var db Database
 	switch dbConfig.Type {
 	case "mysql":
@@ -85,16 +95,46 @@ func runModel(model config.ModelConfig, dbConfig config.SubConfig) (err error) {
 		db = &Etcd{Base: base}
 	case "firebird":
 		db = &Firebird{Base: base}
+	case "clickhouse":
+		db = &ClickHouse{Base: base}
+	case "cassandra", "scylladb":
+		db = &Cassandra{Base: base}
+	case "elasticsearch", "opensearch":
+		db = &Elasticsearch{Base: base}
 	default:
-		logger.Warn(fmt.Errorf("model: %s databases.%s config `type: %s`, but is not implement", model.Name, dbConfig.Name, dbConfig.Type))
-		return
+		return nil, base, fmt.Errorf("model: %s databases.%s config `type: %s`, but is not implement", model.Name, dbConfig.Name, dbConfig.Type)
+	}
+
+	return db, base, nil
+}
+
+// New - initialize Database
+func runModel(ctx context.Context, model config.ModelConfig, dbConfig config.SubConfig) (err error) {
+	logger := logger.Tag("Database").WithContext(ctx)
+
+	timeout, err := helper.ParseTimeout(dbConfig.Viper.GetString("timeout"))
+	if err != nil {
+		return fmt.Errorf("databases.%s: %v", dbConfig.Name, err)
+	}
+	// The after_script should be run even if the dump is timed out
+	hookCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	db, base, err := newDatabase(ctx, model, dbConfig)
+	if err != nil {
+		logger.Warn(err)
+		return nil
 	}
 
 	logger.Infof("=> database | %v: %v", dbConfig.Type, base.name)
 
 	// before perform
 	beforeScript := dbConfig.Viper.GetString("before_script")
-	if err := runHook("dump before_script", beforeScript); err != nil {
+	if err := runHook(ctx, "dump before_script", beforeScript); err != nil {
 		return err
 	}
 
@@ -107,6 +147,14 @@ func runModel(model config.ModelConfig, dbConfig config.SubConfig) (err error) {
 	}
 
 	err = db.perform()
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && hookCtx.Err() == nil {
+		err = fmt.Errorf("dump %s timed out after %s: %v", base.name, timeout, err)
+	}
+	if err == nil {
+		if err = verifyDump(db, base); err != nil {
+			err = fmt.Errorf("verify %s dump failed: %v", base.name, err)
+		}
+	}
 	if err != nil {
 		logger.Info("Dump failed")
 		if len(afterScript) == 0 {
@@ -132,25 +180,59 @@ func runModel(model config.ModelConfig, dbConfig config.SubConfig) (err error) {
 	}
 
 	// after perform
-	if err := runHook("dump after_script", afterScript); err != nil {
+	if err := runHook(hookCtx, "dump after_script", afterScript); err != nil {
 		return err
 	}
 
 	return
 }
 
-// Run databases
-func Run(model config.ModelConfig) error {
-	if len(model.Databases) == 0 {
-		return nil
+// Result of a database dump
+type Result struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Status string `json:"status"` // success, failure, skipped
+	// Duration in seconds
+	Duration float64 `json:"duration"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Run databases sorted by name, it stops at the first failure, the rest of databases are skipped
+func Run(ctx context.Context, model config.ModelConfig) (results []Result, err error) {
+	names := make([]string, 0, len(model.Databases))
+	for name := range model.Databases {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		dbCfg := model.Databases[name]
+		result := Result{Name: dbCfg.Name, Type: dbCfg.Type, Status: "skipped"}
+		if err != nil {
+			results = append(results, result)
+			continue
+		}
 
-	for _, dbCfg := range model.Databases {
-		err := runModel(model, dbCfg)
+		startedAt := time.Now()
+		spanCtx, span := tracing.Start(ctx, "gobackup.database.dump",
+			attribute.String("gobackup.database.name", dbCfg.Name),
+			attribute.String("gobackup.database.type", dbCfg.Type),
+		)
+		err = runModel(spanCtx, model, dbCfg)
+		result.Duration = time.Since(startedAt).Seconds()
 		if err != nil {
-			return err
+			result.Status = "failure"
+			result.Error = err.Error()
+		} else {
+			result.Status = "success"
+			size := progress.Size(path.Join(model.DumpPath, dbCfg.Type, dbCfg.Name))
+			metrics.DatabaseDumpBytes.WithLabelValues(model.Name, dbCfg.Name).Set(float64(size))
+			span.SetAttributes(attribute.Int64("gobackup.database.dump.size", size))
 		}
+		metrics.DatabaseDumpDurationSeconds.WithLabelValues(model.Name, dbCfg.Name, result.Status).Observe(result.Duration)
+		tracing.End(span, err)
+		results = append(results, result)
 	}
 
-	return nil
+	return results, err
 }
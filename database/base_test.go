@@ -1,11 +1,15 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/gobackup/gobackup/config"
 	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
 )
 
 func init() {
@@ -58,3 +62,22 @@ func TestBase_newBase(t *testing.T) {
 	assert.Equal(t, base.name, "mysql-master")
 	assert.Equal(t, base.dumpPath, "/tmp/gobackup/test/mysql/mysql-master")
 }
+
+func TestRunModel_timeout(t *testing.T) {
+	v := viper.New()
+	v.Set("path", "/tmp/not-exist.db")
+	v.Set("timeout", "100ms")
+	v.Set("before_script", "sleep 10")
+
+	model := config.ModelConfig{Name: "test", DumpPath: t.TempDir()}
+	dbConfig := config.SubConfig{Type: "sqlite", Name: "sqlite1", Viper: v}
+
+	startedAt := time.Now()
+	err := runModel(context.Background(), model, dbConfig)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, time.Since(startedAt) < 5*time.Second)
+
+	v.Set("timeout", "invalid")
+	err = runModel(context.Background(), model, dbConfig)
+	assert.EqualError(t, err, "databases.sqlite1: invalid timeout: invalid")
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/logger"
+)
+
+// Cassandra / ScyllaDB database
+//
+// ref:
+// https://cassandra.apache.org/doc/latest/cassandra/operating/backups.html
+//
+// # Keys
+//
+//   - type: cassandra
+//   - host: 127.0.0.1
+//   - port: 7199
+//   - username:
+//   - password:
+//   - keyspaces: [ks1, ks2] # empty to snapshot all keyspaces
+//   - data_dir: /var/lib/cassandra/data
+//   - args:
+//
+// It takes a snapshot with `nodetool snapshot`, collects the sstables of each
+// table from `<data_dir>/<keyspace>/<table>/snapshots/<tag>` into the dump path,
+// then clears the snapshot. For ScyllaDB set `data_dir: /var/lib/scylla/data`.
+type Cassandra struct {
+	Base
+	host      string
+	port      string
+	username  string
+	password  string
+	keyspaces []string
+	dataDir   string
+	args      string
+
+	tag          string
+	passwordFile string
+}
+
+func (db *Cassandra) init() (err error) {
+	viper := db.viper
+	viper.SetDefault("host", "127.0.0.1")
+	viper.SetDefault("port", 7199)
+	viper.SetDefault("data_dir", "/var/lib/cassandra/data")
+
+	db.host = viper.GetString("host")
+	db.port = viper.GetString("port")
+	db.username = viper.GetString("username")
+	db.password = viper.GetString("password")
+	db.keyspaces = viper.GetStringSlice("keyspaces")
+	db.dataDir = helper.ExplandHome(viper.GetString("data_dir"))
+	db.args = viper.GetString("args")
+
+	if len(db.dataDir) == 0 {
+		return fmt.Errorf("Cassandra data_dir config is required")
+	}
+
+	db.tag = fmt.Sprintf("gobackup-%s-%s", db.name, time.Now().Format("20060102150405"))
+
+	return nil
+}
+
+// nodetool returns the args of nodetool with subcommand, the password is passed by `-pwf` passwordFile
+func (db *Cassandra) nodetool(subcommand ...string) []string {
+	args := []string{}
+	if len(db.host) > 0 {
+		args = append(args, "-h", db.host)
+	}
+	if len(db.port) > 0 {
+		args = append(args, "-p", db.port)
+	}
+	if len(db.username) > 0 {
+		args = append(args, "-u", db.username)
+	}
+	if len(db.passwordFile) > 0 {
+		args = append(args, "-pwf", db.passwordFile)
+	}
+	if len(db.args) > 0 {
+		args = append(args, strings.Fields(db.args)...)
+	}
+
+	return append(args, subcommand...)
+}
+
+func (db *Cassandra) build() []string {
+	return db.nodetool(append([]string{"snapshot", "-t", db.tag}, db.keyspaces...)...)
+}
+
+// writePasswordFile writes the credentials in the format of JMX password file for `nodetool -pwf`,
+// so that the password is not in the command line. The file should be removed after nodetool.
+func (db *Cassandra) writePasswordFile() error {
+	f, err := os.CreateTemp(db.model.TempPath, "cassandra-password-*")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "%s %s\n", db.username, db.password); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	db.passwordFile = f.Name()
+
+	return nil
+}
+
+// snapshotDirs returns the snapshot directories of tag, grouped as `<keyspace>/<table>`
+func (db *Cassandra) snapshotDirs() (map[string]string, error) {
+	keyspaces := db.keyspaces
+	if len(keyspaces) == 0 {
+		keyspaces = []string{"*"}
+	}
+
+	dirs := map[string]string{}
+	for _, keyspace := range keyspaces {
+		matches, err := filepath.Glob(filepath.Join(db.dataDir, keyspace, "*", "snapshots", db.tag))
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range matches {
+			tableDir := filepath.Dir(filepath.Dir(dir))
+			key := filepath.Join(filepath.Base(filepath.Dir(tableDir)), filepath.Base(tableDir))
+			dirs[key] = dir
+		}
+	}
+
+	return dirs, nil
+}
+
+func (db *Cassandra) perform() error {
+	logger := logger.Tag("Cassandra").WithContext(db.ctx)
+
+	if len(db.password) > 0 {
+		if err := db.writePasswordFile(); err != nil {
+			return err
+		}
+		defer os.Remove(db.passwordFile)
+	}
+
+	logger.Info("-> Taking snapshot", db.tag)
+	if _, err := helper.ExecContext(db.ctx, "nodetool", db.build()...); err != nil {
+		return fmt.Errorf("-> Snapshot error: %s", err)
+	}
+
+	defer func() {
+		if _, err := helper.ExecContext(context.WithoutCancel(db.ctx), "nodetool", db.nodetool("clearsnapshot", "-t", db.tag)...); err != nil {
+			logger.Warnf("Clear snapshot %s failed: %s", db.tag, err)
+		}
+	}()
+
+	dirs, err := db.snapshotDirs()
+	if err != nil {
+		return err
+	}
+	if len(dirs) == 0 {
+		return fmt.Errorf("no snapshot %s found in %s", db.tag, db.dataDir)
+	}
+
+	for key, dir := range dirs {
+		target := filepath.Join(db.dumpPath, key)
+		if err := helper.MkdirP(filepath.Dir(target)); err != nil {
+			return err
+		}
+		if _, err := helper.ExecContext(db.ctx, "cp", "-a", dir, target); err != nil {
+			return fmt.Errorf("copy %s failed: %s", dir, err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(db.dumpPath, "SNAPSHOT"), []byte(db.tag+"\n"), 0640); err != nil {
+		return err
+	}
+
+	logger.Infof("Collected %d tables, dump path: %s", len(dirs), db.dumpPath)
+	return nil
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func TestCassandra_init(t *testing.T) {
+	viper := viper.New()
+	viper.Set("host", "1.2.3.4")
+	viper.Set("username", "user1")
+	viper.Set("password", "pass1")
+	viper.Set("keyspaces", []string{"ks1", "ks2"})
+
+	base := newBase(
+		config.ModelConfig{
+			DumpPath: "/data/backups",
+		},
+		config.SubConfig{
+			Type:  "cassandra",
+			Name:  "cassandra1",
+			Viper: viper,
+		},
+	)
+
+	db := &Cassandra{
+		Base: base,
+	}
+
+	err := db.init()
+	assert.NoError(t, err)
+	db.tag = "gobackup-cassandra1-20230101000000"
+
+	assert.Equal(t, db.dataDir, "/var/lib/cassandra/data")
+	assert.Equal(t, db.build(), []string{"-h", "1.2.3.4", "-p", "7199", "-u", "user1", "snapshot", "-t", "gobackup-cassandra1-20230101000000", "ks1", "ks2"})
+
+	// The password is in the file
+	db.model.TempPath = t.TempDir()
+	assert.NoError(t, db.writePasswordFile())
+	data, err := os.ReadFile(db.passwordFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1 pass1\n", string(data))
+	info, err := os.Stat(db.passwordFile)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.Equal(t, db.nodetool("clearsnapshot"), []string{"-h", "1.2.3.4", "-p", "7199", "-u", "user1", "-pwf", db.passwordFile, "clearsnapshot"})
+}
+
+func TestCassandra_snapshotDirs(t *testing.T) {
+	dataDir := t.TempDir()
+	tag := "gobackup-cassandra1-20230101000000"
+	for _, dir := range []string{
+		filepath.Join(dataDir, "ks1", "users-1234", "snapshots", tag),
+		filepath.Join(dataDir, "ks1", "posts-5678", "snapshots", tag),
+		filepath.Join(dataDir, "ks1", "posts-5678", "snapshots", "other"),
+		filepath.Join(dataDir, "ks2", "events-9012", "snapshots", tag),
+	} {
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+	}
+
+	db := &Cassandra{dataDir: dataDir, tag: tag, keyspaces: []string{"ks1"}}
+	dirs, err := db.snapshotDirs()
+	assert.NoError(t, err)
+	assert.Equal(t, dirs, map[string]string{
+		"ks1/users-1234": filepath.Join(dataDir, "ks1", "users-1234", "snapshots", tag),
+		"ks1/posts-5678": filepath.Join(dataDir, "ks1", "posts-5678", "snapshots", tag),
+	})
+
+	db.keyspaces = nil
+	dirs, err = db.snapshotDirs()
+	assert.NoError(t, err)
+	assert.Len(t, dirs, 3)
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
+)
+
+// pinger is implemented by databases that can test the connection without dumping
+type pinger interface {
+	ping() error
+}
+
+// Check databases config of model, and the required binaries are installed.
+// When connect is true, it also tests the connection of databases that support it.
+func Check(model config.ModelConfig, connect bool) (errs []error) {
+	for _, dbConfig := range model.Databases {
+		db, _, err := newDatabase(context.Background(), model, dbConfig)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := db.init(); err != nil {
+			errs = append(errs, fmt.Errorf("databases.%s: %v", dbConfig.Name, err))
+			continue
+		}
+
+		for _, binary := range binaries(db) {
+			if _, err := exec.LookPath(binary); err != nil {
+				errs = append(errs, fmt.Errorf("databases.%s: %s cannot be found in $PATH", dbConfig.Name, binary))
+			}
+		}
+
+		if !connect {
+			continue
+		}
+
+		if p, ok := db.(pinger); ok {
+			if err := p.ping(); err != nil {
+				errs = append(errs, fmt.Errorf("databases.%s: connect failed: %v", dbConfig.Name, strings.TrimSpace(err.Error())))
+			}
+		}
+	}
+
+	return
+}
+
+// binaries returns the commands used to dump db, and to verify the dump
+func binaries(db Database) []string {
+	switch db := db.(type) {
+	case *PostgreSQL:
+		if db.verifyEnabled() && db.dumpFormat() != "p" {
+			return []string{"pg_dump", "pg_restore"}
+		}
+		return strings.Fields(db.build())[:1]
+	case *SQLite:
+		if db.mode == "dump" {
+			return []string{"sqlite3"}
+		}
+	case *ClickHouse:
+		if db.mode == "clickhouse-backup" {
+			return []string{"clickhouse-backup", "cp"}
+		}
+		return []string{"clickhouse-client", "mv"}
+	case *Cassandra:
+		return []string{"nodetool", "cp"}
+	case *Elasticsearch:
+		return []string{"cp"}
+	case *InfluxDB2:
+		return []string{"influx"}
+	case *MSSQL:
+		if db.allDatabases {
+			return []string{sqlpackageCli, "sqlcmd"}
+		}
+		return []string{sqlpackageCli}
+	case interface{ build() string }:
+		return strings.Fields(db.build())[:1]
+	}
+
+	return nil
+}
+
+func (db *MySQL) ping() error {
+	args := []string{}
+	if len(db.host) > 0 {
+		args = append(args, "--host", db.host)
+	}
+	if len(db.port) > 0 {
+		args = append(args, "--port", db.port)
+	}
+	if len(db.socket) > 0 {
+		args = append(args, "--socket", db.socket)
+	}
+	if len(db.username) > 0 {
+		args = append(args, "-u", db.username)
+	}
+	if len(db.password) > 0 {
+		args = append(args, "-p"+db.password)
+	}
+
+	_, err := helper.ExecContext(db.ctx, "mysqladmin", append(args, "ping")...)
+	return err
+}
+
+func (db *PostgreSQL) ping() error {
+	args := []string{}
+	if len(db.host) > 0 {
+		args = append(args, "--host="+db.host)
+	}
+	if len(db.port) > 0 {
+		args = append(args, "--port="+db.port)
+	}
+	if len(db.username) > 0 {
+		args = append(args, "--username="+db.username)
+	}
+	if len(db.database) > 0 {
+		args = append(args, "--dbname="+db.database)
+	}
+
+	out, err := helper.ExecContext(db.ctx, "pg_isready", args...)
+	if err != nil {
+		return fmt.Errorf("%s %v", out, err)
+	}
+	return nil
+}
+
+func (db *Redis) ping() error {
+	if db.mode == redisModeCopy {
+		if !helper.IsExistsPath(db.rdbPath) {
+			return fmt.Errorf("Redis RDB file: %s does not exist", db.rdbPath)
+		}
+		return nil
+	}
+
+	args := []string{}
+	if len(db.host) > 0 {
+		args = append(args, "-h", db.host)
+	}
+	if len(db.port) > 0 {
+		args = append(args, "-p", db.port)
+	}
+	if len(db.socket) > 0 {
+		args = append(args, "-s", db.socket)
+	}
+	if len(db.password) > 0 {
+		args = append(args, "-a", db.password)
+	}
+
+	out, err := helper.ExecContext(db.ctx, "redis-cli", append(args, "PING")...)
+	if err != nil {
+		return err
+	}
+	if out != "PONG" {
+		return fmt.Errorf("PING returned %s", out)
+	}
+	return nil
+}
+
+func (db *SQLite) ping() error {
+	if !helper.IsExistsPath(db.path) {
+		return fmt.Errorf("%s does not exist", db.path)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func TestCheck(t *testing.T) {
+	sqlite := viper.New()
+	sqlite.Set("path", "/not/exist/gobackup.db")
+	sqlite.Set("mode", "backup")
+
+	model := config.ModelConfig{
+		Name:     "test",
+		DumpPath: t.TempDir(),
+		Databases: map[string]config.SubConfig{
+			"sqlite": {Name: "sqlite", Type: "sqlite", Viper: sqlite},
+			"foo":    {Name: "foo", Type: "foo", Viper: viper.New()},
+		},
+	}
+
+	errs := Check(model, false)
+	assert.Equal(t, 1, len(errs))
+	assert.EqualError(t, errs[0], "model: test databases.foo config `type: foo`, but is not implement")
+
+	errs = Check(model, true)
+	assert.Equal(t, 2, len(errs))
+}
+
+func TestBinaries(t *testing.T) {
+	v := viper.New()
+	v.Set("database", "gobackup")
+	base := newVerifyBase(t, "postgresql", v)
+	db := &PostgreSQL{Base: base}
+	assert.NoError(t, db.init())
+	assert.Equal(t, []string{"pg_dump"}, binaries(db))
+
+	// pg_restore is used to verify the custom format dump
+	v.Set("args", "--format=custom")
+	assert.NoError(t, db.init())
+	assert.Equal(t, []string{"pg_dump", "pg_restore"}, binaries(db))
+	v.Set("verify", false)
+	assert.Equal(t, []string{"pg_dump"}, binaries(db))
+
+	base = newVerifyBase(t, "cassandra", viper.New())
+	assert.Equal(t, []string{"nodetool", "cp"}, binaries(&Cassandra{Base: base}))
+}
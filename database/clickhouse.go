@@ -0,0 +1,153 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/logger"
+)
+
+// ClickHouse database
+//
+// ref:
+// https://clickhouse.com/docs/en/operations/backup
+// https://github.com/Altinity/clickhouse-backup
+//
+// # Keys
+//
+//   - type: clickhouse
+//   - mode: backup # or clickhouse-backup
+//   - host: 127.0.0.1
+//   - port: 9000
+//   - username: default
+//   - password:
+//   - database:
+//   - disk: backups
+//   - disk_path: /var/lib/clickhouse/backups
+//   - backup_path: /var/lib/clickhouse/backup
+//   - args:
+//
+// The `backup` mode runs `BACKUP DATABASE ... TO Disk(...)` via clickhouse-client,
+// then moves the archive from `disk_path` (the local path of `disk`) into the dump path.
+// The `clickhouse-backup` mode runs `clickhouse-backup create`, then copies the backup
+// from `backup_path` and deletes the local one.
+type ClickHouse struct {
+	Base
+	mode       string
+	host       string
+	port       string
+	username   string
+	password   string
+	database   string
+	disk       string
+	diskPath   string
+	backupPath string
+	args       string
+
+	backupName string
+}
+
+func (db *ClickHouse) init() (err error) {
+	viper := db.viper
+	viper.SetDefault("mode", "backup")
+	viper.SetDefault("host", "127.0.0.1")
+	viper.SetDefault("port", 9000)
+	viper.SetDefault("username", "default")
+	viper.SetDefault("disk", "backups")
+	viper.SetDefault("disk_path", "/var/lib/clickhouse/backups")
+	viper.SetDefault("backup_path", "/var/lib/clickhouse/backup")
+
+	db.mode = viper.GetString("mode")
+	db.host = viper.GetString("host")
+	db.port = viper.GetString("port")
+	db.username = viper.GetString("username")
+	db.password = viper.GetString("password")
+	db.database = viper.GetString("database")
+	db.disk = viper.GetString("disk")
+	db.diskPath = helper.ExplandHome(viper.GetString("disk_path"))
+	db.backupPath = helper.ExplandHome(viper.GetString("backup_path"))
+	db.args = viper.GetString("args")
+
+	switch db.mode {
+	case "backup":
+		if len(db.database) == 0 {
+			return fmt.Errorf("ClickHouse database config is required")
+		}
+	case "clickhouse-backup":
+	default:
+		return fmt.Errorf("ClickHouse mode %q is not supported, use `backup` or `clickhouse-backup`", db.mode)
+	}
+
+	db.backupName = fmt.Sprintf("%s-%s", db.name, time.Now().Format("20060102150405"))
+
+	return nil
+}
+
+func (db *ClickHouse) buildArgs() []string {
+	args := []string{}
+
+	if db.mode == "clickhouse-backup" {
+		if len(db.database) > 0 {
+			args = append(args, "--tables="+db.database+".*")
+		}
+		if len(db.args) > 0 {
+			args = append(args, strings.Fields(db.args)...)
+		}
+		return append([]string{"create"}, append(args, db.backupName)...)
+	}
+
+	if len(db.host) > 0 {
+		args = append(args, "--host", db.host)
+	}
+	if len(db.port) > 0 {
+		args = append(args, "--port", db.port)
+	}
+	if len(db.username) > 0 {
+		args = append(args, "--user", db.username)
+	}
+	if len(db.password) > 0 {
+		args = append(args, "--password", db.password)
+	}
+	if len(db.args) > 0 {
+		args = append(args, strings.Fields(db.args)...)
+	}
+
+	query := fmt.Sprintf("BACKUP DATABASE `%s` TO Disk('%s', '%s.zip')", db.database, db.disk, db.backupName)
+	return append(args, "--query", query)
+}
+
+func (db *ClickHouse) perform() error {
+	logger := logger.Tag("ClickHouse").WithContext(db.ctx)
+
+	if db.mode == "clickhouse-backup" {
+		logger.Info("-> Creating backup with clickhouse-backup...")
+		if _, err := helper.ExecContext(db.ctx, "clickhouse-backup", db.buildArgs()...); err != nil {
+			return fmt.Errorf("-> Backup error: %s", err)
+		}
+
+		source := filepath.Join(db.backupPath, db.backupName)
+		if _, err := helper.ExecContext(db.ctx, "cp", "-a", source, db.dumpPath); err != nil {
+			return fmt.Errorf("copy %s failed: %s", source, err)
+		}
+
+		if _, err := helper.ExecContext(db.ctx, "clickhouse-backup", "delete", "local", db.backupName); err != nil {
+			logger.Warnf("Delete local backup %s failed: %s", db.backupName, err)
+		}
+	} else {
+		logger.Info("-> Backing up ClickHouse...")
+		if _, err := helper.ExecContext(db.ctx, "clickhouse-client", db.buildArgs()...); err != nil {
+			return fmt.Errorf("-> Backup error: %s", err)
+		}
+
+		source := filepath.Join(db.diskPath, db.backupName+".zip")
+		if _, err := helper.ExecContext(db.ctx, "mv", source, db.dumpPath); err != nil {
+			return fmt.Errorf("move %s failed: %s", source, err)
+		}
+	}
+
+	logger.Info("dump path:", db.dumpPath)
+	return nil
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func TestClickHouse_init(t *testing.T) {
+	viper := viper.New()
+	viper.Set("host", "1.2.3.4")
+	viper.Set("database", "my_db")
+	viper.Set("password", "pass1")
+	viper.Set("args", "--secure --max_threads 4")
+
+	base := newBase(
+		config.ModelConfig{
+			DumpPath: "/data/backups",
+		},
+		config.SubConfig{
+			Type:  "clickhouse",
+			Name:  "clickhouse1",
+			Viper: viper,
+		},
+	)
+
+	db := &ClickHouse{
+		Base: base,
+	}
+
+	err := db.init()
+	assert.NoError(t, err)
+	db.backupName = "clickhouse1-20230101000000"
+
+	assert.Equal(t, db.mode, "backup")
+	assert.Equal(t, db.diskPath, "/var/lib/clickhouse/backups")
+	assert.Equal(t, db.buildArgs(), []string{
+		"--host", "1.2.3.4",
+		"--port", "9000",
+		"--user", "default",
+		"--password", "pass1",
+		"--secure", "--max_threads", "4",
+		"--query", "BACKUP DATABASE `my_db` TO Disk('backups', 'clickhouse1-20230101000000.zip')",
+	})
+}
+
+func TestClickHouse_initClickHouseBackup(t *testing.T) {
+	viper := viper.New()
+	viper.Set("mode", "clickhouse-backup")
+	viper.Set("database", "my_db")
+
+	base := newBase(
+		config.ModelConfig{
+			DumpPath: "/data/backups",
+		},
+		config.SubConfig{
+			Type:  "clickhouse",
+			Name:  "clickhouse1",
+			Viper: viper,
+		},
+	)
+
+	db := &ClickHouse{
+		Base: base,
+	}
+
+	err := db.init()
+	assert.NoError(t, err)
+	db.backupName = "clickhouse1-20230101000000"
+
+	assert.Equal(t, db.buildArgs(), []string{"create", "--tables=my_db.*", "clickhouse1-20230101000000"})
+
+	viper.Set("mode", "foo")
+	err = db.init()
+	assert.EqualError(t, err, "ClickHouse mode \"foo\" is not supported, use `backup` or `clickhouse-backup`")
+
+	viper.Set("mode", "backup")
+	viper.Set("database", "")
+	err = db.init()
+	assert.EqualError(t, err, "ClickHouse database config is required")
+}
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/logger"
+)
+
+// Elasticsearch / OpenSearch database
+//
+// ref:
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/snapshot-restore.html
+// https://opensearch.org/docs/latest/tuning-your-cluster/availability-and-recovery/snapshots/index/
+//
+// # Keys
+//
+//   - type: elasticsearch # or opensearch
+//   - url: http://127.0.0.1:9200
+//   - username:
+//   - password:
+//   - repository: gobackup
+//   - repository_path: /mnt/backups/gobackup
+//   - create_repository: true
+//   - indices: "*"
+//   - keep_snapshot: false
+//   - request_timeout: 1h
+//
+// The `repository_path` must be listed in `path.repo` of every node and be
+// readable by gobackup. Each snapshot is taken in a new directory
+// `<repository_path>/<snapshot>`, which the repository is registered at, so
+// the backup only contains the files of this snapshot.
+//
+// With `create_repository: false`, the repository registered by yourself is
+// used, and the whole `repository_path` is archived, including the older
+// snapshots in the repository.
+type Elasticsearch struct {
+	Base
+	url              string
+	username         string
+	password         string
+	repository       string
+	repositoryPath   string
+	createRepository bool
+	indices          string
+	keepSnapshot     bool
+	client           *http.Client
+
+	snapshot string
+}
+
+func (db *Elasticsearch) init() (err error) {
+	viper := db.viper
+	viper.SetDefault("url", "http://127.0.0.1:9200")
+	viper.SetDefault("repository", "gobackup")
+	viper.SetDefault("create_repository", true)
+	viper.SetDefault("indices", "*")
+	viper.SetDefault("request_timeout", "1h")
+
+	db.url = strings.TrimSuffix(viper.GetString("url"), "/")
+	db.username = viper.GetString("username")
+	db.password = viper.GetString("password")
+	db.repository = viper.GetString("repository")
+	db.repositoryPath = helper.ExplandHome(viper.GetString("repository_path"))
+	db.createRepository = viper.GetBool("create_repository")
+	db.indices = viper.GetString("indices")
+	db.keepSnapshot = viper.GetBool("keep_snapshot")
+	// `timeout` is the deadline of the whole dump, see Base
+	requestTimeout, err := helper.ParseTimeout(viper.GetString("request_timeout"))
+	if err != nil {
+		return fmt.Errorf("Elasticsearch request_timeout: %v", err)
+	}
+	db.client = &http.Client{Timeout: requestTimeout}
+
+	if len(db.repositoryPath) == 0 {
+		return fmt.Errorf("Elasticsearch repository_path config is required")
+	}
+
+	db.snapshot = fmt.Sprintf("%s-%s", db.name, time.Now().Format("20060102150405"))
+
+	return nil
+}
+
+func (db *Elasticsearch) request(method, path string, body any) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(db.ctx, method, db.url+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(db.username) > 0 {
+		req.SetBasicAuth(db.username, db.password)
+	}
+
+	resp, err := db.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s status: %d, body: %s", method, path, resp.StatusCode, string(respBody))
+	}
+
+	return respBody, nil
+}
+
+func (db *Elasticsearch) perform() (err error) {
+	logger := logger.Tag("Elasticsearch").WithContext(db.ctx)
+
+	// The directory to archive
+	location := db.repositoryPath
+	if db.createRepository {
+		location = filepath.Join(db.repositoryPath, db.snapshot)
+		logger.Info("-> Registering snapshot repository", db.repository, "at", location)
+		_, err = db.request("PUT", "/_snapshot/"+db.repository, map[string]any{
+			"type": "fs",
+			"settings": map[string]any{
+				"location": location,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("register repository failed: %v", err)
+		}
+
+		defer func() {
+			// The repository is kept with the snapshot
+			if err == nil && db.keepSnapshot {
+				return
+			}
+			if _, err := db.request("DELETE", "/_snapshot/"+db.repository, nil); err != nil {
+				logger.Warnf("Unregister repository %s failed: %v", db.repository, err)
+			}
+			if err := os.RemoveAll(location); err != nil {
+				logger.Warnf("Remove %s failed: %v", location, err)
+			}
+		}()
+	}
+
+	logger.Info("-> Creating snapshot", db.snapshot)
+	snapshotPath := fmt.Sprintf("/_snapshot/%s/%s", db.repository, db.snapshot)
+	respBody, err := db.request("PUT", snapshotPath+"?wait_for_completion=true", map[string]any{
+		"indices":              db.indices,
+		"include_global_state": true,
+	})
+	if err != nil {
+		return fmt.Errorf("create snapshot failed: %v", err)
+	}
+
+	if !db.keepSnapshot {
+		defer func() {
+			if _, err := db.request("DELETE", snapshotPath, nil); err != nil {
+				logger.Warnf("Delete snapshot %s failed: %v", db.snapshot, err)
+			}
+		}()
+	}
+
+	var result struct {
+		Snapshot struct {
+			State    string `json:"state"`
+			Failures []any  `json:"failures"`
+		} `json:"snapshot"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("parse snapshot result failed: %v", err)
+	}
+	if result.Snapshot.State != "SUCCESS" {
+		return fmt.Errorf("snapshot %s state is %s, failures: %v", db.snapshot, result.Snapshot.State, result.Snapshot.Failures)
+	}
+
+	if _, err := helper.ExecContext(db.ctx, "cp", "-a", strings.TrimSuffix(location, "/")+"/.", db.dumpPath); err != nil {
+		return fmt.Errorf("copy repository %s failed: %s", location, err)
+	}
+
+	logger.Info("dump path:", db.dumpPath)
+	return nil
+}
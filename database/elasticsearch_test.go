@@ -0,0 +1,165 @@
+package database
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func TestElasticsearch_init(t *testing.T) {
+	viper := viper.New()
+	viper.Set("url", "http://es.local:9200/")
+	viper.Set("repository_path", "/mnt/backups/gobackup")
+
+	base := newBase(
+		config.ModelConfig{
+			DumpPath: "/data/backups",
+		},
+		config.SubConfig{
+			Type:  "elasticsearch",
+			Name:  "es1",
+			Viper: viper,
+		},
+	)
+
+	db := &Elasticsearch{
+		Base: base,
+	}
+
+	err := db.init()
+	assert.NoError(t, err)
+	assert.Equal(t, db.url, "http://es.local:9200")
+	assert.Equal(t, db.repository, "gobackup")
+	assert.Equal(t, db.indices, "*")
+	assert.Equal(t, db.createRepository, true)
+	assert.Equal(t, time.Hour, db.client.Timeout)
+
+	viper.Set("request_timeout", "30m")
+	assert.NoError(t, db.init())
+	assert.Equal(t, 30*time.Minute, db.client.Timeout)
+
+	viper.Set("request_timeout", "soon")
+	assert.EqualError(t, db.init(), "Elasticsearch request_timeout: invalid timeout: soon")
+
+	viper.Set("request_timeout", nil)
+	viper.Set("repository_path", "")
+	err = db.init()
+	assert.EqualError(t, err, "Elasticsearch repository_path config is required")
+}
+
+func newTestElasticsearch(t *testing.T, viper *viper.Viper, state string) (*Elasticsearch, *[]string) {
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+
+		if r.Method == "PUT" && r.URL.Path == "/_snapshot/gobackup/es1-snapshot" {
+			json.NewEncoder(w).Encode(map[string]any{
+				"snapshot": map[string]any{"state": state},
+			})
+			return
+		}
+		w.Write([]byte(`{"acknowledged":true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	viper.Set("url", server.URL)
+
+	base := newBase(
+		config.ModelConfig{
+			DumpPath: t.TempDir(),
+		},
+		config.SubConfig{
+			Type:  "elasticsearch",
+			Name:  "es1",
+			Viper: viper,
+		},
+	)
+
+	db := &Elasticsearch{
+		Base: base,
+	}
+	assert.NoError(t, db.init())
+	db.snapshot = "es1-snapshot"
+
+	return db, &requests
+}
+
+func TestElasticsearch_perform(t *testing.T) {
+	repositoryPath := t.TempDir()
+	// The older snapshot in repository_path and the files of the new snapshot
+	err := os.WriteFile(filepath.Join(repositoryPath, "index-0"), []byte("{}"), 0644)
+	assert.NoError(t, err)
+	location := filepath.Join(repositoryPath, "es1-snapshot")
+	assert.NoError(t, os.MkdirAll(location, 0755))
+	err = os.WriteFile(filepath.Join(location, "index-1"), []byte("{}"), 0644)
+	assert.NoError(t, err)
+
+	viper := viper.New()
+	viper.Set("repository_path", repositoryPath)
+	viper.Set("indices", "logs-*")
+	db, requests := newTestElasticsearch(t, viper, "SUCCESS")
+
+	err = db.perform()
+	assert.NoError(t, err)
+	assert.Equal(t, *requests, []string{
+		`PUT /_snapshot/gobackup {"settings":{"location":"` + location + `"},"type":"fs"}`,
+		`PUT /_snapshot/gobackup/es1-snapshot?wait_for_completion=true {"include_global_state":true,"indices":"logs-*"}`,
+		`DELETE /_snapshot/gobackup/es1-snapshot `,
+		`DELETE /_snapshot/gobackup `,
+	})
+	assert.True(t, helper.IsExistsPath(filepath.Join(db.dumpPath, "index-1")))
+	assert.False(t, helper.IsExistsPath(filepath.Join(db.dumpPath, "index-0")))
+	assert.False(t, helper.IsExistsPath(location))
+}
+
+func TestElasticsearch_perform_failed(t *testing.T) {
+	repositoryPath := t.TempDir()
+	location := filepath.Join(repositoryPath, "es1-snapshot")
+
+	viper := viper.New()
+	viper.Set("repository_path", repositoryPath)
+	viper.Set("keep_snapshot", true)
+	db, requests := newTestElasticsearch(t, viper, "FAILED")
+
+	err := db.perform()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "snapshot es1-snapshot state is FAILED")
+	// The repository registered by this run is unregistered
+	assert.Equal(t, *requests, []string{
+		`PUT /_snapshot/gobackup {"settings":{"location":"` + location + `"},"type":"fs"}`,
+		`PUT /_snapshot/gobackup/es1-snapshot?wait_for_completion=true {"include_global_state":true,"indices":"*"}`,
+		`DELETE /_snapshot/gobackup `,
+	})
+	assert.False(t, helper.IsExistsPath(location))
+}
+
+func TestElasticsearch_perform_existingRepository(t *testing.T) {
+	repositoryPath := t.TempDir()
+	err := os.WriteFile(filepath.Join(repositoryPath, "index-0"), []byte("{}"), 0644)
+	assert.NoError(t, err)
+
+	viper := viper.New()
+	viper.Set("repository_path", repositoryPath)
+	viper.Set("create_repository", false)
+	db, requests := newTestElasticsearch(t, viper, "SUCCESS")
+
+	err = db.perform()
+	assert.NoError(t, err)
+	assert.Equal(t, *requests, []string{
+		`PUT /_snapshot/gobackup/es1-snapshot?wait_for_completion=true {"include_global_state":true,"indices":"*"}`,
+		`DELETE /_snapshot/gobackup/es1-snapshot `,
+	})
+	// The whole repository is archived
+	assert.True(t, helper.IsExistsPath(filepath.Join(db.dumpPath, "index-0")))
+}
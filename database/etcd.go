@@ -74,14 +74,27 @@ func (db *Etcd) build() string {
 }
 
 func (db *Etcd) perform() error {
-	logger := logger.Tag("etcd")
+	logger := logger.Tag("etcd").WithContext(db.ctx)
 
 	logger.Info("-> Getting snapshot from etcd...")
 
-	_, err := helper.Exec(db.build())
+	_, err := helper.ExecContext(db.ctx, db.build())
 	if err != nil {
 		return err
 	}
 	logger.Info("snapshot path: ", db._dumpFilePath)
 	return nil
 }
+
+// verify the snapshot with `etcdctl snapshot status`
+func (db *Etcd) verify() error {
+	if err := db.checkDumpSize(db._dumpFilePath); err != nil {
+		return err
+	}
+
+	if _, err := helper.ExecContext(db.ctx, "etcdctl", "snapshot", "status", db._dumpFilePath); err != nil {
+		return fmt.Errorf("etcdctl snapshot status %s failed: %v", db._dumpFilePath, err)
+	}
+
+	return nil
+}
@@ -88,11 +88,11 @@ func (db *Firebird) build() string {
 }
 
 func (db *Firebird) perform() error {
-	logger := logger.Tag("Firebird")
+	logger := logger.Tag("Firebird").WithContext(db.ctx)
 
 	logger.Info("-> Dumping Firebird...")
 
-	_, err := helper.Exec(db.build())
+	_, err := helper.ExecContext(db.ctx, db.build())
 	if err != nil {
 		return err
 	}
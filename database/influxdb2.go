@@ -82,10 +82,10 @@ func (db *InfluxDB2) influxCliArguments() []string {
 }
 
 func (db *InfluxDB2) perform() error {
-	logger := logger.Tag("InfluxDB2")
+	logger := logger.Tag("InfluxDB2").WithContext(db.ctx)
 
 	args := db.influxCliArguments()
-	out, err := helper.Exec("influx", args...)
+	out, err := helper.ExecContext(db.ctx, "influx", args...)
 	if err != nil {
 		return fmt.Errorf("-> Dump error: %s", err)
 	}
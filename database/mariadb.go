@@ -2,6 +2,7 @@ package database
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/gobackup/gobackup/helper"
@@ -94,13 +95,33 @@ func (db *MariaDB) build() string {
 }
 
 func (db *MariaDB) perform() error {
-	logger := logger.Tag("MariaDB")
+	logger := logger.Tag("MariaDB").WithContext(db.ctx)
 
 	logger.Info("-> Dumping MariaDB...")
-	_, err := helper.Exec(db.build())
+	_, err := helper.ExecContext(db.ctx, db.build())
 	if err != nil {
 		return fmt.Errorf("-> Dump error: %s", err)
 	}
 	logger.Info("dump path:", db.dumpPath)
 	return nil
 }
+
+// verify the backup has been completed by the checkpoints file of mariadb-backup
+func (db *MariaDB) verify() error {
+	if err := db.checkDumpSize(db.dumpPath); err != nil {
+		return err
+	}
+
+	for _, name := range []string{"mariadb_backup_checkpoints", "xtrabackup_checkpoints"} {
+		checkpoints, err := readHead(filepath.Join(db.dumpPath, name), 4096)
+		if err != nil {
+			continue
+		}
+		if !strings.Contains(checkpoints, "backup_type = full-backuped") {
+			return fmt.Errorf("%s is not a completed full backup:\n%s", name, checkpoints)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("mariadb-backup checkpoints file not found in %s", db.dumpPath)
+}
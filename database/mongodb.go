@@ -145,9 +145,9 @@ func (db *MongoDB) additionOption() string {
 }
 
 func (db *MongoDB) perform() error {
-	logger := logger.Tag("MongoDB")
+	logger := logger.Tag("MongoDB").WithContext(db.ctx)
 
-	out, err := helper.Exec(db.build())
+	out, err := helper.ExecContext(db.ctx, db.build())
 	if err != nil {
 		return fmt.Errorf("-> Dump error: %s", err)
 	}
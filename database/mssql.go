@@ -130,7 +130,7 @@ func (db *MSSQL) getAllDatabases() ([]string, error) {
 		args = append(args, "-C")
 	}
 
-	output, err := helper.Exec("sqlcmd", args...)
+	output, err := helper.ExecContext(db.ctx, "sqlcmd", args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get databases: %s", err)
 	}
@@ -160,7 +160,7 @@ func (db *MSSQL) shouldSkipDatabase(databaseName string) bool {
 }
 
 func (db *MSSQL) perform() error {
-	logger := logger.Tag("MSSQL")
+	logger := logger.Tag("MSSQL").WithContext(db.ctx)
 
 	if db.allDatabases {
 		databases, err := db.getAllDatabases()
@@ -193,7 +193,7 @@ func (db *MSSQL) perform() error {
 			// Update the database field directly so build() uses the correct database name
 			db.database = databaseName
 			logger.Infof("Backing up database: %s", databaseName)
-			out, err := helper.Exec(db.build())
+			out, err := helper.ExecContext(db.ctx, db.build())
 			if err != nil {
 				return fmt.Errorf("-> Dump error for database %s: %s", databaseName, err)
 			}
@@ -207,7 +207,7 @@ func (db *MSSQL) perform() error {
 		return fmt.Errorf("database config is required when `all_databases` is false")
 	}
 
-	out, err := helper.Exec(db.build())
+	out, err := helper.ExecContext(db.ctx, db.build())
 	if err != nil {
 		return fmt.Errorf("-> Dump error: %s", err)
 	}
@@ -110,25 +110,51 @@ func (db *MySQL) build() string {
 		}
 	}
 
-	// Determine dump file name
+	dumpArgs = append(dumpArgs, "--result-file="+db.dumpFilePath())
+
+	return "mysqldump" + " " + strings.Join(dumpArgs, " ")
+}
+
+func (db *MySQL) dumpFilePath() string {
 	dumpFileName := db.database + ".sql"
 	if db.allDatabases {
 		dumpFileName = "all-databases.sql"
 	}
-	dumpFilePath := path.Join(db.dumpPath, dumpFileName)
-	dumpArgs = append(dumpArgs, "--result-file="+dumpFilePath)
-
-	return "mysqldump" + " " + strings.Join(dumpArgs, " ")
+	return path.Join(db.dumpPath, dumpFileName)
 }
 
 func (db *MySQL) perform() error {
-	logger := logger.Tag("MySQL")
+	logger := logger.Tag("MySQL").WithContext(db.ctx)
 
 	logger.Info("-> Dumping MySQL...")
-	_, err := helper.Exec(db.build())
+	_, err := helper.ExecContext(db.ctx, db.build())
 	if err != nil {
 		return fmt.Errorf("-> Dump error: %s", err)
 	}
 	logger.Info("dump path:", db.dumpPath)
 	return nil
 }
+
+// verify the dump ends with the `-- Dump completed` trailer,
+// which is written by mysqldump unless `--skip-comments` or `--compact` is given.
+func (db *MySQL) verify() error {
+	dumpFilePath := db.dumpFilePath()
+	if err := db.checkDumpSize(dumpFilePath); err != nil {
+		return err
+	}
+	for _, arg := range strings.Fields(db.args) {
+		if arg == "--skip-comments" || arg == "--compact" {
+			return nil
+		}
+	}
+
+	tail, err := readTail(dumpFilePath, 512)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(tail, "-- Dump completed") {
+		return fmt.Errorf("%s is missing the `-- Dump completed` trailer, the dump may be truncated", dumpFilePath)
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/gobackup/gobackup/helper"
@@ -162,7 +163,7 @@ func (db *PostgreSQL) build() string {
 }
 
 func (db *PostgreSQL) perform() error {
-	logger := logger.Tag("PostgreSQL")
+	logger := logger.Tag("PostgreSQL").WithContext(db.ctx)
 
 	logger.Info("-> Dumping PostgreSQL...")
 	if len(db.password) > 0 {
@@ -172,9 +173,9 @@ func (db *PostgreSQL) perform() error {
 	var err error
 	if db.allDatabases {
 		// Use ExecScript for pg_dumpall to properly handle shell redirection
-		_, err = helper.ExecScript(db.build())
+		_, err = helper.ExecScriptContext(db.ctx, db.build())
 	} else {
-		_, err = helper.Exec(db.build())
+		_, err = helper.ExecContext(db.ctx, db.build())
 	}
 	if err != nil {
 		return err
@@ -182,3 +183,48 @@ func (db *PostgreSQL) perform() error {
 	logger.Info("dump path:", db._dumpFilePath)
 	return nil
 }
+
+// verify custom, directory and tar format dumps with `pg_restore --list`,
+// and plain SQL dumps by the `dump complete` trailer.
+func (db *PostgreSQL) verify() error {
+	if err := db.checkDumpSize(db._dumpFilePath); err != nil {
+		return err
+	}
+
+	if format := db.dumpFormat(); format != "p" {
+		if _, err := helper.ExecContext(db.ctx, "pg_restore", "--list", "--format="+format, db._dumpFilePath); err != nil {
+			return fmt.Errorf("pg_restore --list %s failed: %v", db._dumpFilePath, err)
+		}
+		return nil
+	}
+
+	tail, err := readTail(db._dumpFilePath, 512)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(tail, "dump complete") {
+		return fmt.Errorf("%s is missing the `PostgreSQL database dump complete` trailer, the dump may be truncated", db._dumpFilePath)
+	}
+
+	return nil
+}
+
+// pgDumpFormat matches the `--format` / `-F` option of pg_dump in args
+var pgDumpFormat = regexp.MustCompile(`(?:^|\s)(?:--format[=\s]+|-F\s*)([a-zA-Z]+)`)
+
+// dumpFormat returns the output format of pg_dump: p (plain), c (custom), d (directory) or t (tar).
+// The last `--format` in args wins like pg_dump, `compress` implies custom.
+func (db *PostgreSQL) dumpFormat() string {
+	if db.allDatabases {
+		return "p"
+	}
+
+	format := "p"
+	if len(db.compress) > 0 {
+		format = "c"
+	}
+	if matches := pgDumpFormat.FindAllStringSubmatch(db.args, -1); len(matches) > 0 {
+		format = strings.ToLower(matches[len(matches)-1][1][:1])
+	}
+	return format
+}
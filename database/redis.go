@@ -134,7 +134,7 @@ func (db *Redis) perform() (err error) {
 }
 
 func (db *Redis) trySave() error {
-	logger := logger.Tag("Redis")
+	logger := logger.Tag("Redis").WithContext(db.ctx)
 
 	if !db.invokeSave {
 		return nil
@@ -142,7 +142,7 @@ func (db *Redis) trySave() error {
 
 	// FIXME: add retry
 	logger.Info("Perform redis-cli save...")
-	out, err := helper.Exec(db.build(), "SAVE")
+	out, err := helper.ExecContext(db.ctx, db.build(), "SAVE")
 	if err != nil {
 		return fmt.Errorf("redis-cli SAVE failed %s", err)
 	}
@@ -151,10 +151,10 @@ func (db *Redis) trySave() error {
 }
 
 func (db *Redis) sync() error {
-	logger := logger.Tag("Redis")
+	logger := logger.Tag("Redis").WithContext(db.ctx)
 
 	logger.Info("Syncing redis dump to", db._dumpFilePath)
-	_, err := helper.Exec(db.build())
+	_, err := helper.ExecContext(db.ctx, db.build())
 	if err != nil {
 		return fmt.Errorf("dump redis error: %s", err)
 	}
@@ -167,10 +167,10 @@ func (db *Redis) sync() error {
 }
 
 func (db *Redis) copy() error {
-	logger := logger.Tag("Redis")
+	logger := logger.Tag("Redis").WithContext(db.ctx)
 
 	logger.Info("Copying redis dump to", db._dumpFilePath)
-	_, err := helper.Exec(db.build())
+	_, err := helper.ExecContext(db.ctx, db.build())
 	if err != nil {
 		return fmt.Errorf("copy redis dump file error: %s", err)
 	}
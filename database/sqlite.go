@@ -1,38 +1,74 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
+	"net/url"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"modernc.org/sqlite"
+
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
 )
 
 // SQLite database
 //
-// type: sqlite
-// path:
+// # Keys
+//
+//   - type: sqlite
+//   - path:
+//   - mode: dump # or backup, vacuum
+//   - checkpoint: # passive, full, restart or truncate
+//
+// The `dump` mode exports SQL text with the `sqlite3` CLI's `.dump`.
+// The `backup` mode uses the SQLite online backup API and `vacuum` uses
+// `VACUUM INTO`, both produce a consistent binary copy without the `sqlite3` CLI
+// while the application keeps writing. They read through SQLite, so any pages
+// still in the `-wal` file are included, and the copy is switched to
+// `journal_mode=DELETE` so it can be restored as a single file.
+// Use `checkpoint` to run `PRAGMA wal_checkpoint` on the source before the copy.
 type SQLite struct {
 	Base
-	path     string
-	database string
+	path       string
+	database   string
+	mode       string
+	checkpoint string
 
 	_dumpFilePath string
 }
 
+var sqliteCheckpointModes = []string{"passive", "full", "restart", "truncate"}
+
 func (db *SQLite) init() error {
 	viper := db.viper
+	viper.SetDefault("mode", "dump")
 
 	db.path = helper.ExplandHome(viper.GetString("path"))
+	db.mode = viper.GetString("mode")
+	db.checkpoint = strings.ToLower(viper.GetString("checkpoint"))
 
 	if len(db.path) == 0 {
 		return fmt.Errorf("SQLite `path` is required, you must special the path of the `.sqlite3` file")
 	}
 
+	if len(db.checkpoint) > 0 && !slices.Contains(sqliteCheckpointModes, db.checkpoint) {
+		return fmt.Errorf("SQLite checkpoint %q is not supported, use one of %v", db.checkpoint, sqliteCheckpointModes)
+	}
+
 	db.database = strings.TrimSuffix(filepath.Base(db.path), filepath.Ext(db.path))
 
-	db._dumpFilePath = filepath.Join(db.dumpPath, db.database+".sql")
+	switch db.mode {
+	case "dump":
+		db._dumpFilePath = filepath.Join(db.dumpPath, db.database+".sql")
+	case "backup", "vacuum":
+		db._dumpFilePath = filepath.Join(db.dumpPath, filepath.Base(db.path))
+	default:
+		return fmt.Errorf("SQLite mode %q is not supported, use `dump`, `backup` or `vacuum`", db.mode)
+	}
 
 	return nil
 }
@@ -47,13 +83,185 @@ func (db *SQLite) buildArgs() []string {
 }
 
 func (db *SQLite) perform() error {
-	logger := logger.Tag("SQLite")
+	logger := logger.Tag("SQLite").WithContext(db.ctx)
 
-	logger.Info("-> Dumping SQLite...")
-	if _, err := helper.Exec("sqlite3", db.buildArgs()...); err != nil {
-		return err
+	switch db.mode {
+	case "backup":
+		logger.Info("-> Backing up SQLite with online backup API...")
+		if err := db.backup(); err != nil {
+			return err
+		}
+	case "vacuum":
+		logger.Info("-> Backing up SQLite with VACUUM INTO...")
+		if err := db.vacuumInto(); err != nil {
+			return err
+		}
+	default:
+		logger.Info("-> Dumping SQLite...")
+		if _, err := helper.ExecContext(db.ctx, "sqlite3", db.buildArgs()...); err != nil {
+			return err
+		}
 	}
 
 	logger.Info("dump path:", db._dumpFilePath)
 	return nil
 }
+
+// open the source database, read-only unless a checkpoint is requested
+func (db *SQLite) open() (*sql.DB, error) {
+	query := url.Values{}
+	query.Add("_pragma", "busy_timeout(5000)")
+	if len(db.checkpoint) == 0 {
+		query.Set("mode", "ro")
+	}
+
+	conn, err := sql.Open("sqlite", helper.SQLiteURI(db.path, query))
+	if err != nil {
+		return nil, err
+	}
+	// The backup API and VACUUM INTO need to stay on one connection
+	conn.SetMaxOpenConns(1)
+
+	if len(db.checkpoint) > 0 {
+		if _, err := conn.ExecContext(db.ctx, fmt.Sprintf("PRAGMA wal_checkpoint(%s)", strings.ToUpper(db.checkpoint))); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("wal_checkpoint failed: %v", err)
+		}
+	}
+
+	return conn, nil
+}
+
+func (db *SQLite) backup() error {
+	src, err := db.open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	conn, err := src.Conn(db.ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn any) error {
+		backuper, ok := driverConn.(interface {
+			NewBackup(string) (*sqlite.Backup, error)
+		})
+		if !ok {
+			return fmt.Errorf("SQLite driver does not support online backup")
+		}
+
+		bck, err := backuper.NewBackup(db._dumpFilePath)
+		if err != nil {
+			return err
+		}
+
+		// Copy by pages, so that the backup can be canceled
+		for more := true; more; {
+			if err := db.ctx.Err(); err != nil {
+				bck.Finish()
+				return err
+			}
+			if more, err = bck.Step(1024); err != nil {
+				bck.Finish()
+				return fmt.Errorf("backup step failed: %v", err)
+			}
+		}
+
+		return bck.Finish()
+	})
+	if err != nil {
+		return err
+	}
+
+	return db.disableWAL()
+}
+
+func (db *SQLite) vacuumInto() error {
+	src, err := db.open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if _, err := src.ExecContext(db.ctx, "VACUUM INTO ?", db._dumpFilePath); err != nil {
+		return fmt.Errorf("VACUUM INTO failed: %v", err)
+	}
+
+	return db.disableWAL()
+}
+
+// disableWAL switches the copy out of WAL mode, so it does not depend on `-wal`/`-shm` files
+func (db *SQLite) disableWAL() error {
+	dst, err := sql.Open("sqlite", db._dumpFilePath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if _, err := dst.Exec("PRAGMA journal_mode=DELETE"); err != nil {
+		return fmt.Errorf("set journal_mode of %s failed: %v", db._dumpFilePath, err)
+	}
+
+	return nil
+}
+
+// verify the dump by `PRAGMA integrity_check`.
+//
+// In `dump` mode, the dump must end with `COMMIT;` and it is loaded into
+// a temporary database before the check.
+func (db *SQLite) verify() error {
+	if err := db.checkDumpSize(db._dumpFilePath); err != nil {
+		return err
+	}
+
+	if db.mode != "dump" {
+		return db.integrityCheck()
+	}
+
+	tail, err := readTail(db._dumpFilePath, 64)
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(strings.TrimSpace(tail), "COMMIT;") {
+		return fmt.Errorf("%s does not end with `COMMIT;`, the dump may be truncated", db._dumpFilePath)
+	}
+
+	// Loaded on disk out of the dump path, the dump may be larger than memory
+	tmpFile, err := os.CreateTemp(db.model.TempPath, "sqlite-verify-*.db")
+	if err != nil {
+		return err
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	out, err := helper.ExecContext(db.ctx, "sqlite3", tmpFile.Name(), ".bail on", ".read "+db._dumpFilePath, "PRAGMA integrity_check")
+	if err != nil {
+		return fmt.Errorf("load %s failed: %v", db._dumpFilePath, err)
+	}
+	if strings.TrimSpace(out) != "ok" {
+		return fmt.Errorf("integrity_check of %s failed: %s", db._dumpFilePath, out)
+	}
+
+	return nil
+}
+
+func (db *SQLite) integrityCheck() error {
+	dst, err := sql.Open("sqlite", helper.SQLiteURI(db._dumpFilePath, url.Values{"mode": {"ro"}}))
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	var result string
+	if err := dst.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("integrity_check of %s failed: %v", db._dumpFilePath, err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity_check of %s failed: %s", db._dumpFilePath, result)
+	}
+
+	return nil
+}
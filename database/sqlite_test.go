@@ -1,9 +1,14 @@
 package database
 
 import (
+	"database/sql"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
 	"github.com/longbridgeapp/assert"
 	"github.com/spf13/viper"
 )
@@ -34,3 +39,127 @@ func TestSQLite_init(t *testing.T) {
 	assert.Equal(t, db._dumpFilePath, "/data/backups/sqlite/sqlite1/my.sql")
 	assert.Equal(t, db.buildArgs(), []string{"/var/db/my.sqlite", ".output /data/backups/sqlite/sqlite1/my.sql", ".dump"})
 }
+
+func TestSQLite_verify(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not found")
+	}
+
+	v := viper.New()
+	v.Set("verify", true)
+	base := newVerifyBase(t, "sqlite", v)
+	base.model.TempPath = t.TempDir()
+	db := &SQLite{Base: base, mode: "dump", _dumpFilePath: filepath.Join(base.dumpPath, "my.sql")}
+
+	err := os.WriteFile(db._dumpFilePath, []byte("PRAGMA foreign_keys=OFF;\nBEGIN TRANSACTION;\nCREATE TABLE a(x);\nINSERT INTO a VAL"), 0644)
+	assert.NoError(t, err)
+	err = verifyDump(db, base)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not end with `COMMIT;`")
+
+	err = os.WriteFile(db._dumpFilePath, []byte("PRAGMA foreign_keys=OFF;\nBEGIN TRANSACTION;\nCREATE TABLE a(x);\nINSERT INTO a VALUES(1);\nCOMMIT;\n"), 0644)
+	assert.NoError(t, err)
+	assert.NoError(t, verifyDump(db, base))
+
+	// The temporary database is removed
+	entries, err := os.ReadDir(base.model.TempPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(entries))
+}
+
+func newSQLiteWALDatabase(t *testing.T) string {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "app.sqlite3")
+	conn, err := sql.Open("sqlite", dbPath)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	for _, stmt := range []string{
+		"PRAGMA journal_mode=WAL",
+		"PRAGMA wal_autocheckpoint=0",
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)",
+		"INSERT INTO users (name) VALUES ('foo'), ('bar')",
+	} {
+		_, err := conn.Exec(stmt)
+		assert.NoError(t, err)
+	}
+
+	// Rows only in the -wal file until checkpoint
+	assert.True(t, helper.IsExistsPath(dbPath+"-wal"))
+
+	return dbPath
+}
+
+func TestSQLite_performBinaryModes(t *testing.T) {
+	for _, mode := range []string{"backup", "vacuum"} {
+		t.Run(mode, func(t *testing.T) {
+			v := viper.New()
+			v.Set("path", newSQLiteWALDatabase(t))
+			v.Set("mode", mode)
+			v.Set("verify", true)
+			base := newVerifyBase(t, "sqlite", v)
+			db := &SQLite{Base: base}
+
+			assert.NoError(t, db.init())
+			assert.Equal(t, db._dumpFilePath, filepath.Join(base.dumpPath, "app.sqlite3"))
+			assert.NoError(t, db.perform())
+			assert.NoError(t, verifyDump(db, base))
+
+			assert.False(t, helper.IsExistsPath(db._dumpFilePath+"-wal"))
+
+			conn, err := sql.Open("sqlite", db._dumpFilePath)
+			assert.NoError(t, err)
+			defer conn.Close()
+
+			var count int
+			assert.NoError(t, conn.QueryRow("SELECT COUNT(*) FROM users").Scan(&count))
+			assert.Equal(t, 2, count)
+
+			var journalMode string
+			assert.NoError(t, conn.QueryRow("PRAGMA journal_mode").Scan(&journalMode))
+			assert.Equal(t, "delete", journalMode)
+		})
+	}
+}
+
+func TestSQLite_performEscapedPath(t *testing.T) {
+	walPath := newSQLiteWALDatabase(t)
+	dir := filepath.Join(filepath.Dir(walPath), "data?mode=memory#1")
+	assert.NoError(t, os.Mkdir(dir, 0755))
+	dbPath := filepath.Join(dir, "app 100%.sqlite3")
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		assert.NoError(t, os.Rename(walPath+suffix, dbPath+suffix))
+	}
+
+	v := viper.New()
+	v.Set("path", dbPath)
+	v.Set("mode", "backup")
+	v.Set("verify", true)
+	base := newVerifyBase(t, "sqlite", v)
+	db := &SQLite{Base: base}
+
+	assert.NoError(t, db.init())
+	assert.NoError(t, db.perform())
+	assert.NoError(t, verifyDump(db, base))
+
+	conn, err := sql.Open("sqlite", helper.SQLiteURI(db._dumpFilePath, nil))
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	var count int
+	assert.NoError(t, conn.QueryRow("SELECT COUNT(*) FROM users").Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+func TestSQLite_initInvalid(t *testing.T) {
+	v := viper.New()
+	v.Set("path", "/var/db/my.sqlite")
+	v.Set("mode", "copy")
+	db := &SQLite{Base: newVerifyBase(t, "sqlite", v)}
+	assert.EqualError(t, db.init(), "SQLite mode \"copy\" is not supported, use `dump`, `backup` or `vacuum`")
+
+	v.Set("mode", "backup")
+	v.Set("checkpoint", "foo")
+	assert.EqualError(t, db.init(), "SQLite checkpoint \"foo\" is not supported, use one of [passive full restart truncate]")
+}
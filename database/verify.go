@@ -0,0 +1,126 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/dustin/go-humanize"
+)
+
+// verifier is implemented by databases that know how to check the dump
+// produced by perform(), for example by parsing it with the vendor tool.
+type verifier interface {
+	verify() error
+}
+
+// verifyDump validates the dump before it is archived, so that a dump tool
+// exiting with 0 but writing a truncated file does not go unnoticed.
+// It's enabled by default, set `verify: false` to skip it.
+//
+// # Keys
+//
+//   - verify: true
+//   - min_size: 1B
+func verifyDump(db Database, base Base) error {
+	if !base.verifyEnabled() {
+		return nil
+	}
+
+	if v, ok := db.(verifier); ok {
+		return v.verify()
+	}
+
+	return base.checkDumpSize(base.dumpPath)
+}
+
+// verifyEnabled returns the `verify` config, default: true
+func (base Base) verifyEnabled() bool {
+	if base.viper == nil {
+		return false
+	}
+	base.viper.SetDefault("verify", true)
+	return base.viper.GetBool("verify")
+}
+
+// checkDumpSize checks the dump file (or directory) exists and its total
+// size is not less than `min_size`.
+func (base Base) checkDumpSize(dumpPath string) error {
+	minSize := uint64(1)
+	if s := base.viper.GetString("min_size"); len(s) > 0 {
+		size, err := humanize.ParseBytes(s)
+		if err != nil {
+			return fmt.Errorf("invalid min_size %q: %v", s, err)
+		}
+		minSize = size
+	}
+
+	if _, err := os.Stat(dumpPath); err != nil {
+		return fmt.Errorf("dump %s not found: %v", dumpPath, err)
+	}
+
+	var total uint64
+	err := filepath.Walk(dumpPath, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			total += uint64(info.Size())
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("stat dump %s: %v", dumpPath, err)
+	}
+
+	if total < minSize {
+		return fmt.Errorf("dump %s is %s, less than min_size %s", dumpPath, humanize.IBytes(total), humanize.IBytes(minSize))
+	}
+
+	return nil
+}
+
+// readTail returns the last n bytes of file
+func readTail(filePath string, n int64) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	offset := info.Size() - n
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// readHead returns the first n bytes of file
+func readHead(filePath string, n int) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, n)
+	l, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return string(buf[:l]), nil
+}
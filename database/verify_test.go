@@ -0,0 +1,161 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func newVerifyBase(t *testing.T, dbType string, v *viper.Viper) Base {
+	t.Helper()
+
+	return newBase(
+		config.ModelConfig{
+			DumpPath: t.TempDir(),
+		},
+		config.SubConfig{
+			Type:  dbType,
+			Name:  dbType + "1",
+			Viper: v,
+		},
+	)
+}
+
+func TestVerifyDump_disabled(t *testing.T) {
+	v := viper.New()
+	v.Set("verify", false)
+	base := newVerifyBase(t, "mongodb", v)
+	db := &MongoDB{Base: base}
+
+	assert.NoError(t, verifyDump(db, base))
+}
+
+func TestVerifyDump_enabledByDefault(t *testing.T) {
+	base := newVerifyBase(t, "mongodb", viper.New())
+	db := &MongoDB{Base: base}
+
+	err := verifyDump(db, base)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "less than min_size")
+}
+
+func TestVerifyDump_minSize(t *testing.T) {
+	v := viper.New()
+	v.Set("verify", true)
+	base := newVerifyBase(t, "mongodb", v)
+	db := &MongoDB{Base: base}
+
+	err := verifyDump(db, base)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "less than min_size")
+
+	err = os.WriteFile(filepath.Join(base.dumpPath, "foo.bson"), []byte("hello"), 0644)
+	assert.NoError(t, err)
+	assert.NoError(t, verifyDump(db, base))
+
+	v.Set("min_size", "1KB")
+	err = verifyDump(db, base)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is 5 B, less than min_size 1000 B")
+
+	v.Set("min_size", "foo")
+	err = verifyDump(db, base)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid min_size")
+}
+
+func TestMySQL_verify(t *testing.T) {
+	v := viper.New()
+	v.Set("verify", true)
+	base := newVerifyBase(t, "mysql", v)
+	db := &MySQL{Base: base, database: "my_db"}
+
+	err := verifyDump(db, base)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+
+	dumpFilePath := filepath.Join(base.dumpPath, "my_db.sql")
+	err = os.WriteFile(dumpFilePath, []byte("CREATE TABLE `foo` (\n"), 0644)
+	assert.NoError(t, err)
+	err = verifyDump(db, base)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "missing the `-- Dump completed` trailer")
+
+	err = os.WriteFile(dumpFilePath, []byte("CREATE TABLE `foo`;\n-- Dump completed on 2023-01-01 00:00:00\n"), 0644)
+	assert.NoError(t, err)
+	assert.NoError(t, verifyDump(db, base))
+
+	// No trailer is written with --skip-comments or --compact
+	err = os.WriteFile(dumpFilePath, []byte("CREATE TABLE `foo`;\n"), 0644)
+	assert.NoError(t, err)
+	db.args = "--single-transaction --compact"
+	assert.NoError(t, verifyDump(db, base))
+	db.args = "--skip-comments"
+	assert.NoError(t, verifyDump(db, base))
+	db.args = "--skip-comments-foo"
+	assert.Error(t, verifyDump(db, base))
+}
+
+func TestPostgreSQL_verify(t *testing.T) {
+	v := viper.New()
+	v.Set("verify", true)
+	base := newVerifyBase(t, "postgresql", v)
+	db := &PostgreSQL{Base: base, _dumpFilePath: filepath.Join(base.dumpPath, "my_db.sql")}
+
+	err := os.WriteFile(db._dumpFilePath, []byte("CREATE TABLE foo (\n"), 0644)
+	assert.NoError(t, err)
+	err = verifyDump(db, base)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "dump may be truncated")
+
+	err = os.WriteFile(db._dumpFilePath, []byte("CREATE TABLE foo ();\n--\n-- PostgreSQL database dump complete\n--\n"), 0644)
+	assert.NoError(t, err)
+	assert.NoError(t, verifyDump(db, base))
+}
+
+func TestPostgreSQL_dumpFormat(t *testing.T) {
+	cases := []struct {
+		args         string
+		compress     string
+		allDatabases bool
+		expect       string
+	}{
+		{"", "", false, "p"},
+		{"--no-owner", "", false, "p"},
+		{"", "--compress=9", false, "c"},
+		{"--format=directory --jobs=4", "", false, "d"},
+		{"--format tar", "", false, "t"},
+		{"-Fd", "", false, "d"},
+		{"-F c", "", false, "c"},
+		{"--format=Plain", "--compress=9", false, "p"},
+		{"--format=c --format=d", "", false, "d"},
+		{"--no-owner --format=directory", "", true, "p"},
+	}
+
+	for _, c := range cases {
+		db := &PostgreSQL{args: c.args, compress: c.compress, allDatabases: c.allDatabases}
+		assert.Equal(t, c.expect, db.dumpFormat(), c.args)
+	}
+}
+
+func TestMariaDB_verify(t *testing.T) {
+	v := viper.New()
+	v.Set("verify", true)
+	base := newVerifyBase(t, "mariadb", v)
+	db := &MariaDB{Base: base}
+
+	checkpoints := filepath.Join(base.dumpPath, "mariadb_backup_checkpoints")
+	err := os.WriteFile(checkpoints, []byte("backup_type = log-applied\n"), 0644)
+	assert.NoError(t, err)
+	err = verifyDump(db, base)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a completed full backup")
+
+	err = os.WriteFile(checkpoints, []byte("backup_type = full-backuped\nfrom_lsn = 0\n"), 0644)
+	assert.NoError(t, err)
+	assert.NoError(t, verifyDump(db, base))
+}
@@ -1,6 +1,10 @@
 package encryptor
 
 import (
+	"context"
+	"fmt"
+	"os/exec"
+
 	"github.com/gobackup/gobackup/config"
 	"github.com/gobackup/gobackup/logger"
 	"github.com/spf13/viper"
@@ -8,6 +12,7 @@ import (
 
 // Base encryptor
 type Base struct {
+	ctx         context.Context
 	model       config.ModelConfig
 	viper       *viper.Viper
 	archivePath string
@@ -20,6 +25,7 @@ type Encryptor interface {
 
 func newBase(archivePath string, model config.ModelConfig) (base *Base) {
 	base = &Base{
+		ctx:         context.Background(),
 		archivePath: archivePath,
 		model:       model,
 		viper:       model.EncryptWith.Viper,
@@ -28,10 +34,11 @@ func newBase(archivePath string, model config.ModelConfig) (base *Base) {
 }
 
 // Run compressor
-func Run(archivePath string, model config.ModelConfig) (encryptPath string, err error) {
-	logger := logger.Tag("Encryptor")
+func Run(ctx context.Context, archivePath string, model config.ModelConfig) (encryptPath string, err error) {
+	logger := logger.Tag("Encryptor").WithContext(ctx)
 
 	base := newBase(archivePath, model)
+	base.ctx = ctx
 	var enc Encryptor
 	switch model.EncryptWith.Type {
 	case "openssl":
@@ -53,3 +60,37 @@ func Run(archivePath string, model config.ModelConfig) (encryptPath string, err
 
 	return
 }
+
+// Decrypt the encrypted archive by encrypt_with config of model, returns the path of decrypted archive
+func Decrypt(ctx context.Context, encryptPath string, model config.ModelConfig) (archivePath string, err error) {
+	logger := logger.Tag("Encryptor").WithContext(ctx)
+
+	base := newBase(encryptPath, model)
+	base.ctx = ctx
+	switch model.EncryptWith.Type {
+	case "openssl":
+		logger.Info("decrypt | " + model.EncryptWith.Type)
+		return NewOpenSSL(base).decrypt()
+	default:
+		return "", fmt.Errorf("%s is encrypted, but encrypt_with is not configured", encryptPath)
+	}
+}
+
+// Check encrypt_with config of model
+func Check(model config.ModelConfig) (errs []error) {
+	switch model.EncryptWith.Type {
+	case "":
+		return nil
+	case "openssl":
+		if len(model.EncryptWith.Viper.GetString("password")) == 0 {
+			errs = append(errs, fmt.Errorf("encrypt_with: password option is required"))
+		}
+		if _, err := exec.LookPath("openssl"); err != nil {
+			errs = append(errs, fmt.Errorf("encrypt_with: openssl cannot be found in $PATH"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("encrypt_with: Unsupported encrypt type: %s", model.EncryptWith.Type))
+	}
+
+	return
+}
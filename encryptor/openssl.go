@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/progress"
 )
 
 // OpenSSL encryptor for use openssl aes-256-cbc
@@ -49,7 +50,9 @@ func (enc *OpenSSL) perform() (encryptPath string, err error) {
 
 	opts := enc.options()
 	opts = append(opts, "-in", enc.archivePath, "-out", enc.encryptPath)
-	_, err = helper.Exec("openssl", opts...)
+	stop := progress.Watch(enc.ctx, progress.KindEncrypt, enc.encryptPath, progress.Size(enc.archivePath))
+	_, err = helper.ExecContext(enc.ctx, "openssl", opts...)
+	stop(err)
 	if err != nil {
 		err = fmt.Errorf("OpenSSL encrypt failed: %s `openssl %s`", strings.TrimSpace(err.Error()), strings.Join(opts, " "))
 		return "", err
@@ -57,6 +60,28 @@ func (enc *OpenSSL) perform() (encryptPath string, err error) {
 	return enc.encryptPath, nil
 }
 
+// decrypt the archive which is encrypted by perform, the `.enc` extension is removed
+func (enc *OpenSSL) decrypt() (archivePath string, err error) {
+	if len(enc.password) == 0 {
+		err = fmt.Errorf("password option is required")
+		return
+	}
+
+	archivePath = strings.TrimSuffix(enc.archivePath, ".enc")
+	if archivePath == enc.archivePath {
+		archivePath += ".dec"
+	}
+
+	// The same options with -d after the cipher
+	opts := append([]string{enc.chiper, "-d"}, enc.options()[1:]...)
+	opts = append(opts, "-in", enc.archivePath, "-out", archivePath)
+	_, err = helper.ExecContext(enc.ctx, "openssl", opts...)
+	if err != nil {
+		return "", fmt.Errorf("OpenSSL decrypt failed: %s", strings.TrimSpace(err.Error()))
+	}
+	return archivePath, nil
+}
+
 func (enc *OpenSSL) options() (opts []string) {
 	opts = append(opts, enc.chiper)
 	if enc.base64 {
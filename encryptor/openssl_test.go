@@ -1,11 +1,17 @@
 package encryptor
 
 import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/longbridgeapp/assert"
 	"github.com/spf13/viper"
+
+	"github.com/gobackup/gobackup/config"
 )
 
 func TestOpenSSL_options(t *testing.T) {
@@ -41,3 +47,42 @@ func TestOpenSSL_options(t *testing.T) {
 	// Verify args are properly split into separate tokens (not treated as single string)
 	assert.Equal(t, 7, len(opts)) // rc4, -base64, -pbkdf2, -iter, 1000, -k, gobackup-123
 }
+
+func TestOpenSSL_decrypt(t *testing.T) {
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl is not installed")
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "archive.tar")
+	assert.NoError(t, os.WriteFile(archivePath, []byte("hello gobackup"), 0644))
+
+	model := config.ModelConfig{
+		Viper: viper.New(),
+		EncryptWith: config.SubConfig{
+			Type:  "openssl",
+			Viper: viper.New(),
+		},
+	}
+	model.EncryptWith.Viper.Set("password", "gobackup-123")
+	model.EncryptWith.Viper.Set("args", "-pbkdf2")
+
+	encryptPath, err := Run(context.Background(), archivePath, model)
+	assert.NoError(t, err)
+	assert.Equal(t, archivePath+".enc", encryptPath)
+	assert.NoError(t, os.Remove(archivePath))
+
+	decryptPath, err := Decrypt(context.Background(), encryptPath, model)
+	assert.NoError(t, err)
+	assert.Equal(t, archivePath, decryptPath)
+	data, err := os.ReadFile(decryptPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello gobackup", string(data))
+
+	model.EncryptWith.Viper.Set("password", "wrong")
+	_, err = Decrypt(context.Background(), encryptPath, model)
+	assert.Error(t, err)
+
+	model.EncryptWith.Type = ""
+	_, err = Decrypt(context.Background(), encryptPath, model)
+	assert.EqualError(t, err, encryptPath+" is encrypted, but encrypt_with is not configured")
+}
@@ -2,6 +2,7 @@ package helper
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -9,13 +10,18 @@ import (
 	"path"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/tracing"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
 	spaceRegexp = regexp.MustCompile(`[\s]+`)
+
+	waitDelay = 5 * time.Second
 )
 
 // Exec cli commands
@@ -23,7 +29,16 @@ func Exec(command string, args ...string) (output string, err error) {
 	return ExecWithStdio(command, false, args...)
 }
 
+// ExecContext cli commands, the command will be killed when ctx is done
+func ExecContext(ctx context.Context, command string, args ...string) (output string, err error) {
+	return ExecWithStdioContext(ctx, command, false, args...)
+}
+
 func ExecWithStdio(command string, stdout bool, args ...string) (output string, err error) {
+	return ExecWithStdioContext(context.Background(), command, stdout, args...)
+}
+
+func ExecWithStdioContext(ctx context.Context, command string, stdout bool, args ...string) (output string, err error) {
 	commands := spaceRegexp.Split(command, -1)
 	command = commands[0]
 	commandArgs := []string{}
@@ -39,31 +54,79 @@ func ExecWithStdio(command string, stdout bool, args ...string) (output string,
 		return "", fmt.Errorf("%s cannot be found", command)
 	}
 
-	cmd := exec.Command(fullCommand, commandArgs...)
+	cmd := exec.CommandContext(ctx, fullCommand, commandArgs...)
 	cmd.Env = os.Environ()
+	killOnCancel(cmd)
+	// Do not wait for the children still holding the output after killed
+	cmd.WaitDelay = waitDelay
 
 	var stdErr bytes.Buffer
 	var stdOut bytes.Buffer
 	cmd.Stderr = &stdErr
 
 	if stdout {
-		cmd.Stdout = os.Stdout
+		// Logged line by line, so it's in the run log and history, and one JSON object per line in JSON format
+		w := &logWriter{logger: logger.Tag(command).WithContext(ctx)}
+		defer w.flush()
+		cmd.Stdout = w
 	} else {
 		cmd.Stdout = &stdOut
 	}
 
 	err = cmd.Run()
+	if cmd.ProcessState != nil {
+		tracing.AddEvent(ctx, "exec",
+			attribute.String("process.executable.name", command),
+			attribute.Int("process.exit.code", cmd.ProcessState.ExitCode()),
+		)
+	}
 	if err != nil {
-		logger.Debug(fullCommand, " ", strings.Join(commandArgs, " "))
-		err = errors.New(stdErr.String())
+		logger.WithContext(ctx).Debug(fullCommand, " ", strings.Join(commandArgs, " "))
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = fmt.Errorf("%s: %w", command, ctxErr)
+		} else {
+			err = errors.New(stdErr.String())
+		}
 	}
 	output = strings.Trim(stdOut.String(), "\n")
 
 	return
 }
 
+// logWriter writes the output of command to logger line by line
+type logWriter struct {
+	logger logger.Logger
+	buf    []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.logger.Info(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+func (w *logWriter) flush() {
+	if len(w.buf) > 0 {
+		w.logger.Info(string(w.buf))
+		w.buf = nil
+	}
+}
+
 // Execute multiple line script with stdio
 func ExecScriptWithStdio(script string, stdout bool) (string, error) {
+	return ExecScriptWithStdioContext(context.Background(), script, stdout)
+}
+
+// Execute multiple line script with stdio, the script will be killed when ctx is done
+func ExecScriptWithStdioContext(ctx context.Context, script string, stdout bool) (string, error) {
 	tmpFileName, _ := uuid.NewUUID()
 	tmpFile := path.Join(os.TempDir(), tmpFileName.String())
 
@@ -80,10 +143,15 @@ func ExecScriptWithStdio(script string, stdout bool) (string, error) {
 	defer f.Close()
 	defer os.Remove(tmpFile)
 
-	return ExecWithStdio("sh", stdout, tmpFile)
+	return ExecWithStdioContext(ctx, "sh", stdout, tmpFile)
 }
 
 // Execute multiple line script
 func ExecScript(script string) (output string, err error) {
 	return ExecScriptWithStdio(script, false)
 }
+
+// Execute multiple line script, the script will be killed when ctx is done
+func ExecScriptContext(ctx context.Context, script string) (output string, err error) {
+	return ExecScriptWithStdioContext(ctx, script, false)
+}
@@ -1,8 +1,12 @@
 package helper
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/gobackup/gobackup/logger"
 	"github.com/longbridgeapp/assert"
 )
 
@@ -39,6 +43,18 @@ func TestExecWithStdio(t *testing.T) {
 	assert.Empty(t, out)
 }
 
+func TestExecWithStdioContext_capture(t *testing.T) {
+	ctx := logger.NewContext(context.Background(), logger.Fields{RunID: "exec-test"})
+	capture := logger.StartCapture("exec-test", 10)
+	defer capture.Stop()
+
+	out, err := ExecWithStdioContext(ctx, "echo", true, "hello\nworld")
+	assert.Nil(t, err)
+	assert.Empty(t, out)
+	assert.Contains(t, capture.String(), "hello")
+	assert.Contains(t, capture.String(), "world")
+}
+
 func TestExecScriptWithStdio(t *testing.T) {
 	out, err := ExecScriptWithStdio("head -n1 ./exec_test.go\n# This is a comment\nhead -n1 ./exec_test.go | wc -c | sed 's/^[[:space:]]*//'\necho hello world\necho foobar", false)
 	assert.Nil(t, err)
@@ -54,3 +70,16 @@ func TestExecScript(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, out, "package helper\nhello world")
 }
+
+func TestExecContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	startedAt := time.Now()
+	_, err := ExecContext(ctx, "sleep", "10")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, time.Since(startedAt) < 5*time.Second)
+
+	_, err = ExecScriptContext(ctx, "sleep 10")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
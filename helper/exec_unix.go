@@ -0,0 +1,17 @@
+//go:build !windows
+
+package helper
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killOnCancel kills the whole process group when the context of cmd is done,
+// so the children (e.g. of sh) will be killed too
+func killOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
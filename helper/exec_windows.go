@@ -0,0 +1,15 @@
+//go:build windows
+
+package helper
+
+import (
+	"os/exec"
+)
+
+// killOnCancel kills the process when the context of cmd is done,
+// the children are not killed since there is no process group on Windows
+func killOnCancel(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		return cmd.Process.Kill()
+	}
+}
@@ -1,6 +1,7 @@
 package helper
 
 import (
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -54,3 +55,9 @@ func AbsolutePath(path string) string {
 
 	return path
 }
+
+// SQLiteURI returns the `file:` URI of path with query, the `?`, `#` and `%` in path are escaped
+func SQLiteURI(path string, query url.Values) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path), OmitHost: true, RawQuery: query.Encode()}
+	return u.String()
+}
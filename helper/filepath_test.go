@@ -1,6 +1,7 @@
 package helper
 
 import (
+	"net/url"
 	"os"
 	"path"
 	"testing"
@@ -61,3 +62,8 @@ func TestAbsolutePath(t *testing.T) {
 	newPath = AbsolutePath("~/foo/bar/dar")
 	assert.Equal(t, newPath, path.Join(os.Getenv("HOME"), "/foo/bar/dar"))
 }
+
+func TestSQLiteURI(t *testing.T) {
+	assert.Equal(t, "file:/data/app%3F%231%25.db?mode=ro", SQLiteURI("/data/app?#1%.db", url.Values{"mode": {"ro"}}))
+	assert.Equal(t, "file:data/app.db", SQLiteURI("data/app.db", nil))
+}
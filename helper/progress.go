@@ -1,6 +1,7 @@
 package helper
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"github.com/cheggaaa/pb/v3"
 	"github.com/dustin/go-humanize"
 	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/progress"
 	"github.com/hako/durafmt"
 )
 
@@ -24,16 +26,22 @@ type ProgressBar struct {
 	startTime  time.Time
 }
 
-func NewProgressBar(myLogger logger.Logger, reader *os.File) ProgressBar {
+// NewProgressBar returns the progress bar of upload, the bytes read are reported to the upload progress of the storage in ctx
+func NewProgressBar(ctx context.Context, myLogger logger.Logger, reader *os.File) ProgressBar {
 	info, _ := reader.Stat()
 	fileLength := info.Size()
 
-	bar := pb.ProgressBarTemplate(progressbarTemplate).Start64(fileLength)
+	bar := pb.ProgressBarTemplate(progressbarTemplate).New(0).SetTotal(fileLength)
 	bar.SetWidth(100)
 	bar.Set("time", time.Now().Format(logger.TimeFormat))
 	bar.Set("prefix", myLogger.Prefix())
+	// The progress bar is not a line of log
+	if logger.IsJSON() {
+		bar.SetWriter(io.Discard)
+	}
+	bar.Start()
 
-	multiReader := bar.NewProxyReader(reader)
+	multiReader := progress.NewReader(ctx, bar.NewProxyReader(reader))
 
 	progressBar := ProgressBar{bar, fileLength, multiReader, myLogger, time.Now()}
 	progressBar.start()
@@ -1,7 +1,10 @@
 package helper
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -36,3 +39,22 @@ func FormatEndpoint(endpoint string) string {
 
 	return endpoint
 }
+
+// ParseTimeout parses a timeout of duration string (e.g. 30m, 2h) or integer seconds,
+// empty or zero means no timeout.
+func ParseTimeout(timeout string) (time.Duration, error) {
+	timeout = strings.TrimSpace(timeout)
+	if len(timeout) == 0 {
+		return 0, nil
+	}
+
+	if seconds, err := strconv.Atoi(timeout); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	duration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout: %s", timeout)
+	}
+	return duration, nil
+}
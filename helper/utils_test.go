@@ -3,6 +3,7 @@ package helper
 import (
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/longbridgeapp/assert"
 )
@@ -27,3 +28,20 @@ func TestFormatEndpoint(t *testing.T) {
 	assert.Equal(t, "https://foo.bar.com", FormatEndpoint("https://foo.bar.com"))
 	assert.Equal(t, "https://foo.bar.com", FormatEndpoint("https://foo.bar.com"))
 }
+
+func TestParseTimeout(t *testing.T) {
+	timeout, err := ParseTimeout("")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), timeout)
+
+	timeout, err = ParseTimeout("300")
+	assert.NoError(t, err)
+	assert.Equal(t, 300*time.Second, timeout)
+
+	timeout, err = ParseTimeout("1h30m")
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, timeout)
+
+	_, err = ParseTimeout("1day")
+	assert.EqualError(t, err, "invalid timeout: 1day")
+}
@@ -0,0 +1,505 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
+
+	// Register sqlite driver
+	_ "modernc.org/sqlite"
+)
+
+// Trigger is what the run was started by
+type Trigger string
+
+// Status of the run
+type Status string
+
+// Kind of the run
+type Kind string
+
+const (
+	TriggerSchedule Trigger = "schedule"
+	TriggerAPI      Trigger = "api"
+	TriggerCLI      Trigger = "cli"
+
+	KindBackup  Kind = "backup"
+	KindVerify  Kind = "verify"
+	KindRestore Kind = "restore"
+
+	StatusQueued   Status = "queued"
+	StatusRunning  Status = "running"
+	StatusSuccess  Status = "success"
+	StatusFailure  Status = "failure"
+	StatusCanceled Status = "canceled"
+	StatusSkipped  Status = "skipped"
+)
+
+var (
+	// ErrNotFound is returned by Get when the record is not exist
+	ErrNotFound = errors.New("run not found")
+	// ErrInterrupted is the error of the records not finished by the previous process
+	ErrInterrupted = errors.New("interrupted")
+
+	// Keep is the number of records to keep for each model
+	Keep = 100
+
+	dbLock sync.Mutex
+	db     *sql.DB
+	dbPath string
+)
+
+type triggerKey struct{}
+
+// Stage is a step of the run, e.g. database, compressor, storage
+type Stage struct {
+	Name string `json:"name"`
+	// Duration in seconds
+	Duration float64 `json:"duration"`
+}
+
+// Record of a model run
+type Record struct {
+	ID         string     `json:"id"`
+	Model      string     `json:"model"`
+	Kind       Kind       `json:"kind"`
+	Trigger    Trigger    `json:"trigger"`
+	Status     Status     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Stages     []Stage    `json:"stages"`
+	// ArchiveSize in bytes, the size before split
+	ArchiveSize int64    `json:"archive_size"`
+	Storages    []string `json:"storages"`
+	Error       string   `json:"error,omitempty"`
+	// Log is the last lines of log during the run
+	Log string `json:"log,omitempty"`
+	// Result of verify or restore
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+// WithTrigger returns a copy of ctx with the trigger of run
+func WithTrigger(ctx context.Context, trigger Trigger) context.Context {
+	return context.WithValue(ctx, triggerKey{}, trigger)
+}
+
+// TriggerFrom returns the trigger in ctx, default: cli
+func TriggerFrom(ctx context.Context) Trigger {
+	if trigger, ok := ctx.Value(triggerKey{}).(Trigger); ok {
+		return trigger
+	}
+	return TriggerCLI
+}
+
+// LogPath returns the path of the log file of the run
+func LogPath(id string) string {
+	return filepath.Join(config.GoBackupDir, "runs", id+".log")
+}
+
+// open the history database in GoBackupDir, it will be reopened when GoBackupDir changed
+func open() (*sql.DB, error) {
+	dbLock.Lock()
+	defer dbLock.Unlock()
+
+	path := filepath.Join(config.GoBackupDir, "history.db")
+	if db != nil && dbPath == path {
+		return db, nil
+	}
+	if db != nil {
+		db.Close()
+		db = nil
+	}
+
+	if err := helper.MkdirP(config.GoBackupDir); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	conn, err := sql.Open("sqlite", helper.SQLiteURI(path, query))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(1)
+
+	_, err = conn.Exec(`
+		CREATE TABLE IF NOT EXISTS runs (
+			id TEXT PRIMARY KEY,
+			model TEXT NOT NULL,
+			status TEXT NOT NULL,
+			started_at INTEGER NOT NULL,
+			data TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS runs_model_started_at ON runs (model, started_at);
+		CREATE TABLE IF NOT EXISTS digests (
+			model TEXT NOT NULL,
+			notifier TEXT NOT NULL,
+			since INTEGER NOT NULL,
+			PRIMARY KEY (model, notifier)
+		);
+	`)
+	if err == nil {
+		err = migrate(conn)
+	}
+	if err == nil {
+		err = interrupt(conn)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("open history %s: %v", path, err)
+	}
+
+	db, dbPath = conn, path
+	return db, nil
+}
+
+// migrate adds the kind column to the runs table created by the old versions, their records are backups
+func migrate(conn *sql.DB) error {
+	var count int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('runs') WHERE name = 'kind'`).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	_, err := conn.Exec(`ALTER TABLE runs ADD COLUMN kind TEXT NOT NULL DEFAULT 'backup'`)
+	return err
+}
+
+// interrupt marks the queued and running records left by the previous process as canceled and failure,
+// they will never be finished.
+func interrupt(conn *sql.DB) error {
+	rows, err := conn.Query(`SELECT data FROM runs WHERE status IN (?, ?)`, StatusQueued, StatusRunning)
+	if err != nil {
+		return err
+	}
+
+	var records []Record
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			rows.Close()
+			return err
+		}
+		record, err := decode(data)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		records = append(records, record)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	finishedAt := time.Now()
+	for _, record := range records {
+		if record.Status == StatusQueued {
+			record.Status = StatusCanceled
+		} else {
+			record.Status = StatusFailure
+		}
+		record.FinishedAt = &finishedAt
+		record.Error = ErrInterrupted.Error()
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if _, err := conn.Exec(`UPDATE runs SET status = ?, data = ? WHERE id = ?`, record.Status, string(data), record.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close the history database
+func Close() error {
+	dbLock.Lock()
+	defer dbLock.Unlock()
+
+	if db == nil {
+		return nil
+	}
+	err := db.Close()
+	db = nil
+	return err
+}
+
+// Save inserts or updates the record, and removes the old records of the model and kind more than Keep
+func Save(record Record) error {
+	db, err := open()
+	if err != nil {
+		return err
+	}
+
+	if len(record.Kind) == 0 {
+		record.Kind = KindBackup
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO runs (id, model, kind, status, started_at, data) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET status = excluded.status, data = excluded.data`,
+		record.ID, record.Model, record.Kind, record.Status, record.StartedAt.UnixNano(), string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("save run %s: %v", record.ID, err)
+	}
+
+	if record.Status == StatusQueued || record.Status == StatusRunning {
+		return nil
+	}
+
+	return prune(db, record.Model, record.Kind)
+}
+
+// prune removes the old records of the model and kind more than Keep, with their log files
+func prune(db *sql.DB, model string, kind Kind) error {
+	rows, err := db.Query(
+		`SELECT id FROM runs WHERE model = ? AND kind = ? ORDER BY started_at DESC LIMIT -1 OFFSET ?`,
+		model, kind, Keep,
+	)
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := db.Exec(`DELETE FROM runs WHERE id = ?`, id); err != nil {
+			return err
+		}
+		if err := os.Remove(LogPath(id)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// List returns the records from the newest, of all models if model is empty, and of all kinds if kind is empty
+func List(model string, kind Kind, limit int) ([]Record, error) {
+	db, err := open()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(
+		`SELECT data FROM runs WHERE (? = '' OR model = ?) AND (? = '' OR kind = ?) ORDER BY started_at DESC LIMIT ?`,
+		model, model, kind, kind, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []Record{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		record, err := decode(data)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// Get the record by id
+func Get(id string) (*Record, error) {
+	db, err := open()
+	if err != nil {
+		return nil, err
+	}
+
+	var data string
+	err = db.QueryRow(`SELECT data FROM runs WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// decode the record in data, the records saved by the old versions are backups
+func decode(data string) (Record, error) {
+	var record Record
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return record, err
+	}
+	if len(record.Kind) == 0 {
+		record.Kind = KindBackup
+	}
+	return record, nil
+}
+
+// ListSince returns the backup records of model started since the time, from the oldest
+func ListSince(model string, since time.Time) ([]Record, error) {
+	db, err := open()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(
+		`SELECT data FROM runs WHERE model = ? AND kind = ? AND started_at >= ? ORDER BY started_at`,
+		model, KindBackup, since.UnixNano(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []Record{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		record, err := decode(data)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// ConsecutiveFailures returns the number of the latest finished backups of model that failed or canceled in a row,
+// the running and skipped runs are ignored.
+func ConsecutiveFailures(model string) (int, error) {
+	db, err := open()
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := db.Query(
+		`SELECT status FROM runs WHERE model = ? AND kind = ? AND status IN (?, ?, ?) ORDER BY started_at DESC`,
+		model, KindBackup, StatusSuccess, StatusFailure, StatusCanceled,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var status Status
+		if err := rows.Scan(&status); err != nil {
+			return 0, err
+		}
+		if status == StatusSuccess {
+			break
+		}
+		count++
+	}
+
+	return count, rows.Err()
+}
+
+// LastSuccess returns the finished time of the latest successful backup of model, zero if there is none
+func LastSuccess(model string) (time.Time, error) {
+	db, err := open()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var data string
+	err = db.QueryRow(
+		`SELECT data FROM runs WHERE model = ? AND kind = ? AND status = ? ORDER BY started_at DESC LIMIT 1`,
+		model, KindBackup, StatusSuccess,
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	record, err := decode(data)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if record.FinishedAt == nil {
+		return record.StartedAt, nil
+	}
+	return *record.FinishedAt, nil
+}
+
+// DigestSince returns the start time of the pending digest of the notifier of model, zero if not started
+func DigestSince(model, notifier string) (time.Time, error) {
+	db, err := open()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var since int64
+	err = db.QueryRow(`SELECT since FROM digests WHERE model = ? AND notifier = ?`, model, notifier).Scan(&since)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(0, since), nil
+}
+
+// SetDigestSince sets the start time of the pending digest of the notifier of model
+func SetDigestSince(model, notifier string, since time.Time) error {
+	db, err := open()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO digests (model, notifier, since) VALUES (?, ?, ?)
+		ON CONFLICT (model, notifier) DO UPDATE SET since = excluded.since`,
+		model, notifier, since.UnixNano(),
+	)
+	return err
+}
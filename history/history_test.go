@@ -0,0 +1,201 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/longbridgeapp/assert"
+)
+
+func TestTrigger(t *testing.T) {
+	assert.Equal(t, TriggerCLI, TriggerFrom(context.Background()))
+	assert.Equal(t, TriggerAPI, TriggerFrom(WithTrigger(context.Background(), TriggerAPI)))
+}
+
+func TestSaveAndList(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	defer Close()
+
+	Keep = 3
+	defer func() {
+		Keep = 100
+	}()
+
+	startedAt := time.Now()
+	assert.NoError(t, os.MkdirAll(filepath.Dir(LogPath("run-0")), 0750))
+	assert.NoError(t, os.WriteFile(LogPath("run-0"), []byte("log"), 0640))
+	for i := 0; i < 5; i++ {
+		record := Record{
+			ID:        fmt.Sprintf("run-%d", i),
+			Model:     "app",
+			Trigger:   TriggerSchedule,
+			Status:    StatusRunning,
+			StartedAt: startedAt.Add(time.Duration(i) * time.Minute),
+		}
+		assert.NoError(t, Save(record))
+
+		finishedAt := record.StartedAt.Add(time.Second)
+		record.Status = StatusSuccess
+		record.FinishedAt = &finishedAt
+		record.Stages = []Stage{{Name: "database", Duration: 1}}
+		record.Storages = []string{"local"}
+		assert.NoError(t, Save(record))
+	}
+	assert.NoError(t, Save(Record{ID: "other", Model: "other", Status: StatusFailure, StartedAt: startedAt, Error: "dump failed"}))
+
+	records, err := List("app", "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(records))
+	assert.Equal(t, "run-4", records[0].ID)
+	assert.Equal(t, "run-2", records[2].ID)
+	assert.Equal(t, StatusSuccess, records[0].Status)
+	assert.Equal(t, []string{"local"}, records[0].Storages)
+
+	records, err = List("", "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(records))
+
+	record, err := Get("other")
+	assert.NoError(t, err)
+	assert.Equal(t, "dump failed", record.Error)
+
+	_, err = Get("run-0")
+	assert.Equal(t, ErrNotFound, err)
+	_, err = os.Stat(LogPath("run-0"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	defer Close()
+
+	count, err := ConsecutiveFailures("app")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	startedAt := time.Now()
+	statuses := []Status{StatusFailure, StatusSuccess, StatusFailure, StatusSkipped, StatusCanceled, StatusFailure, StatusRunning}
+	for i, status := range statuses {
+		record := Record{ID: fmt.Sprintf("run-%d", i), Model: "app", Status: status, StartedAt: startedAt.Add(time.Duration(i) * time.Minute)}
+		assert.NoError(t, Save(record))
+	}
+
+	count, err = ConsecutiveFailures("app")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	lastSuccess, err := LastSuccess("app")
+	assert.NoError(t, err)
+	assert.True(t, lastSuccess.Equal(startedAt.Add(time.Minute)))
+	lastSuccess, err = LastSuccess("other")
+	assert.NoError(t, err)
+	assert.True(t, lastSuccess.IsZero())
+
+	records, err := ListSince("app", startedAt.Add(5*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, "run-5", records[0].ID)
+}
+
+func TestKind(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	defer Close()
+
+	// The history created by the old versions has no kind column
+	conn, err := sql.Open("sqlite", filepath.Join(config.GoBackupDir, "history.db"))
+	assert.NoError(t, err)
+	_, err = conn.Exec(`CREATE TABLE runs (id TEXT PRIMARY KEY, model TEXT NOT NULL, status TEXT NOT NULL, started_at INTEGER NOT NULL, data TEXT NOT NULL)`)
+	assert.NoError(t, err)
+	startedAt := time.Now()
+	_, err = conn.Exec(`INSERT INTO runs VALUES ('run-0', 'app', 'success', ?, '{"id":"run-0","model":"app","status":"success"}')`, startedAt.UnixNano())
+	assert.NoError(t, err)
+	assert.NoError(t, conn.Close())
+
+	assert.NoError(t, Save(Record{ID: "run-1", Model: "app", Status: StatusFailure, StartedAt: startedAt.Add(time.Minute)}))
+	assert.NoError(t, Save(Record{ID: "verify-1", Model: "app", Kind: KindVerify, Status: StatusSuccess, StartedAt: startedAt.Add(2 * time.Minute)}))
+	assert.NoError(t, Save(Record{ID: "restore-1", Model: "app", Kind: KindRestore, Status: StatusFailure, StartedAt: startedAt.Add(3 * time.Minute)}))
+
+	records, err := List("app", "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(records))
+	assert.Equal(t, KindBackup, records[3].Kind)
+
+	records, err = List("app", KindBackup, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, "run-1", records[0].ID)
+
+	records, err = List("", KindVerify, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, "verify-1", records[0].ID)
+
+	// The verify and restore are not counted as backups
+	count, err := ConsecutiveFailures("app")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	records, err = ListSince("app", startedAt)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(records))
+}
+
+func TestDigestSince(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	defer Close()
+
+	since, err := DigestSince("app", "slack")
+	assert.NoError(t, err)
+	assert.True(t, since.IsZero())
+
+	now := time.Now()
+	assert.NoError(t, SetDigestSince("app", "slack", now))
+	assert.NoError(t, SetDigestSince("app", "slack", now.Add(time.Hour)))
+	since, err = DigestSince("app", "slack")
+	assert.NoError(t, err)
+	assert.True(t, since.Equal(now.Add(time.Hour)))
+
+	since, err = DigestSince("app", "mail")
+	assert.NoError(t, err)
+	assert.True(t, since.IsZero())
+}
+
+func TestInterrupt(t *testing.T) {
+	config.GoBackupDir = filepath.Join(t.TempDir(), "gobackup?#1%")
+	defer Close()
+
+	startedAt := time.Now()
+	assert.NoError(t, Save(Record{ID: "run-0", Model: "app", Status: StatusSuccess, StartedAt: startedAt}))
+	assert.NoError(t, Save(Record{ID: "run-1", Model: "app", Status: StatusRunning, StartedAt: startedAt.Add(time.Minute)}))
+	assert.NoError(t, Save(Record{ID: "run-2", Model: "app", Status: StatusQueued, StartedAt: startedAt.Add(2 * time.Minute)}))
+	_, err := os.Stat(filepath.Join(config.GoBackupDir, "history.db"))
+	assert.NoError(t, err)
+
+	// Reopened by the next process
+	assert.NoError(t, Close())
+
+	record, err := Get("run-0")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusSuccess, record.Status)
+	assert.Equal(t, "", record.Error)
+
+	record, err = Get("run-1")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusFailure, record.Status)
+	assert.Equal(t, "interrupted", record.Error)
+	assert.NotNil(t, record.FinishedAt)
+
+	record, err = Get("run-2")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusCanceled, record.Status)
+	assert.Equal(t, "interrupted", record.Error)
+
+	count, err := ConsecutiveFailures("app")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,297 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/metrics"
+)
+
+// Config of leader election by a Kubernetes Lease, so that only one of the replicas runs the schedules
+type Config struct {
+	// Lease name, default: gobackup
+	Lease string
+	// Namespace of the Lease, default: the namespace of pod
+	Namespace string
+	// Identity of this instance, default: the hostname, which is the pod name
+	Identity string
+	// LeaseDuration is the time the other instances wait before taking over the lease, default: 15s
+	LeaseDuration time.Duration
+	// RenewDeadline is the time the leader keeps retrying to renew before giving up the leadership, default: 10s
+	RenewDeadline time.Duration
+	// RetryPeriod is the interval to acquire or renew the lease, default: 2s
+	RetryPeriod time.Duration
+}
+
+var (
+	lock    sync.Mutex
+	current *elector
+)
+
+// elector acquires and renews the lease in background
+type elector struct {
+	config Config
+	client *client
+
+	// lock of leader and renewedAt
+	lock   sync.Mutex
+	leader bool
+	// renewedAt is the last time the lease was renewed by this instance
+	renewedAt time.Time
+
+	// observed is the spec of lease last read, observedAt is when it was changed,
+	// the lease is expired when it's not changed in LeaseDuration by the local clock.
+	observed   leaseSpec
+	observedAt time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Setup starts the leader election in background, the previous one is stopped and its lease is released.
+// It does nothing when the config is not changed, and stops leader election when config is nil.
+func Setup(config *Config) error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if config == nil {
+		if current != nil {
+			current.close()
+			current = nil
+			metrics.Leader.Set(0)
+		}
+		return nil
+	}
+
+	next := withDefaults(*config)
+	if next.LeaseDuration <= next.RenewDeadline || next.RenewDeadline <= next.RetryPeriod {
+		return fmt.Errorf("leader election: lease_duration must be greater than renew_deadline, and renew_deadline must be greater than retry_period")
+	}
+	if current != nil && reflect.DeepEqual(current.config, next) {
+		return nil
+	}
+
+	c, err := newClient(next.Namespace, next.Lease)
+	if err != nil {
+		return fmt.Errorf("leader election: %v", err)
+	}
+
+	if current != nil {
+		current.close()
+	}
+	current = newElector(next, c)
+	current.start()
+
+	logger.Tag("Leader").Infof("Leader election by lease %s/%s as %s", next.Namespace, next.Lease, next.Identity)
+	return nil
+}
+
+// Shutdown stops leader election and releases the lease, so that another instance can take over at once
+func Shutdown() {
+	_ = Setup(nil)
+}
+
+// IsLeader returns true when this instance is the leader, or leader election is disabled
+func IsLeader() bool {
+	lock.Lock()
+	e := current
+	lock.Unlock()
+
+	if e == nil {
+		return true
+	}
+	return e.isLeader()
+}
+
+func withDefaults(config Config) Config {
+	if len(config.Lease) == 0 {
+		config.Lease = "gobackup"
+	}
+	if len(config.Namespace) == 0 {
+		config.Namespace = defaultNamespace()
+	}
+	if len(config.Identity) == 0 {
+		config.Identity, _ = os.Hostname()
+	}
+	if config.LeaseDuration == 0 {
+		config.LeaseDuration = 15 * time.Second
+	}
+	if config.RenewDeadline == 0 {
+		config.RenewDeadline = 10 * time.Second
+	}
+	if config.RetryPeriod == 0 {
+		config.RetryPeriod = 2 * time.Second
+	}
+	return config
+}
+
+func newElector(config Config, c *client) *elector {
+	return &elector{
+		config: config,
+		client: c,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+func (e *elector) start() {
+	go func() {
+		defer close(e.done)
+
+		for {
+			e.tryAcquireOrRenew(time.Now())
+
+			select {
+			case <-e.stop:
+				e.release()
+				return
+			case <-time.After(e.config.RetryPeriod):
+			}
+		}
+	}()
+}
+
+// close stops the election and waits for the lease released
+func (e *elector) close() {
+	close(e.stop)
+	<-e.done
+}
+
+func (e *elector) isLeader() bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return e.leader
+}
+
+// tryAcquireOrRenew the lease, and updates the leadership by the result
+func (e *elector) tryAcquireOrRenew(now time.Time) {
+	logger := logger.Tag("Leader")
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.RenewDeadline)
+	defer cancel()
+
+	ok, err := e.acquire(ctx, now)
+	if err != nil {
+		logger.Errorf("Failed to acquire lease %s/%s: %v", e.config.Namespace, e.config.Lease, err)
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	switch {
+	case ok:
+		if !e.leader {
+			logger.Infof("Became the leader of lease %s/%s", e.config.Namespace, e.config.Lease)
+		}
+		e.leader = true
+		e.renewedAt = now
+	case !e.leader:
+		return
+	case err == nil || now.Sub(e.renewedAt) > e.config.RenewDeadline:
+		// The lease is taken by another instance, or it can't be renewed in time
+		logger.Warnf("Lost the leadership of lease %s/%s", e.config.Namespace, e.config.Lease)
+		e.leader = false
+	}
+
+	if e.leader {
+		metrics.Leader.Set(1)
+	} else {
+		metrics.Leader.Set(0)
+	}
+}
+
+// acquire or renew the lease, returns false when it's held by another instance
+func (e *elector) acquire(ctx context.Context, now time.Time) (bool, error) {
+	identity := e.config.Identity
+	spec := leaseSpec{
+		HolderIdentity:       identity,
+		LeaseDurationSeconds: int(e.config.LeaseDuration.Seconds()),
+		AcquireTime:          now.UTC().Format(microTime),
+		RenewTime:            now.UTC().Format(microTime),
+	}
+
+	l, err := e.client.get(ctx)
+	if err == errNotFound {
+		if err := e.client.create(ctx, spec); err == errConflict {
+			// Created by another instance
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		e.observe(spec, now)
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if l.Spec != e.observed {
+		e.observe(l.Spec, now)
+	}
+	holder := l.Spec.HolderIdentity
+	if len(holder) > 0 && holder != identity {
+		duration := time.Duration(l.Spec.LeaseDurationSeconds) * time.Second
+		if now.Before(e.observedAt.Add(duration)) {
+			return false, nil
+		}
+	}
+
+	if holder == identity {
+		spec.AcquireTime = l.Spec.AcquireTime
+		spec.LeaseTransitions = l.Spec.LeaseTransitions
+	} else {
+		spec.LeaseTransitions = l.Spec.LeaseTransitions + 1
+	}
+	l.Spec = spec
+	if err := e.client.update(ctx, l); err == errConflict {
+		// Updated by another instance after get
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	e.observe(spec, now)
+	return true, nil
+}
+
+func (e *elector) observe(spec leaseSpec, now time.Time) {
+	e.observed = spec
+	e.observedAt = now
+}
+
+// release the lease if this instance is the leader
+func (e *elector) release() {
+	e.lock.Lock()
+	leader := e.leader
+	e.leader = false
+	e.lock.Unlock()
+
+	if !leader {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.RenewDeadline)
+	defer cancel()
+
+	l, err := e.client.get(ctx)
+	if err == nil && l.Spec.HolderIdentity == e.config.Identity {
+		now := time.Now().UTC().Format(microTime)
+		l.Spec = leaseSpec{
+			LeaseDurationSeconds: 1,
+			AcquireTime:          now,
+			RenewTime:            now,
+			LeaseTransitions:     l.Spec.LeaseTransitions,
+		}
+		err = e.client.update(ctx, l)
+	}
+	if err != nil {
+		logger.Tag("Leader").Errorf("Failed to release lease %s/%s: %v", e.config.Namespace, e.config.Lease, err)
+		return
+	}
+	logger.Tag("Leader").Infof("Released lease %s/%s", e.config.Namespace, e.config.Lease)
+}
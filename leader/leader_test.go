@@ -0,0 +1,187 @@
+package leader
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/longbridgeapp/assert"
+)
+
+// fakeAPIServer serves a Lease like Kubernetes API, with optimistic concurrency by resourceVersion
+type fakeAPIServer struct {
+	lock    sync.Mutex
+	lease   *lease
+	version int
+}
+
+func (s *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if s.lease == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(s.lease)
+	case http.MethodPost, http.MethodPut:
+		l := &lease{}
+		if err := json.NewDecoder(r.Body).Decode(l); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodPost && s.lease != nil {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		if r.Method == http.MethodPut && l.Metadata["resourceVersion"] != s.lease.Metadata["resourceVersion"] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		s.version++
+		l.Metadata["resourceVersion"] = strconv.Itoa(s.version)
+		s.lease = l
+		_ = json.NewEncoder(w).Encode(s.lease)
+	}
+}
+
+func (s *fakeAPIServer) holder() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.lease.Spec.HolderIdentity
+}
+
+// inCluster runs a fake API server and sets up the service account like in a pod
+func inCluster(t *testing.T) *fakeAPIServer {
+	api := &fakeAPIServer{}
+	server := httptest.NewTLSServer(api)
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), ca, 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("test-token\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "namespace"), []byte("backup"), 0644))
+
+	dir, serviceAccountDir = serviceAccountDir, dir
+	t.Cleanup(func() {
+		serviceAccountDir = dir
+	})
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	t.Setenv("KUBERNETES_SERVICE_HOST", host)
+	t.Setenv("KUBERNETES_SERVICE_PORT", port)
+
+	return api
+}
+
+func newTestElector(t *testing.T, identity string) *elector {
+	config := withDefaults(Config{Identity: identity})
+	c, err := newClient(config.Namespace, config.Lease)
+	assert.NoError(t, err)
+	return newElector(config, c)
+}
+
+func TestElector(t *testing.T) {
+	api := inCluster(t)
+	a := newTestElector(t, "gobackup-a")
+	b := newTestElector(t, "gobackup-b")
+	assert.Equal(t, "backup", a.config.Namespace)
+	assert.Equal(t, "gobackup", a.config.Lease)
+
+	now := time.Now()
+	a.tryAcquireOrRenew(now)
+	assert.True(t, a.isLeader())
+	assert.Equal(t, "gobackup-a", api.holder())
+	assert.Equal(t, 15, api.lease.Spec.LeaseDurationSeconds)
+
+	b.tryAcquireOrRenew(now)
+	assert.False(t, b.isLeader())
+
+	// Renew
+	now = now.Add(2 * time.Second)
+	a.tryAcquireOrRenew(now)
+	assert.True(t, a.isLeader())
+	assert.Equal(t, now.UTC().Format(microTime), api.lease.Spec.RenewTime)
+
+	// Not expired since b observed the renew
+	b.tryAcquireOrRenew(now.Add(10 * time.Second))
+	assert.False(t, b.isLeader())
+
+	// a is gone, b takes over after the lease duration
+	now = now.Add(30 * time.Second)
+	b.tryAcquireOrRenew(now)
+	assert.True(t, b.isLeader())
+	assert.Equal(t, "gobackup-b", api.holder())
+	assert.Equal(t, 1, api.lease.Spec.LeaseTransitions)
+
+	// a finds it lost the leadership
+	a.tryAcquireOrRenew(now)
+	assert.False(t, a.isLeader())
+
+	// b releases the lease, a takes over at once
+	b.release()
+	assert.False(t, b.isLeader())
+	assert.Equal(t, "", api.holder())
+	a.tryAcquireOrRenew(now)
+	assert.True(t, a.isLeader())
+	assert.Equal(t, 2, api.lease.Spec.LeaseTransitions)
+}
+
+func TestElector_renewDeadline(t *testing.T) {
+	inCluster(t)
+	a := newTestElector(t, "gobackup-a")
+
+	now := time.Now()
+	a.tryAcquireOrRenew(now)
+	assert.True(t, a.isLeader())
+
+	// Keep the leadership until renew deadline when API server is unavailable
+	a.client.server = "https://127.0.0.1:1"
+	a.tryAcquireOrRenew(now.Add(5 * time.Second))
+	assert.True(t, a.isLeader())
+	a.tryAcquireOrRenew(now.Add(11 * time.Second))
+	assert.False(t, a.isLeader())
+}
+
+func TestSetup(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	err := Setup(&Config{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not running in Kubernetes")
+	assert.True(t, IsLeader())
+
+	err = Setup(&Config{LeaseDuration: 5 * time.Second})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "lease_duration must be greater than renew_deadline")
+
+	api := inCluster(t)
+	assert.NoError(t, Setup(&Config{Identity: "gobackup-a", RetryPeriod: 10 * time.Millisecond}))
+	defer Shutdown()
+	for i := 0; i < 100 && !IsLeader(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, IsLeader())
+	assert.Equal(t, "gobackup-a", api.holder())
+
+	// Release the lease on shutdown
+	Shutdown()
+	assert.Equal(t, "", api.holder())
+	assert.True(t, IsLeader())
+}
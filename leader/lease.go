@@ -0,0 +1,175 @@
+package leader
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// microTime is the format of MicroTime in Kubernetes API
+const microTime = "2006-01-02T15:04:05.000000Z07:00"
+
+// serviceAccountDir is the mounted service account of pod, it has the token, ca.crt and namespace
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+var (
+	errNotFound = errors.New("not found")
+	errConflict = errors.New("conflict")
+)
+
+// lease is the coordination.k8s.io/v1 Lease, metadata is kept as is in update
+type lease struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Metadata   map[string]any `json:"metadata"`
+	Spec       leaseSpec      `json:"spec"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int    `json:"leaseTransitions,omitempty"`
+}
+
+// client of the Lease API in cluster, by the service account of pod
+type client struct {
+	server     string
+	namespace  string
+	name       string
+	httpClient *http.Client
+}
+
+func newClient(namespace, name string) (*client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if len(host) == 0 || len(port) == 0 {
+		return nil, fmt.Errorf("not running in Kubernetes, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+	}
+
+	ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate found in %s", filepath.Join(serviceAccountDir, "ca.crt"))
+	}
+
+	return &client{
+		server:    "https://" + net.JoinHostPort(host, port),
+		namespace: namespace,
+		name:      name,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: pool},
+			},
+		},
+	}, nil
+}
+
+// defaultNamespace is the namespace of pod
+func defaultNamespace() string {
+	if namespace := os.Getenv("POD_NAMESPACE"); len(namespace) > 0 {
+		return namespace
+	}
+	if data, err := os.ReadFile(filepath.Join(serviceAccountDir, "namespace")); err == nil {
+		if namespace := strings.TrimSpace(string(data)); len(namespace) > 0 {
+			return namespace
+		}
+	}
+	return "default"
+}
+
+func (c *client) url(withName bool) string {
+	url := fmt.Sprintf("%s/apis/coordination.k8s.io/v1/namespaces/%s/leases", c.server, c.namespace)
+	if withName {
+		url += "/" + c.name
+	}
+	return url
+}
+
+func (c *client) get(ctx context.Context) (*lease, error) {
+	l := &lease{}
+	if err := c.do(ctx, http.MethodGet, c.url(true), nil, l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (c *client) create(ctx context.Context, spec leaseSpec) error {
+	l := &lease{
+		APIVersion: "coordination.k8s.io/v1",
+		Kind:       "Lease",
+		Metadata:   map[string]any{"name": c.name, "namespace": c.namespace},
+		Spec:       spec,
+	}
+	return c.do(ctx, http.MethodPost, c.url(false), l, nil)
+}
+
+// update the lease, it fails with errConflict when the lease was changed after l was read
+func (c *client) update(ctx context.Context, l *lease) error {
+	return c.do(ctx, http.MethodPut, c.url(true), l, nil)
+}
+
+func (c *client) do(ctx context.Context, method, url string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	// The token is rotated by kubelet, read it for each request
+	token, err := os.ReadFile(filepath.Join(serviceAccountDir, "token"))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return errNotFound
+	case res.StatusCode == http.StatusConflict:
+		return errConflict
+	case res.StatusCode >= 300:
+		status := struct {
+			Message string `json:"message"`
+		}{}
+		_ = json.NewDecoder(res.Body).Decode(&status)
+		return fmt.Errorf("%s lease %s/%s: HTTP %d %s", method, c.namespace, c.name, res.StatusCode, status.Message)
+	}
+
+	if out != nil {
+		return json.NewDecoder(res.Body).Decode(out)
+	}
+	return nil
+}
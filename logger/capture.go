@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	capturesLock sync.Mutex
+	captures     = map[*Capture]bool{}
+
+	colorRegexp = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+)
+
+// Capture keeps the last lines of log of a run between StartCapture and Stop
+type Capture struct {
+	lock  sync.Mutex
+	runID string
+	limit int
+	lines []string
+}
+
+// StartCapture starts to keep the last limit lines of log, which has the run ID in the fields
+func StartCapture(runID string, limit int) *Capture {
+	c := &Capture{runID: runID, limit: limit}
+
+	capturesLock.Lock()
+	captures[c] = true
+	capturesLock.Unlock()
+
+	return c
+}
+
+// Stop capturing
+func (c *Capture) Stop() {
+	capturesLock.Lock()
+	delete(captures, c)
+	capturesLock.Unlock()
+}
+
+// String returns the captured lines without colors
+func (c *Capture) String() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return strings.Join(c.lines, "\n")
+}
+
+func (c *Capture) add(line string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lines = append(c.lines, colorRegexp.ReplaceAllString(line, ""))
+	if len(c.lines) > c.limit {
+		c.lines = c.lines[len(c.lines)-c.limit:]
+	}
+}
+
+// capture the log of the run to its captures
+func capture(runID, text string) {
+	if runID == "" {
+		return
+	}
+
+	capturesLock.Lock()
+	defer capturesLock.Unlock()
+
+	for c := range captures {
+		if c.runID != runID {
+			continue
+		}
+		for _, line := range strings.Split(text, "\n") {
+			c.add(line)
+		}
+	}
+}
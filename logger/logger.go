@@ -1,38 +1,73 @@
 package logger
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fatih/color"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+// Logger with a tag and the fields of structured log
 type Logger struct {
-	logFlag int
-	myLog   *log.Logger
+	tag    string
+	fields Fields
 }
 
+// Fields of the structured log, they are empty when logging outside of a run
+type Fields struct {
+	Model string
+	Stage string
+	RunID string
+}
+
+// Rotation of the log file
+type Rotation struct {
+	// MaxSize in megabytes of the log file before it gets rotated
+	MaxSize int
+	// MaxBackups is the number of rotated log files to keep, 0 to keep all
+	MaxBackups int
+	// MaxAge in days to keep the rotated log files, 0 to keep all
+	MaxAge int
+	// Compress the rotated log files with gzip
+	Compress bool
+}
+
+// entry is a line of log in JSON format
+type entry struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Tag     string `json:"tag,omitempty"`
+	Model   string `json:"model,omitempty"`
+	Stage   string `json:"stage,omitempty"`
+	RunID   string `json:"run_id,omitempty"`
+	Message string `json:"message"`
+}
+
+type fieldsKey struct{}
+
 var (
-	_logFlag     = log.Ldate | log.Ltime
-	TimeFormat   = "2006/01/02 15:04:05"
-	_myLog       = log.New(&writer{os.Stdout, TimeFormat}, "", 0)
+	TimeFormat = "2006/01/02 15:04:05"
+
+	outputLock   sync.Mutex
+	output       io.Writer = os.Stdout
+	logFile      *lumberjack.Logger
+	logPath      string
+	rotation     = Rotation{MaxSize: 100, MaxBackups: 10}
+	jsonFormat   atomic.Bool
 	sharedLogger Logger
 	isTest       = os.Getenv("GO_ENV") == "test"
 	isDebug      = os.Getenv("DEBUG") == "true"
 )
 
-type writer struct {
-	io.Writer
-	timeFormat string
-}
-
-func (w writer) Write(b []byte) (n int, err error) {
-	return w.Writer.Write(append([]byte(time.Now().Format(w.timeFormat)+" "), b...))
-}
-
 func init() {
 	if isTest {
 		if err := os.MkdirAll("../log", 0777); err != nil {
@@ -40,60 +75,171 @@ func init() {
 		}
 
 		logfile, _ := os.OpenFile("../log/test.log", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-		_myLog = log.New(logfile, "", _logFlag)
+		output = logfile
+	}
+}
+
+// SetLogger writes log to stdout and the log file, the log file is rotated by the Rotation
+func SetLogger(path string) {
+	outputLock.Lock()
+	defer outputLock.Unlock()
+
+	logPath = path
+	openLogFile()
+}
+
+// SetRotation changes the rotation of the log file
+func SetRotation(r Rotation) {
+	outputLock.Lock()
+	defer outputLock.Unlock()
+
+	if r == rotation {
+		return
+	}
+	rotation = r
+	if logPath != "" {
+		openLogFile()
+	}
+}
+
+// openLogFile (re)opens the log file with the rotation, must be called with outputLock held
+func openLogFile() {
+	if logFile != nil {
+		logFile.Close()
+	}
+
+	logFile = &lumberjack.Logger{
+		Filename:   logPath,
+		MaxSize:    rotation.MaxSize,
+		MaxBackups: rotation.MaxBackups,
+		MaxAge:     rotation.MaxAge,
+		Compress:   rotation.Compress,
+		LocalTime:  true,
 	}
-	sharedLogger = newLogger()
+	output = io.MultiWriter(os.Stdout, logFile)
+}
+
+// SetFormat sets the log format, text (default) or json
+func SetFormat(format string) error {
+	switch format {
+	case "", "text":
+		jsonFormat.Store(false)
+	case "json":
+		jsonFormat.Store(true)
+	default:
+		return fmt.Errorf("unsupported log format: %s", format)
+	}
+
+	return nil
 }
 
-func SetLogger(logPath string) {
-	writers := make([]io.Writer, 0)
-	writers = append(writers, os.Stdout)
-	logfile, err := os.OpenFile(logPath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-	if err == nil {
-		writers = append(writers, logfile)
+// IsJSON returns true when the log format is json
+func IsJSON() bool {
+	return jsonFormat.Load()
+}
+
+// NewContext returns a copy of ctx with the fields, the non-empty fields override the fields in ctx.
+// The loggers WithContext(ctx) will carry them.
+func NewContext(ctx context.Context, fields Fields) context.Context {
+	current := FieldsFrom(ctx)
+	if fields.Model != "" {
+		current.Model = fields.Model
+	}
+	if fields.Stage != "" {
+		current.Stage = fields.Stage
+	}
+	if fields.RunID != "" {
+		current.RunID = fields.RunID
 	}
-	multi := io.MultiWriter(writers...)
-	_myLog = log.New(&writer{multi, TimeFormat}, "", 0)
-	sharedLogger = newLogger()
+
+	return context.WithValue(ctx, fieldsKey{}, current)
 }
 
-func newLogger() Logger {
-	return Logger{_logFlag, _myLog}
+// FieldsFrom returns the fields in ctx
+func FieldsFrom(ctx context.Context) Fields {
+	if ctx == nil {
+		return Fields{}
+	}
+
+	fields, _ := ctx.Value(fieldsKey{}).(Fields)
+	return fields
 }
 
 func Tag(tag string) Logger {
-	return sharedLogger.Tag(color.CyanString(fmt.Sprintf("[%s] ", tag)))
+	return sharedLogger.Tag(tag)
+}
+
+// WithContext returns a logger with the fields in ctx
+func WithContext(ctx context.Context) Logger {
+	return sharedLogger.WithContext(ctx)
 }
 
 func (logger Logger) Prefix() string {
-	return logger.myLog.Prefix()
+	if logger.tag == "" {
+		return ""
+	}
+	return color.CyanString(fmt.Sprintf("[%s] ", logger.tag))
 }
 
 func (logger Logger) Writer() io.Writer {
-	return logger.myLog.Writer()
+	outputLock.Lock()
+	defer outputLock.Unlock()
+
+	return output
 }
 
 func (logger Logger) Tag(tag string) Logger {
-	logger.myLog.SetPrefix(tag)
+	logger.tag = tag
 	return logger
 }
 
-func (logger Logger) log(v ...interface{}) {
-	logger.myLog.Print(v...)
+// WithContext returns a copy of logger with the fields in ctx
+func (logger Logger) WithContext(ctx context.Context) Logger {
+	logger.fields = FieldsFrom(ctx)
+	return logger
 }
 
-func (logger Logger) logln(v ...interface{}) {
-	logger.myLog.Println(v...)
+// write a line of log in the format
+func (logger Logger) write(level, message string) {
+	now := time.Now()
+	message = strings.TrimSuffix(message, "\n")
+	text := now.Format(TimeFormat) + " " + logger.Prefix() + message
+
+	var line []byte
+	if IsJSON() {
+		line, _ = json.Marshal(entry{
+			Time:    now.Format(time.RFC3339Nano),
+			Level:   level,
+			Tag:     logger.tag,
+			Model:   logger.fields.Model,
+			Stage:   logger.fields.Stage,
+			RunID:   logger.fields.RunID,
+			Message: colorRegexp.ReplaceAllString(message, ""),
+		})
+	} else {
+		line = []byte(text)
+	}
+
+	capture(logger.fields.RunID, text)
+	if IsJSON() {
+		writeRunLog(logger.fields.RunID, line)
+	} else {
+		writeRunLog(logger.fields.RunID, []byte(colorRegexp.ReplaceAllString(text, "")))
+	}
+
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	_, _ = output.Write(append(line, '\n'))
 }
 
 // Print log
 func (logger Logger) Print(v ...interface{}) {
-	logger.log(v...)
+	logger.write("info", fmt.Sprint(v...))
 }
 
 // Println log
 func (logger Logger) Println(v ...interface{}) {
-	logger.logln(v...)
+	logger.write("info", fmt.Sprintln(v...))
 }
 
 // Printf log
@@ -104,7 +250,7 @@ func (logger Logger) Printf(format string, v ...interface{}) {
 // Debug log
 func (logger Logger) Debug(v ...interface{}) {
 	if isDebug || isTest {
-		logger.logln("[debug] ", fmt.Sprint(v...))
+		logger.write("debug", "[debug] "+fmt.Sprint(v...))
 	}
 }
 
@@ -115,7 +261,7 @@ func (logger Logger) Debugf(format string, v ...interface{}) {
 
 // Info log
 func (logger Logger) Info(v ...interface{}) {
-	logger.logln(v...)
+	logger.write("info", fmt.Sprintln(v...))
 }
 
 // Infof log
@@ -125,8 +271,7 @@ func (logger Logger) Infof(format string, v ...interface{}) {
 
 // Warn log
 func (logger Logger) Warn(v ...interface{}) {
-	c := color.YellowString(fmt.Sprint(v...))
-	logger.logln(c)
+	logger.write("warn", color.YellowString(fmt.Sprint(v...)))
 }
 
 // Warnf log
@@ -136,8 +281,7 @@ func (logger Logger) Warnf(format string, v ...interface{}) {
 
 // Error log
 func (logger Logger) Error(v ...interface{}) {
-	c := color.RedString(fmt.Sprint(v...))
-	logger.logln(c)
+	logger.write("error", color.RedString(fmt.Sprint(v...)))
 }
 
 // Errorf log
@@ -147,8 +291,7 @@ func (logger Logger) Errorf(format string, v ...interface{}) {
 
 // Fatal log
 func (logger Logger) Fatal(v ...interface{}) {
-	c := color.MagentaString(fmt.Sprint(v...))
-	logger.logln(c)
+	logger.write("fatal", color.MagentaString(fmt.Sprint(v...)))
 	os.Exit(1)
 }
 
@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+)
+
+func captureOutput(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	original := output
+	output = buf
+	t.Cleanup(func() {
+		output = original
+		jsonFormat.Store(false)
+	})
+
+	return buf
+}
+
+func TestSetFormat(t *testing.T) {
+	assert.NoError(t, SetFormat("json"))
+	assert.True(t, IsJSON())
+	assert.NoError(t, SetFormat(""))
+	assert.False(t, IsJSON())
+	assert.EqualError(t, SetFormat("xml"), "unsupported log format: xml")
+}
+
+func TestLogger_json(t *testing.T) {
+	buf := captureOutput(t)
+	assert.NoError(t, SetFormat("json"))
+
+	ctx := NewContext(context.Background(), Fields{Model: "app", RunID: "run-1"})
+	ctx = NewContext(ctx, Fields{Stage: "database"})
+	Tag("MySQL").WithContext(ctx).Errorf("dump %s failed", "app")
+	Info("Config loaded")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+
+	line := map[string]any{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	assert.Equal(t, "error", line["level"])
+	assert.Equal(t, "MySQL", line["tag"])
+	assert.Equal(t, "app", line["model"])
+	assert.Equal(t, "database", line["stage"])
+	assert.Equal(t, "run-1", line["run_id"])
+	assert.Equal(t, "dump app failed", line["message"])
+	assert.NotNil(t, line["time"])
+
+	line = map[string]any{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &line))
+	assert.Equal(t, "info", line["level"])
+	assert.Equal(t, "Config loaded", line["message"])
+	assert.Nil(t, line["run_id"])
+}
+
+func TestLogger_text(t *testing.T) {
+	buf := captureOutput(t)
+
+	Tag("MySQL").Info("dump", "succeeded")
+	assert.Contains(t, buf.String(), "[MySQL] dump succeeded\n")
+}
+
+func TestCapture(t *testing.T) {
+	captureOutput(t)
+
+	c := StartCapture("run-1", 2)
+	ctx := NewContext(context.Background(), Fields{RunID: "run-1"})
+	other := NewContext(context.Background(), Fields{RunID: "run-2"})
+
+	WithContext(ctx).Info("line 1")
+	WithContext(other).Info("other run")
+	Tag("MySQL").WithContext(ctx).Info("line 2")
+	WithContext(ctx).Error("line 3")
+	c.Stop()
+	WithContext(ctx).Info("line 4")
+
+	lines := strings.Split(c.String(), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.True(t, strings.HasSuffix(lines[0], "[MySQL] line 2"))
+	assert.True(t, strings.HasSuffix(lines[1], " line 3"))
+}
+
+func TestRunLog(t *testing.T) {
+	captureOutput(t)
+
+	path := filepath.Join(t.TempDir(), "runs", "run-1.log")
+	assert.NoError(t, OpenRunLog("run-1", path))
+
+	ctx := NewContext(context.Background(), Fields{RunID: "run-1"})
+	Tag("MySQL").WithContext(ctx).Error("dump failed")
+	WithContext(NewContext(context.Background(), Fields{RunID: "run-2"})).Info("other run")
+	CloseRunLog("run-1")
+	WithContext(ctx).Info("after closed")
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(data), "[MySQL] dump failed\n"))
+	assert.Equal(t, 1, strings.Count(string(data), "\n"))
+}
+
+func TestSetLogger(t *testing.T) {
+	captureOutput(t)
+	defer func() {
+		logFile.Close()
+		logPath = ""
+		logFile = nil
+		rotation = Rotation{MaxSize: 100, MaxBackups: 10}
+	}()
+
+	path := filepath.Join(t.TempDir(), "gobackup.log")
+	SetLogger(path)
+	SetRotation(Rotation{MaxSize: 1, MaxBackups: 2, Compress: true})
+	assert.Equal(t, 1, logFile.MaxSize)
+	assert.Equal(t, 2, logFile.MaxBackups)
+	assert.True(t, logFile.Compress)
+
+	Info("to log file")
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "to log file")
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	runLogsLock sync.Mutex
+	// log files of the runs by run ID
+	runLogs = map[string]*os.File{}
+)
+
+// OpenRunLog starts to write the log of the run into a separate file, until CloseRunLog
+func OpenRunLog(runID, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+
+	runLogsLock.Lock()
+	defer runLogsLock.Unlock()
+
+	if previous, ok := runLogs[runID]; ok {
+		previous.Close()
+	}
+	runLogs[runID] = file
+	return nil
+}
+
+// CloseRunLog stops writing the log of the run
+func CloseRunLog(runID string) {
+	runLogsLock.Lock()
+	defer runLogsLock.Unlock()
+
+	if file, ok := runLogs[runID]; ok {
+		file.Close()
+		delete(runLogs, runID)
+	}
+}
+
+// writeRunLog writes the line to the log file of the run if it's opened
+func writeRunLog(runID string, line []byte) {
+	if runID == "" {
+		return
+	}
+
+	runLogsLock.Lock()
+	defer runLogsLock.Unlock()
+
+	if file, ok := runLogs[runID]; ok {
+		_, _ = file.Write(append(line, '\n'))
+	}
+}
@@ -1,19 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	ossignal "os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/sevlyar/go-daemon"
 	"github.com/spf13/viper"
 	"github.com/urfave/cli/v2"
 
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
+	"github.com/gobackup/gobackup/leader"
 	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/metrics"
 	"github.com/gobackup/gobackup/model"
 	"github.com/gobackup/gobackup/scheduler"
+	"github.com/gobackup/gobackup/tracing"
 	"github.com/gobackup/gobackup/web"
 )
 
@@ -21,6 +30,19 @@ const (
 	usage = "Backup your databases, files to FTP / SCP / S3 / GCS and other cloud storages."
 )
 
+// cleanupTimeout is the max time to wait for the canceled models to cleanup their temp files on shutdown
+const cleanupTimeout = 30 * time.Second
+
+// Exit codes of `gobackup perform --from-schedule`, 1 is the error before performing, e.g. invalid config
+const (
+	// exitFailed when some of the models failed
+	exitFailed = 2
+	// exitAllFailed when all of the performed models failed
+	exitAllFailed = 3
+	// exitCanceled when it's canceled by SIGTERM or SIGINT
+	exitCanceled = 4
+)
+
 var (
 	configFile string
 	version    = "master"
@@ -28,6 +50,10 @@ var (
   quit — graceful shutdown
   stop — fast shutdown
   reload — reloading the configuration file`)
+
+	shutdownOnce sync.Once
+	// stopping is canceled by the stop signal, to end waiting for the performing models
+	stopping, stopNow = context.WithCancel(context.Background())
 )
 
 func buildFlags(flags []cli.Flag) []cli.Flag {
@@ -39,13 +65,54 @@ func buildFlags(flags []cli.Flag) []cli.Flag {
 	})
 }
 
-func termHandler(sig os.Signal) error {
-	logger.Info("Received QUIT signal, exiting...")
-	scheduler.Stop()
-	os.Exit(0)
+// quitHandler shuts down gracefully, the performing models are canceled after `shutdown_timeout`
+func quitHandler(sig os.Signal) error {
+	logger.Info("Received QUIT signal, exiting after the performing models are done...")
+	go shutdown()
+	return nil
+}
+
+// stopHandler shuts down fast, the performing models are canceled at once, it also ends the graceful shutdown
+func stopHandler(sig os.Signal) error {
+	logger.Infof("Received signal: %s, canceling the performing models and exiting...", sig)
+	stopNow()
+	go shutdown()
 	return nil
 }
 
+// shutdown stops scheduling and waits for the performing models until they are done, `shutdown_timeout` or the stop signal,
+// then cancels the rest of them and waits for their temp files cleaned up, and exits.
+func shutdown() {
+	shutdownOnce.Do(func() {
+		model.Close()
+		// Stopping scheduler waits for the performing models
+		go scheduler.Stop()
+
+		ctx := stopping
+		if config.ShutdownTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, config.ShutdownTimeout)
+			defer cancel()
+		}
+		if err := model.Wait(ctx); err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				logger.Warnf("The performing models are not done in %s, canceling...", config.ShutdownTimeout)
+			}
+			cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+			defer cancel()
+			model.Shutdown(cleanupCtx)
+		}
+
+		leader.Shutdown()
+		tracing.Shutdown()
+		if err := history.Close(); err != nil {
+			logger.Error(err)
+		}
+		logger.Info("Bye.")
+		os.Exit(0)
+	})
+}
+
 func reloadHandler(sig os.Signal) error {
 	logger.Info("Reloading config...")
 	err := config.Init(configFile)
@@ -60,11 +127,13 @@ func main() {
 	app := cli.NewApp()
 
 	app.Version = version
+	tracing.Version = version
 	app.Name = "gobackup"
 	app.Usage = usage
 
-	daemon.AddCommand(daemon.StringFlag(signal, "quit"), syscall.SIGQUIT, termHandler)
-	daemon.AddCommand(daemon.StringFlag(signal, "stop"), syscall.SIGTERM, termHandler)
+	daemon.AddCommand(daemon.StringFlag(signal, "quit"), syscall.SIGQUIT, quitHandler)
+	daemon.AddCommand(daemon.StringFlag(signal, "stop"), syscall.SIGTERM, stopHandler)
+	daemon.AddCommand(nil, syscall.SIGINT, stopHandler)
 	daemon.AddCommand(daemon.StringFlag(signal, "reload"), syscall.SIGHUP, reloadHandler)
 
 	app.Commands = []*cli.Command{
@@ -76,6 +145,10 @@ func main() {
 					Aliases: []string{"m"},
 					Usage:   "Model name that you want perform",
 				},
+				&cli.BoolFlag{
+					Name:  "from-schedule",
+					Usage: "Perform the scheduled models once and exit with the result, e.g. in Kubernetes CronJob",
+				},
 			}),
 			Action: func(ctx *cli.Context) error {
 				var modelNames []string
@@ -83,8 +156,49 @@ func main() {
 				if err != nil {
 					return err
 				}
+				// Flush the traces before exit
+				defer tracing.Shutdown()
 				modelNames = append(ctx.StringSlice("model"), ctx.Args().Slice()...)
-				return perform(modelNames)
+				return perform(modelNames, ctx.Bool("from-schedule"))
+			},
+		},
+		{
+			Name:  "check",
+			Usage: "Check config, binaries and connectivity of models",
+			Flags: buildFlags([]cli.Flag{
+				&cli.StringSliceFlag{
+					Name:    "model",
+					Aliases: []string{"m"},
+					Usage:   "Model name that you want check",
+				},
+				&cli.BoolFlag{
+					Name:  "connect",
+					Usage: "Connect to databases and storages",
+				},
+				&cli.BoolFlag{
+					Name:  "notify",
+					Usage: "Send test notification by notifiers",
+				},
+			}),
+			Action: func(ctx *cli.Context) error {
+				err := initApplication()
+				if err != nil {
+					return err
+				}
+				modelNames := append(ctx.StringSlice("model"), ctx.Args().Slice()...)
+				return check(modelNames, ctx.Bool("connect"), ctx.Bool("notify"))
+			},
+		},
+		{
+			Name:  "schema",
+			Usage: "Print the JSON Schema of config",
+			Action: func(ctx *cli.Context) error {
+				schema, err := config.SchemaJSON()
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(schema))
+				return nil
 			},
 		},
 		{
@@ -145,6 +259,13 @@ func main() {
 					return fmt.Errorf("failed to start scheduler: %w", err)
 				}
 
+				// Both `gobackup run` and the daemon started by `gobackup start` run here
+				go func() {
+					if err := daemon.ServeSignals(); err != nil {
+						logger.Error(err)
+					}
+				}()
+
 				if !config.Web.Enabled {
 					select {}
 				}
@@ -163,7 +284,7 @@ func initApplication() error {
 	return config.Init(configFile)
 }
 
-func perform(modelNames []string) error {
+func getModels(modelNames []string) ([]*model.Model, error) {
 	var models []*model.Model
 	if len(modelNames) == 0 {
 		// perform all
@@ -171,21 +292,170 @@ func perform(modelNames []string) error {
 	} else {
 		for _, name := range modelNames {
 			if m := model.GetModelByName(name); m == nil {
-				return fmt.Errorf("model %s not found in %s", name, viper.ConfigFileUsed())
+				return nil, fmt.Errorf("model %s not found in %s", name, viper.ConfigFileUsed())
 			} else {
 				models = append(models, m)
 			}
 		}
 	}
 
+	return models, nil
+}
+
+func perform(modelNames []string, fromSchedule bool) error {
+	models, err := getModels(modelNames)
+	if err != nil {
+		return err
+	}
+
+	trigger := history.TriggerCLI
+	if fromSchedule {
+		trigger = history.TriggerSchedule
+	}
+	ctx, stop := cancelOnSignal(history.WithTrigger(context.Background(), trigger))
+	defer stop()
+
 	var last_error error
 	last_error = nil
+	statuses := map[history.Status]int{}
+	performedModels := []string{}
 	for _, m := range models {
-		if err := m.Perform(); err != nil {
-			logger.Tag(fmt.Sprintf("Model %s", m.Config.Name)).Error(err)
+		logger := logger.Tag(fmt.Sprintf("Model %s", m.Config.Name))
+
+		if fromSchedule {
+			if !m.Config.Schedule.Enabled {
+				continue
+			}
+			if scheduler.IsPaused(m.Config.Name) {
+				logger.Info("Paused, skip.")
+				statuses[history.StatusSkipped]++
+				continue
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		err := m.Perform(ctx)
+		status := performStatus(err)
+		statuses[status]++
+		if status == history.StatusFailure {
+			logger.Error(err)
 			last_error = err
+		} else if err != nil {
+			logger.Info(err)
+		}
+		pushMetrics(m.Config.Name)
+		performedModels = append(performedModels, m.Config.Name)
+	}
+	writeMetrics(performedModels)
+
+	if !fromSchedule {
+		return last_error
+	}
+
+	performed := statuses[history.StatusSuccess] + statuses[history.StatusFailure] + statuses[history.StatusCanceled]
+	summary := fmt.Sprintf("%d succeeded, %d failed, %d canceled, %d skipped",
+		statuses[history.StatusSuccess], statuses[history.StatusFailure], statuses[history.StatusCanceled], statuses[history.StatusSkipped])
+	switch {
+	case ctx.Err() != nil || statuses[history.StatusCanceled] > 0:
+		return cli.Exit("perform canceled: "+summary, exitCanceled)
+	case statuses[history.StatusFailure] > 0 && statuses[history.StatusFailure] == performed:
+		return cli.Exit("all models failed: "+summary, exitAllFailed)
+	case statuses[history.StatusFailure] > 0:
+		return cli.Exit("some models failed: "+summary, exitFailed)
+	}
+
+	logger.Info("Performed: " + summary)
+	return nil
+}
+
+// performStatus returns the status of run by the error of Model.Perform
+func performStatus(err error) history.Status {
+	switch {
+	case err == nil:
+		return history.StatusSuccess
+	case errors.Is(err, model.ErrOverlapSkipped):
+		return history.StatusSkipped
+	case errors.Is(err, model.ErrRunCanceled), errors.Is(err, model.ErrCanceled):
+		return history.StatusCanceled
+	default:
+		return history.StatusFailure
+	}
+}
+
+// cancelOnSignal returns a copy of ctx canceled by SIGINT or SIGTERM, so that the performing model
+// is stopped and cleaned up before exit, e.g. the pod of CronJob is deleted.
+func cancelOnSignal(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	signals := make(chan os.Signal, 1)
+	ossignal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			logger.Infof("Received %s, canceling...", sig)
+			cancel(fmt.Errorf("%w by %s", model.ErrRunCanceled, sig))
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		ossignal.Stop(signals)
+		cancel(nil)
+	}
+}
+
+// pushMetrics pushes the metrics of model to Pushgateway, the counters are lost when the process exits
+func pushMetrics(model string) {
+	if config.Metrics.PushGateway == nil {
+		return
+	}
+
+	if err := metrics.Push(*config.Metrics.PushGateway, model); err != nil {
+		logger.Tag("Metrics").Error(err)
+	}
+}
+
+// writeMetrics writes the metrics of the performed models to the textfiles for node_exporter
+func writeMetrics(models []string) {
+	if len(config.Metrics.Textfile) == 0 {
+		return
+	}
+
+	if err := metrics.WriteTextfiles(config.Metrics.Textfile, models); err != nil {
+		logger.Tag("Metrics").Errorf("Write metrics to %s: %v", config.Metrics.Textfile, err)
+	}
+}
+
+func check(modelNames []string, connect, notify bool) error {
+	if viper.GetBool("useTempWorkDir") {
+		defer os.RemoveAll(viper.GetString("workdir"))
+	}
+
+	models, err := getModels(modelNames)
+	if err != nil {
+		return err
+	}
+
+	problems := 0
+	for _, m := range models {
+		errs := m.Check(connect, notify)
+		if len(errs) == 0 {
+			fmt.Printf("%s: OK\n", m.Config.Name)
+			continue
+		}
+
+		fmt.Printf("%s: %d problem(s)\n", m.Config.Name, len(errs))
+		for _, err := range errs {
+			fmt.Printf("  - %v\n", err)
 		}
+		problems += len(errs)
 	}
 
-	return last_error
+	if problems > 0 {
+		return cli.Exit(fmt.Sprintf("check failed with %d problem(s) in %s", problems, viper.ConfigFileUsed()), 1)
+	}
+
+	return nil
 }
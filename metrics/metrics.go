@@ -16,8 +16,8 @@ var (
 		[]string{"model", "status"},
 	)
 
-	// DuractionSeconds is a histogram for backup duration
-	DuractionSeconds = promauto.NewHistogramVec(
+	// DurationSeconds is a histogram for backup duration
+	DurationSeconds = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "gobackup",
 			Name:      "duration_seconds",
@@ -27,6 +27,11 @@ var (
 		[]string{"model"},
 	)
 
+	// DuractionSeconds is the misspelled name of DurationSeconds.
+	//
+	// Deprecated: Use DurationSeconds instead.
+	DuractionSeconds = DurationSeconds
+
 	// FileSizes is a gauge for the last backup file size in bytes
 	FileSizes = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -46,4 +51,150 @@ var (
 		},
 		[]string{"model", "status"},
 	)
+
+	// NextExpectedTimestamp is a gauge for the deadline of next successful backup (Unix epoch),
+	// include the grace of schedule, the backup is missed after it.
+	NextExpectedTimestamp = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "gobackup",
+			Name:      "next_expected_timestamp",
+			Help:      "Deadline of the next successful backup by the schedule (Unix epoch)",
+		},
+		[]string{"model"},
+	)
+
+	// StageDurationSeconds is a histogram for the duration of each stage of backup:
+	// dump, archive, compress, encrypt, split, upload
+	StageDurationSeconds = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "gobackup",
+			Name:      "stage_duration_seconds",
+			Help:      "Duration of backup stage in seconds",
+			Buckets:   prometheus.ExponentialBuckets(0.5, 2, 16), // 0.5s to ~9h
+		},
+		[]string{"model", "stage"},
+	)
+
+	// DatabaseDumpBytes is a gauge for the size of the last dump of database in bytes
+	DatabaseDumpBytes = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "gobackup",
+			Name:      "database_dump_bytes",
+			Help:      "Size of the last dump of database in bytes",
+		},
+		[]string{"model", "database"},
+	)
+
+	// DatabaseDumpDurationSeconds is a histogram for the dump duration of database
+	DatabaseDumpDurationSeconds = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "gobackup",
+			Name:      "database_dump_duration_seconds",
+			Help:      "Duration of database dump in seconds",
+			Buckets:   prometheus.ExponentialBuckets(0.5, 2, 16),
+		},
+		[]string{"model", "database", "status"},
+	)
+
+	// StorageUploadBytes is a counter for the bytes uploaded to storage
+	StorageUploadBytes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gobackup",
+			Name:      "storage_upload_bytes_total",
+			Help:      "Total bytes uploaded to storage",
+		},
+		[]string{"model", "storage"},
+	)
+
+	// StorageUploadDurationSeconds is a histogram for the upload duration of storage
+	StorageUploadDurationSeconds = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "gobackup",
+			Name:      "storage_upload_duration_seconds",
+			Help:      "Duration of upload to storage in seconds",
+			Buckets:   prometheus.ExponentialBuckets(0.5, 2, 16),
+		},
+		[]string{"model", "storage", "status"},
+	)
+
+	// StorageUploadErrors is a counter for the failed uploads of storage
+	StorageUploadErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gobackup",
+			Name:      "storage_upload_errors_total",
+			Help:      "Total number of failed uploads to storage",
+		},
+		[]string{"model", "storage"},
+	)
+
+	// RetentionDeletions is a counter for the packages deleted by `keep` of storage
+	RetentionDeletions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gobackup",
+			Name:      "retention_deletions_total",
+			Help:      "Total number of backup packages deleted by the retention of storage",
+		},
+		[]string{"model", "storage"},
+	)
+
+	// RemotePackages is a gauge for the number of backup packages kept in storage
+	RemotePackages = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "gobackup",
+			Name:      "remote_packages",
+			Help:      "Number of backup packages kept in storage",
+		},
+		[]string{"model", "storage"},
+	)
+
+	// RemotePackageBytes is a gauge for the total size of backup packages kept in storage,
+	// the packages uploaded by the old versions have no size
+	RemotePackageBytes = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "gobackup",
+			Name:      "remote_package_bytes",
+			Help:      "Total size of backup packages kept in storage in bytes",
+		},
+		[]string{"model", "storage"},
+	)
+
+	// Up is always 1 when GoBackup is running, for alerting on `absent(gobackup_up)`
+	Up = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "gobackup",
+			Name:      "up",
+			Help:      "Whether GoBackup is running",
+		},
+	)
+
+	// Leader is 1 when this instance is the leader of `leader_election` and runs the schedules
+	Leader = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "gobackup",
+			Name:      "leader",
+			Help:      "Whether this instance is the leader of leader election",
+		},
+	)
+
+	// ConfigReloadSuccess is a gauge for whether the last config load is successful
+	ConfigReloadSuccess = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "gobackup",
+			Name:      "config_reload_success",
+			Help:      "Whether the last config load is successful",
+		},
+	)
+
+	// ConfigReloadTimestamp is a gauge for the timestamp of the last successful config load (Unix epoch)
+	ConfigReloadTimestamp = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "gobackup",
+			Name:      "config_reload_timestamp",
+			Help:      "Timestamp of the last successful config load (Unix epoch)",
+		},
+	)
 )
+
+func init() {
+	Up.Set(1)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/longbridgeapp/assert"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestMetricsRegistration(t *testing.T) {
@@ -17,7 +18,7 @@ func TestMetricsRegistration(t *testing.T) {
 	assert.NotNil(t, desc)
 
 	// BackupDurationSeconds
-	DuractionSeconds.Describe(ch)
+	DurationSeconds.Describe(ch)
 	desc = <-ch
 	assert.NotNil(t, desc)
 
@@ -30,6 +31,26 @@ func TestMetricsRegistration(t *testing.T) {
 	LastTimestamp.Describe(ch)
 	desc = <-ch
 	assert.NotNil(t, desc)
+
+	NextExpectedTimestamp.Describe(ch)
+	desc = <-ch
+	assert.NotNil(t, desc)
+
+	for _, c := range []prometheus.Collector{
+		StageDurationSeconds, DatabaseDumpBytes, DatabaseDumpDurationSeconds,
+		StorageUploadBytes, StorageUploadDurationSeconds, StorageUploadErrors,
+		RetentionDeletions, RemotePackages, RemotePackageBytes,
+		Up, ConfigReloadSuccess, ConfigReloadTimestamp,
+	} {
+		c.Describe(ch)
+		desc = <-ch
+		assert.NotNil(t, desc)
+	}
+}
+
+func TestDeprecatedDuractionSeconds(t *testing.T) {
+	assert.Equal(t, DurationSeconds, DuractionSeconds)
+	assert.Equal(t, float64(1), testutil.ToFloat64(Up))
 }
 
 func TestMetricsLabels(t *testing.T) {
@@ -37,10 +58,13 @@ func TestMetricsLabels(t *testing.T) {
 	TotalAttempts.WithLabelValues("test_model", "success").Add(0)
 	TotalAttempts.WithLabelValues("test_model", "failure").Add(0)
 
-	DuractionSeconds.WithLabelValues("test_model").Observe(0)
+	DurationSeconds.WithLabelValues("test_model").Observe(0)
+	StageDurationSeconds.WithLabelValues("test_model", "dump").Observe(0)
 
 	FileSizes.WithLabelValues("test_model").Set(0)
 
 	LastTimestamp.WithLabelValues("test_model", "success").Set(0)
 	LastTimestamp.WithLabelValues("test_model", "failure").Set(0)
+
+	NextExpectedTimestamp.WithLabelValues("test_model").Set(0)
 }
@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// namespace of the metrics of GoBackup
+const namespace = "gobackup"
+
+// PushGateway is the Prometheus Pushgateway to push the metrics of one-shot runs
+type PushGateway struct {
+	// URL of Pushgateway, e.g. http://pushgateway:9091
+	URL string
+	// Job name, default: gobackup
+	Job string
+	// Grouping labels besides the model
+	Grouping map[string]string
+	// Username and Password of basic auth
+	Username string
+	Password string
+}
+
+// Push replaces the metrics of model in Pushgateway, grouped by the job, model and the grouping labels,
+// so that the models performed by different jobs do not overwrite each other.
+func Push(gateway PushGateway, model string) error {
+	job := gateway.Job
+	if len(job) == 0 {
+		job = namespace
+	}
+
+	pusher := push.New(gateway.URL, job).
+		Gatherer(gatherer(model)).
+		Grouping("model", model)
+	for name, value := range gateway.Grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+	if len(gateway.Username) > 0 {
+		pusher = pusher.BasicAuth(gateway.Username, gateway.Password)
+	}
+
+	if err := pusher.Push(); err != nil {
+		return fmt.Errorf("push metrics to %s: %v", gateway.URL, err)
+	}
+	return nil
+}
+
+// WriteTextfiles writes the metrics in the text format for the textfile collector of node_exporter.
+// The series of each model are written to `<name>-<model>.prom` beside path, and the series without model label to path,
+// so that the models performed by different jobs do not overwrite each other. The files are replaced atomically.
+func WriteTextfiles(path string, models []string) error {
+	families, err := gather(func(model string) bool { return len(model) == 0 }, false)
+	if err != nil {
+		return err
+	}
+	if err := writeTextfile(path, families); err != nil {
+		return err
+	}
+
+	for _, model := range models {
+		families, err := gather(func(value string) bool { return value == model }, false)
+		if err != nil {
+			return err
+		}
+		if err := writeTextfile(modelTextfile(path, model), families); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// modelTextfile returns `<name>-<model>.prom` beside path, the unsafe characters of model are replaced by `_`
+func modelTextfile(path, model string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, model)
+	return strings.TrimSuffix(path, ".prom") + "-" + name + ".prom"
+}
+
+func writeTextfile(path string, families []*dto.MetricFamily) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	// The textfile collector only reads *.prom, so the temp file is ignored
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	encoder := expfmt.NewEncoder(f, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// gatherer returns the metrics of GoBackup without the Go and process metrics.
+// When model is not empty, only the series of model or without model label are returned,
+// and the model label is removed, because Pushgateway adds it from the grouping labels.
+func gatherer(model string) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		if len(model) == 0 {
+			return gather(func(string) bool { return true }, false)
+		}
+		return gather(func(value string) bool { return len(value) == 0 || value == model }, true)
+	})
+}
+
+// gather returns the gobackup_* series which model label is matched, the value is empty without model label.
+// The model label is removed when dropModel is true.
+func gather(match func(model string) bool, dropModel bool) ([]*dto.MetricFamily, error) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*dto.MetricFamily, 0, len(families))
+	for _, family := range families {
+		if !strings.HasPrefix(family.GetName(), namespace+"_") {
+			continue
+		}
+
+		metrics := make([]*dto.Metric, 0, len(family.Metric))
+		for _, metric := range family.Metric {
+			value, _ := labelValue(metric, "model")
+			if !match(value) {
+				continue
+			}
+			if dropModel {
+				// The labels are shared with the collectors, do not modify them
+				labels := make([]*dto.LabelPair, 0, len(metric.Label))
+				for _, label := range metric.Label {
+					if label.GetName() != "model" {
+						labels = append(labels, label)
+					}
+				}
+				metric.Label = labels
+			}
+			metrics = append(metrics, metric)
+		}
+		if len(metrics) > 0 {
+			family.Metric = metrics
+			result = append(result, family)
+		}
+	}
+	return result, nil
+}
+
+func labelValue(metric *dto.Metric, name string) (string, bool) {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue(), true
+		}
+	}
+	return "", false
+}
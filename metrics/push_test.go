@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+)
+
+func TestPush(t *testing.T) {
+	TotalAttempts.WithLabelValues("push_app", "success").Inc()
+	TotalAttempts.WithLabelValues("push_other", "success").Inc()
+
+	var method, path, username, password string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		username, password, _ = r.BasicAuth()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	err := Push(PushGateway{
+		URL:      server.URL,
+		Grouping: map[string]string{"cluster": "prod"},
+		Username: "gobackup",
+		Password: "123456",
+	}, "push_app")
+	assert.NoError(t, err)
+	assert.Equal(t, "PUT", method)
+	assert.Equal(t, "/metrics/job/gobackup/model/push_app/cluster/prod", path)
+	assert.Equal(t, "gobackup", username)
+	assert.Equal(t, "123456", password)
+
+	err = Push(PushGateway{URL: "http://127.0.0.1:1", Job: "backup"}, "push_app")
+	assert.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "push metrics to http://127.0.0.1:1: "))
+}
+
+func Test_gatherer(t *testing.T) {
+	TotalAttempts.WithLabelValues("gather_app", "success").Inc()
+	TotalAttempts.WithLabelValues("gather_other", "success").Inc()
+
+	families, err := gatherer("gather_app").Gather()
+	assert.NoError(t, err)
+
+	names := map[string]int{}
+	for _, family := range families {
+		names[family.GetName()] = len(family.Metric)
+		assert.True(t, strings.HasPrefix(family.GetName(), "gobackup_"))
+		for _, metric := range family.Metric {
+			_, ok := labelValue(metric, "model")
+			assert.False(t, ok)
+		}
+	}
+	assert.Equal(t, 1, names["gobackup_total_attempts"])
+	assert.Equal(t, 1, names["gobackup_up"])
+
+	families, err = gatherer("").Gather()
+	assert.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == "gobackup_total_attempts" {
+			assert.True(t, len(family.Metric) > 1)
+		}
+	}
+}
+
+func TestWriteTextfiles(t *testing.T) {
+	TotalAttempts.WithLabelValues("textfile_app", "success").Inc()
+	TotalAttempts.WithLabelValues("textfile_db", "failure").Inc()
+
+	path := filepath.Join(t.TempDir(), "node_exporter", "gobackup.prom")
+	assert.NoError(t, WriteTextfiles(path, []string{"textfile_app"}))
+	// Written by another job, the textfile of textfile_app is kept
+	assert.NoError(t, WriteTextfiles(path, []string{"textfile_db"}))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "gobackup_up 1")
+	assert.NotContains(t, string(data), "model=")
+	assert.NotContains(t, string(data), "go_goroutines")
+
+	data, err = os.ReadFile(filepath.Join(filepath.Dir(path), "gobackup-textfile_app.prom"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `gobackup_total_attempts{model="textfile_app",status="success"} 1`)
+	assert.NotContains(t, string(data), "textfile_db")
+	assert.NotContains(t, string(data), "gobackup_up")
+
+	data, err = os.ReadFile(filepath.Join(filepath.Dir(path), "gobackup-textfile_db.prom"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `gobackup_total_attempts{model="textfile_db",status="failure"} 1`)
+	assert.NotContains(t, string(data), "textfile_app")
+
+	// No temp files left
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(entries))
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+}
+
+func TestModelTextfile(t *testing.T) {
+	assert.Equal(t, "/var/lib/node_exporter/gobackup-my_app.prom", modelTextfile("/var/lib/node_exporter/gobackup.prom", "my_app"))
+	assert.Equal(t, "/var/lib/node_exporter/gobackup-my_app_1.prom", modelTextfile("/var/lib/node_exporter/gobackup.prom", "my/app 1"))
+}
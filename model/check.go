@@ -0,0 +1,31 @@
+package model
+
+import (
+	"os"
+
+	"github.com/gobackup/gobackup/archive"
+	"github.com/gobackup/gobackup/compressor"
+	"github.com/gobackup/gobackup/database"
+	"github.com/gobackup/gobackup/encryptor"
+	"github.com/gobackup/gobackup/notifier"
+	"github.com/gobackup/gobackup/splitter"
+	"github.com/gobackup/gobackup/storage"
+)
+
+// Check validates the model config and the required binaries without performing backup.
+//
+// When connect is true, it also connects to each database and storage,
+// when notify is true, it sends a test notification by each notifier.
+func (m Model) Check(connect, notify bool) (errs []error) {
+	defer os.RemoveAll(m.Config.TempPath)
+
+	errs = append(errs, database.Check(m.Config, connect)...)
+	errs = append(errs, archive.Check(m.Config)...)
+	errs = append(errs, compressor.Check(m.Config)...)
+	errs = append(errs, encryptor.Check(m.Config)...)
+	errs = append(errs, splitter.Check(m.Config)...)
+	errs = append(errs, storage.Check(m.Config, connect)...)
+	errs = append(errs, notifier.Check(m.Config, notify)...)
+
+	return
+}
@@ -0,0 +1,364 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/history"
+	"github.com/gobackup/gobackup/logger"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrOverlapSkipped is returned by Perform when the model is performing and `on_overlap: skip`
+	ErrOverlapSkipped = errors.New("skipped, the previous run is still performing")
+	// ErrCanceled is the cause of the run canceled by `on_overlap: cancel_previous`
+	ErrCanceled = errors.New("canceled by a new run")
+	// ErrRunCanceled is the cause of the run canceled by CancelRun
+	ErrRunCanceled = errors.New("canceled")
+	// ErrShutdown is returned by Perform when GoBackup is shutting down, and the cause of the runs canceled by Shutdown
+	ErrShutdown = fmt.Errorf("%w by shutdown", ErrRunCanceled)
+
+	runsLock = sync.Mutex{}
+	runsCond = sync.NewCond(&runsLock)
+	// performing runs by model name
+	runs = map[string]*Run{}
+	// queued runs by id, waiting for the previous run or max_concurrent_models
+	queued = map[string]*Run{}
+	// closed to reject the new runs when GoBackup is shutting down
+	closed bool
+
+	// lockPollInterval is the interval to retry the file lock held by other process
+	lockPollInterval = time.Second
+)
+
+// Run is a performing of model
+type Run struct {
+	ID        string    `json:"id"`
+	Model     string    `json:"model"`
+	StartedAt time.Time `json:"started_at"`
+	Queued    bool      `json:"queued"`
+
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	lockFile *os.File
+}
+
+// newRun with the model and run ID in the log fields of ctx
+func newRun(ctx context.Context, model string) *Run {
+	id := uuid.NewString()
+	ctx = logger.NewContext(ctx, logger.Fields{Model: model, RunID: id})
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Run{
+		ID:     id,
+		Model:  model,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Runs returns the performing and queued runs
+func Runs() []*Run {
+	runsLock.Lock()
+	defer runsLock.Unlock()
+
+	result := make([]*Run, 0, len(runs)+len(queued))
+	for _, r := range runs {
+		result = append(result, r)
+	}
+	for _, r := range queued {
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartedAt.Before(result[j].StartedAt)
+	})
+
+	return result
+}
+
+// CancelRun cancels the performing or queued run by id, the temp files will be cleaned up when it's stopped.
+// It returns false if the run is not found.
+func CancelRun(id string) bool {
+	runsLock.Lock()
+	defer runsLock.Unlock()
+
+	if r, ok := queued[id]; ok {
+		r.cancel(ErrRunCanceled)
+		return true
+	}
+	for _, r := range runs {
+		if r.ID == id {
+			r.cancel(ErrRunCanceled)
+			return true
+		}
+	}
+
+	return false
+}
+
+// acquire waits for the model can be performed with the `on_overlap` policy, `max_concurrent_models` and `lock_file`,
+// then marks the model as performing until the returned run is released.
+// The waiting run is queued, it stops waiting with the cause when ctx is done or it's canceled by CancelRun.
+func (m Model) acquire(ctx context.Context) (*Run, error) {
+	r := newRun(ctx, m.Config.Name)
+	logger := logger.Tag(fmt.Sprintf("Model: %s", m.Config.Name)).WithContext(r.ctx)
+
+	runsLock.Lock()
+	if previous, ok := runs[m.Config.Name]; ok {
+		switch m.Config.OnOverlap {
+		case "skip":
+			runsLock.Unlock()
+			r.cancel(ErrOverlapSkipped)
+			m.finishQueued(ctx, r, ErrOverlapSkipped)
+			return nil, ErrOverlapSkipped
+		case "cancel_previous":
+			logger.Info("Cancel the previous run")
+			previous.cancel(ErrCanceled)
+		default:
+			logger.Info("Waiting for the previous run")
+		}
+	}
+
+	// Wake up the waiting runs to check ctx
+	stop := context.AfterFunc(r.ctx, func() {
+		runsLock.Lock()
+		defer runsLock.Unlock()
+
+		runsCond.Broadcast()
+	})
+	defer stop()
+
+	var err error
+	waitingLock := false
+	for {
+		if closed {
+			err = ErrShutdown
+			break
+		}
+		if r.ctx.Err() != nil {
+			err = context.Cause(r.ctx)
+			break
+		}
+
+		if m.busy() {
+			if !r.Queued {
+				m.enqueue(ctx, r)
+				continue
+			}
+			runsCond.Wait()
+			continue
+		}
+		if !config.LockFile {
+			break
+		}
+
+		// The file lock is taken before counting the run as performing,
+		// the run waiting for other process is queued and does not take a slot of max_concurrent_models.
+		var locked bool
+		if locked, err = r.tryLock(m); locked || err != nil {
+			break
+		}
+		if m.Config.OnOverlap == "skip" {
+			err = ErrOverlapSkipped
+			break
+		}
+		if !r.Queued {
+			m.enqueue(ctx, r)
+			continue
+		}
+
+		// The run in other process can not be canceled, wait for it
+		if !waitingLock {
+			logger.Infof("Waiting for the lock: %s", r.lockFile.Name())
+			waitingLock = true
+		}
+		runsLock.Unlock()
+		select {
+		case <-r.ctx.Done():
+		case <-time.After(lockPollInterval):
+		}
+		runsLock.Lock()
+	}
+	if r.Queued {
+		delete(queued, r.ID)
+		r.Queued = false
+	}
+
+	if err != nil {
+		runsLock.Unlock()
+		if r.lockFile != nil {
+			r.lockFile.Close()
+			r.lockFile = nil
+		}
+		r.cancel(err)
+		m.finishQueued(ctx, r, err)
+		return nil, err
+	}
+	r.StartedAt = time.Now()
+	runs[m.Config.Name] = r
+	runsLock.Unlock()
+
+	return r, nil
+}
+
+// enqueue marks the run as queued and saves the record, runsLock must be held, it's unlocked during saving
+func (m Model) enqueue(ctx context.Context, r *Run) {
+	r.Queued = true
+	r.StartedAt = time.Now()
+	queued[r.ID] = r
+	runsLock.Unlock()
+
+	m.saveRecord(r.ctx, history.Record{
+		ID:        r.ID,
+		Model:     m.Config.Name,
+		Trigger:   history.TriggerFrom(ctx),
+		Status:    history.StatusQueued,
+		StartedAt: r.StartedAt,
+	})
+
+	runsLock.Lock()
+}
+
+// busy returns true when the model is performing or max_concurrent_models is reached, runsLock must be held
+func (m Model) busy() bool {
+	return runs[m.Config.Name] != nil || len(runs) >= config.MaxConcurrentModels
+}
+
+// finishQueued saves the record of the queued run which is stopped before performing, and of the skipped run
+func (m Model) finishQueued(ctx context.Context, r *Run, err error) {
+	status := history.StatusFailure
+	switch {
+	case errors.Is(err, ErrOverlapSkipped):
+		status = history.StatusSkipped
+	case errors.Is(err, ErrRunCanceled) || errors.Is(err, context.Canceled):
+		status = history.StatusCanceled
+	}
+
+	if r.StartedAt.IsZero() {
+		if status != history.StatusSkipped {
+			return
+		}
+		r.StartedAt = time.Now()
+	}
+
+	finishedAt := time.Now()
+	m.saveRecord(r.ctx, history.Record{
+		ID:         r.ID,
+		Model:      m.Config.Name,
+		Trigger:    history.TriggerFrom(ctx),
+		Status:     status,
+		StartedAt:  r.StartedAt,
+		FinishedAt: &finishedAt,
+		Error:      err.Error(),
+	})
+}
+
+// tryLock takes the file lock of the model in GoBackupDir across processes without blocking,
+// it returns false when the lock is held by other process.
+func (r *Run) tryLock(m Model) (bool, error) {
+	if r.lockFile == nil {
+		dir := filepath.Join(config.GoBackupDir, "locks")
+		if err := helper.MkdirP(dir); err != nil {
+			return false, err
+		}
+
+		file, err := os.OpenFile(filepath.Join(dir, m.Config.Name+".lock"), os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			return false, err
+		}
+		r.lockFile = file
+	}
+
+	locked, err := tryLockFile(r.lockFile)
+	if err != nil {
+		return false, fmt.Errorf("lock %s: %v", r.lockFile.Name(), err)
+	}
+	return locked, nil
+}
+
+// release the run, and wakes up the waiting runs
+func (m Model) release(r *Run) {
+	r.cancel(nil)
+
+	if r.lockFile != nil {
+		unlockFile(r.lockFile)
+		r.lockFile.Close()
+	}
+
+	runsLock.Lock()
+	defer runsLock.Unlock()
+
+	if runs[m.Config.Name] == r {
+		delete(runs, m.Config.Name)
+	}
+	runsCond.Broadcast()
+}
+
+// performingCount returns the number of performing models
+func performingCount() int {
+	runsLock.Lock()
+	defer runsLock.Unlock()
+
+	return len(runs)
+}
+
+// Close rejects the new runs and the queued runs with ErrShutdown, the performing runs are not affected
+func Close() {
+	runsLock.Lock()
+	defer runsLock.Unlock()
+
+	closed = true
+	runsCond.Broadcast()
+}
+
+// Wait for the performing runs done, returns the error of ctx if they are not done before ctx is done
+func Wait(ctx context.Context) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for performingCount() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+// Shutdown cancels the performing runs with ErrShutdown, and waits for their temp files cleaned up until ctx is done.
+// The temp files of the runs not stopped in time are removed.
+func Shutdown(ctx context.Context) {
+	Close()
+
+	runsLock.Lock()
+	for _, r := range runs {
+		r.cancel(ErrShutdown)
+	}
+	runsLock.Unlock()
+
+	if err := Wait(ctx); err == nil {
+		return
+	}
+
+	for _, r := range Runs() {
+		logger := logger.Tag(fmt.Sprintf("Model: %s", r.Model)).WithContext(r.ctx)
+		modelConfig := config.GetModelConfigByName(r.Model)
+		if modelConfig == nil {
+			continue
+		}
+		logger.Warnf("Not stopped in time, cleanup temp: %s/", modelConfig.TempPath)
+		if err := os.RemoveAll(modelConfig.TempPath); err != nil {
+			logger.Errorf("Cleanup temp dir %s error: %v", modelConfig.TempPath, err)
+		}
+	}
+}
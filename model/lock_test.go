@@ -0,0 +1,300 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
+	"github.com/longbridgeapp/assert"
+)
+
+func newLockModel(name, onOverlap string) Model {
+	return Model{Config: config.ModelConfig{Name: name, OnOverlap: onOverlap}}
+}
+
+func TestModel_acquire_skip(t *testing.T) {
+	config.MaxConcurrentModels = 2
+
+	m := newLockModel("lock-skip", "skip")
+	r, err := m.acquire(context.Background())
+	assert.NoError(t, err)
+
+	_, err = m.acquire(context.Background())
+	assert.Equal(t, ErrOverlapSkipped, err)
+
+	m.release(r)
+	r, err = m.acquire(context.Background())
+	assert.NoError(t, err)
+	m.release(r)
+}
+
+func TestModel_acquire_queue(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	config.MaxConcurrentModels = 2
+
+	m := newLockModel("lock-queue", "queue")
+	r, err := m.acquire(context.Background())
+	assert.NoError(t, err)
+
+	acquired := make(chan *Run)
+	go func() {
+		r, _ := m.acquire(context.Background())
+		acquired <- r
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired before the previous run released")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	m.release(r)
+	next := <-acquired
+	assert.NoError(t, next.ctx.Err())
+	m.release(next)
+}
+
+func TestModel_acquire_cancelPrevious(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	config.MaxConcurrentModels = 2
+
+	m := newLockModel("lock-cancel", "cancel_previous")
+	r, err := m.acquire(context.Background())
+	assert.NoError(t, err)
+
+	acquired := make(chan *Run)
+	go func() {
+		r, _ := m.acquire(context.Background())
+		acquired <- r
+	}()
+
+	select {
+	case <-r.ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the previous run is not canceled")
+	}
+
+	m.release(r)
+	next := <-acquired
+	assert.NoError(t, next.ctx.Err())
+	m.release(next)
+}
+
+func TestModel_acquire_maxConcurrentModels(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	config.MaxConcurrentModels = 1
+
+	r1, err := newLockModel("lock-max1", "queue").acquire(context.Background())
+	assert.NoError(t, err)
+
+	m2 := newLockModel("lock-max2", "queue")
+	acquired := make(chan *Run)
+	go func() {
+		r, _ := m2.acquire(context.Background())
+		acquired <- r
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired more than max_concurrent_models")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	newLockModel("lock-max1", "queue").release(r1)
+	m2.release(<-acquired)
+}
+
+func TestModel_acquire_queueCanceled(t *testing.T) {
+	config.MaxConcurrentModels = 2
+	config.GoBackupDir = t.TempDir()
+
+	m := newLockModel("lock-queue-canceled", "queue")
+	r, err := m.acquire(context.Background())
+	assert.NoError(t, err)
+	defer m.release(r)
+
+	// By ctx, e.g. timeout or signal
+	ctx, cancel := context.WithTimeoutCause(context.Background(), 50*time.Millisecond, errors.New("timed out"))
+	defer cancel()
+	_, err = m.acquire(ctx)
+	assert.EqualError(t, err, "timed out")
+
+	record, err := history.List("lock-queue-canceled", "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(record))
+	assert.Equal(t, history.StatusFailure, record[0].Status)
+	assert.Equal(t, "timed out", record[0].Error)
+
+	// By CancelRun
+	queued := make(chan error)
+	go func() {
+		_, err := m.acquire(context.Background())
+		queued <- err
+	}()
+
+	var id string
+	for i := 0; i < 100 && len(id) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		for _, run := range Runs() {
+			if run.Queued {
+				id = run.ID
+			}
+		}
+	}
+	assert.True(t, CancelRun(id))
+	assert.Equal(t, ErrRunCanceled, <-queued)
+	assert.Equal(t, 1, len(Runs()))
+
+	record, err = history.List("lock-queue-canceled", "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(record))
+	assert.Equal(t, id, record[0].ID)
+	assert.Equal(t, history.StatusCanceled, record[0].Status)
+}
+
+func TestModel_acquire_lockFile(t *testing.T) {
+	config.MaxConcurrentModels = 2
+	config.LockFile = true
+	config.GoBackupDir = t.TempDir()
+	defer func() {
+		config.LockFile = false
+	}()
+
+	m := newLockModel("lock-file", "skip")
+	r, err := m.acquire(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, r.lockFile)
+
+	// Simulate the lock held by other process
+	other := &Run{}
+	locked, err := other.tryLock(m)
+	assert.NoError(t, err)
+	assert.False(t, locked)
+
+	m.release(r)
+	locked, err = other.tryLock(m)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+
+	// Skipped with the lock held by other process
+	_, err = m.acquire(context.Background())
+	assert.Equal(t, ErrOverlapSkipped, err)
+	records, err := history.List("lock-file", "", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, history.StatusSkipped, records[0].Status)
+	other.lockFile.Close()
+}
+
+func TestModel_acquire_lockFileQueued(t *testing.T) {
+	config.MaxConcurrentModels = 1
+	config.LockFile = true
+	config.GoBackupDir = t.TempDir()
+	defer history.Close()
+	lockPollInterval = 10 * time.Millisecond
+	defer func() {
+		config.LockFile = false
+		config.MaxConcurrentModels = 2
+		lockPollInterval = time.Second
+	}()
+
+	m := newLockModel("lock-file-wait", "queue")
+	// Simulate the lock held by other process
+	other := &Run{}
+	locked, err := other.tryLock(m)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+
+	// Queued until canceled, without taking the slot of max_concurrent_models
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := m.acquire(ctx)
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, performingCount())
+	runs := Runs()
+	assert.Equal(t, 1, len(runs))
+	assert.True(t, runs[0].Queued)
+
+	another := newLockModel("lock-file-another", "queue")
+	r, err := another.acquire(context.Background())
+	assert.NoError(t, err)
+	another.release(r)
+
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+	assert.Equal(t, 0, len(Runs()))
+
+	// Acquired when the lock is released
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		unlockFile(other.lockFile)
+		other.lockFile.Close()
+	}()
+	r, err = m.acquire(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, r.Queued)
+	m.release(r)
+}
+
+func TestCancelRun(t *testing.T) {
+	config.MaxConcurrentModels = 2
+
+	m := newLockModel("cancel-run", "queue")
+	r, err := m.acquire(context.Background())
+	assert.NoError(t, err)
+
+	runs := Runs()
+	assert.Equal(t, 1, len(runs))
+	assert.Equal(t, "cancel-run", runs[0].Model)
+
+	assert.False(t, CancelRun("not-exist"))
+	assert.True(t, CancelRun(r.ID))
+	assert.Error(t, r.ctx.Err())
+	assert.Equal(t, ErrRunCanceled, context.Cause(r.ctx))
+
+	m.release(r)
+	assert.Equal(t, 0, len(Runs()))
+}
+
+func TestShutdown(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	config.MaxConcurrentModels = 2
+	defer func() {
+		closed = false
+	}()
+
+	m := newLockModel("shutdown", "queue")
+	r, err := m.acquire(context.Background())
+	assert.NoError(t, err)
+
+	queued := make(chan error)
+	go func() {
+		_, err := m.acquire(context.Background())
+		queued <- err
+	}()
+
+	// The queued run is rejected, the performing run is not affected
+	Close()
+	assert.Equal(t, ErrShutdown, <-queued)
+	assert.NoError(t, r.ctx.Err())
+	_, err = newLockModel("shutdown-new", "queue").acquire(context.Background())
+	assert.Equal(t, ErrShutdown, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, Wait(ctx))
+
+	go func() {
+		<-r.ctx.Done()
+		m.release(r)
+	}()
+	Shutdown(context.Background())
+	assert.Equal(t, ErrShutdown, context.Cause(r.ctx))
+	assert.True(t, errors.Is(ErrShutdown, ErrRunCanceled))
+	assert.NoError(t, Wait(context.Background()))
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package model
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLockFile locks file exclusively without blocking, it returns false when the lock is held by other process
+func tryLockFile(file *os.File) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func unlockFile(file *os.File) {
+	_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
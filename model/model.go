@@ -1,11 +1,15 @@
 package model
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/gobackup/gobackup/archive"
 	"github.com/gobackup/gobackup/compressor"
@@ -13,11 +17,31 @@ import (
 	"github.com/gobackup/gobackup/database"
 	"github.com/gobackup/gobackup/encryptor"
 	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/history"
 	"github.com/gobackup/gobackup/logger"
 	"github.com/gobackup/gobackup/metrics"
 	"github.com/gobackup/gobackup/notifier"
+	"github.com/gobackup/gobackup/progress"
 	"github.com/gobackup/gobackup/splitter"
 	"github.com/gobackup/gobackup/storage"
+	"github.com/gobackup/gobackup/tracing"
+)
+
+// metricStages are the names of stages in metrics
+var metricStages = map[string]string{
+	"database":   "dump",
+	"archive":    "archive",
+	"compressor": "compress",
+	"encryptor":  "encrypt",
+	"splitter":   "split",
+	"storage":    "upload",
+}
+
+const (
+	// logExcerptLines is the number of the last log lines kept in the run history
+	logExcerptLines = 100
+	// logTailLines is the number of the last log lines in the report to notifiers
+	logTailLines = 20
 )
 
 // Model class
@@ -25,25 +49,115 @@ type Model struct {
 	Config config.ModelConfig
 }
 
-// Perform model
-func (m Model) Perform() (err error) {
-	logger := logger.Tag(fmt.Sprintf("Model: %s", m.Config.Name))
+// Perform model, it stops when ctx is done or the model `timeout` exceeded
+func (m Model) Perform(ctx context.Context) (err error) {
+	record := history.Record{
+		Model:     m.Config.Name,
+		Trigger:   history.TriggerFrom(ctx),
+		StartedAt: time.Now(),
+	}
+
+	// The timeout includes the time waiting in queue
+	if m.Config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, m.Config.Timeout, fmt.Errorf("timed out after %s", m.Config.Timeout))
+		defer cancel()
+	}
+
+	// The skipped and queued runs are recorded by acquire
+	r, err := m.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer m.release(r)
+
+	capture := logger.StartCapture(r.ID, logExcerptLines)
+	defer capture.Stop()
+
+	logPath := history.LogPath(r.ID)
+	runLogErr := logger.OpenRunLog(r.ID, logPath)
+	defer logger.CloseRunLog(r.ID)
+
+	ctx = r.ctx
+	logger := logger.Tag(fmt.Sprintf("Model: %s", m.Config.Name)).WithContext(ctx)
+	if runLogErr != nil {
+		logger.Errorf("Failed to open run log: %v", runLogErr)
+		logPath = ""
+	}
+
+	ctx, span := tracing.Start(ctx, "gobackup.perform",
+		attribute.String("gobackup.model", m.Config.Name),
+		attribute.String("gobackup.run_id", r.ID),
+		attribute.String("gobackup.trigger", string(record.Trigger)),
+	)
+
 	startTime := time.Now()
 	var archivePath string
+	var dbResults []database.Result
+	var storageResults []storage.Result
+
+	record.ID = r.ID
+	record.StartedAt = r.StartedAt
+	record.Status = history.StatusRunning
+	m.saveRecord(ctx, record)
+
+	progress.Start(r.ID, m.Config.Name)
+	defer progress.Finish(r.ID)
 
-	m.before()
+	m.before(ctx)
 
 	defer func() {
 		duration := time.Since(startTime).Seconds()
-		metrics.DuractionSeconds.WithLabelValues(m.Config.Name).Observe(duration)
+		metrics.DurationSeconds.WithLabelValues(m.Config.Name).Observe(duration)
+
+		finishedAt := time.Now()
+		record.FinishedAt = &finishedAt
+		switch {
+		case err == nil:
+			record.Status = history.StatusSuccess
+		case errors.Is(err, ErrRunCanceled), errors.Is(err, ErrCanceled):
+			record.Status = history.StatusCanceled
+			record.Error = err.Error()
+		default:
+			record.Status = history.StatusFailure
+			record.Error = err.Error()
+		}
+		record.Log = capture.String()
+
+		report := notifier.Report{
+			Model:       m.Config.Name,
+			RunID:       r.ID,
+			StartedAt:   record.StartedAt,
+			FinishedAt:  finishedAt,
+			Duration:    duration,
+			ArchiveSize: record.ArchiveSize,
+			Error:       record.Error,
+			LogTail:     tailLines(record.Log, logTailLines),
+			LogFile:     logPath,
+		}
+		// The current run is not finished in history yet
+		if previous, historyErr := history.ConsecutiveFailures(m.Config.Name); historyErr != nil {
+			logger.Errorf("Failed to read run history: %v", historyErr)
+		} else {
+			report.PreviousFailures = previous
+		}
+		if err != nil {
+			report.ConsecutiveFailures = report.PreviousFailures + 1
+		}
+		for _, result := range dbResults {
+			report.Databases = append(report.Databases, notifier.DatabaseReport(result))
+		}
+		for _, result := range storageResults {
+			report.Storages = append(report.Storages, notifier.StorageReport(result))
+		}
 
 		if err != nil {
 			logger.Error(err)
-			notifier.Failure(m.Config, err.Error())
+			notifier.Failure(withStage(ctx, "notifier"), m.Config, report)
 			metrics.TotalAttempts.WithLabelValues(m.Config.Name, "failure").Inc()
 			metrics.LastTimestamp.WithLabelValues(m.Config.Name, "failure").Set(float64(time.Now().Unix()))
 		} else {
-			notifier.Success(m.Config)
+			notifier.Success(withStage(ctx, "notifier"), m.Config, report)
 			metrics.TotalAttempts.WithLabelValues(m.Config.Name, "success").Inc()
 			metrics.LastTimestamp.WithLabelValues(m.Config.Name, "success").Set(float64(time.Now().Unix()))
 
@@ -54,47 +168,120 @@ func (m Model) Perform() (err error) {
 				}
 			}
 		}
+
+		m.saveRecord(ctx, record)
+
+		span.SetAttributes(
+			attribute.String("gobackup.status", string(record.Status)),
+			attribute.Int64("gobackup.archive.size", record.ArchiveSize),
+		)
+		tracing.End(span, err)
+	}()
+
+	// Report the reason when it's canceled or timed out
+	defer func() {
+		if ctx.Err() != nil {
+			cause := context.Cause(ctx)
+			if err == nil || errors.Is(err, cause) {
+				err = cause
+			} else {
+				err = fmt.Errorf("%w: %v", cause, err)
+			}
+		}
 	}()
 
 	logger.Info("WorkDir:", m.Config.DumpPath)
 
 	defer func() {
 		if r := recover(); r != nil {
-			m.after()
+			m.after(ctx)
 		}
 
-		m.after()
+		m.after(ctx)
 	}()
 
-	err = database.Run(m.Config)
+	// stage runs fn with the stage in log fields, and records the duration
+	stage := func(name string, fn func(ctx context.Context) error) error {
+		startedAt := time.Now()
+		progress.SetStage(ctx, name)
+		ctx, span := tracing.Start(ctx, "gobackup."+metricStages[name])
+		err := fn(withStage(ctx, name))
+		tracing.End(span, err)
+		duration := time.Since(startedAt).Seconds()
+		record.Stages = append(record.Stages, history.Stage{Name: name, Duration: duration})
+		metrics.StageDurationSeconds.WithLabelValues(m.Config.Name, metricStages[name]).Observe(duration)
+		return err
+	}
+
+	err = stage("database", func(ctx context.Context) (err error) {
+		stop := progress.Watch(ctx, progress.KindDump, m.Config.DumpPath, 0)
+		dbResults, err = database.Run(ctx, m.Config)
+		stop(err)
+		return
+	})
 	if err != nil {
 		return
 	}
 
 	if m.Config.Archive != nil {
-		err = archive.Run(m.Config)
+		err = stage("archive", func(ctx context.Context) (err error) {
+			// The archive files are copied into the dump path
+			stop := progress.Watch(ctx, progress.KindDump, m.Config.DumpPath, 0)
+			err = archive.Run(ctx, m.Config)
+			stop(err)
+			return
+		})
 		if err != nil {
 			return
 		}
 	}
 
 	// It always to use compressor, default use tar, even not enable compress.
-	archivePath, err = compressor.Run(m.Config)
+	err = stage("compressor", func(ctx context.Context) (err error) {
+		archivePath, err = compressor.Run(ctx, m.Config)
+		tracing.SetAttributes(ctx,
+			attribute.String("gobackup.compressor.type", m.Config.CompressWith.Type),
+			attribute.Int64("gobackup.file.size", progress.Size(archivePath)),
+		)
+		return
+	})
 	if err != nil {
 		return
 	}
 
-	archivePath, err = encryptor.Run(archivePath, m.Config)
+	err = stage("encryptor", func(ctx context.Context) (err error) {
+		archivePath, err = encryptor.Run(ctx, archivePath, m.Config)
+		tracing.SetAttributes(ctx,
+			attribute.String("gobackup.encryptor.type", m.Config.EncryptWith.Type),
+			attribute.Int64("gobackup.file.size", progress.Size(archivePath)),
+		)
+		return
+	})
 	if err != nil {
 		return
 	}
 
-	archivePath, err = splitter.Run(archivePath, m.Config)
+	if fi, statErr := os.Stat(archivePath); statErr == nil {
+		record.ArchiveSize = fi.Size()
+	}
+
+	err = stage("splitter", func(ctx context.Context) (err error) {
+		archivePath, err = splitter.Run(ctx, archivePath, m.Config)
+		return
+	})
 	if err != nil {
 		return
 	}
 
-	err = storage.Run(m.Config, archivePath)
+	err = stage("storage", func(ctx context.Context) (err error) {
+		storageResults, err = storage.Run(ctx, m.Config, archivePath)
+		for _, result := range storageResults {
+			if len(result.Error) == 0 {
+				record.Storages = append(record.Storages, result.Name)
+			}
+		}
+		return
+	})
 	if err != nil {
 		return
 	}
@@ -102,11 +289,34 @@ func (m Model) Perform() (err error) {
 	return nil
 }
 
-func (m Model) before() {
+// withStage returns a copy of ctx with the stage in log fields
+func withStage(ctx context.Context, stage string) context.Context {
+	return logger.NewContext(ctx, logger.Fields{Stage: stage})
+}
+
+// tailLines returns the last n lines of s
+func tailLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// saveRecord saves the run history, the backup will not fail when it's failed
+func (m Model) saveRecord(ctx context.Context, record history.Record) {
+	if err := history.Save(record); err != nil {
+		logger.Tag(fmt.Sprintf("Model: %s", m.Config.Name)).WithContext(ctx).Errorf("Failed to save run history: %v", err)
+	}
+}
+
+func (m Model) before(ctx context.Context) {
+	logger := logger.WithContext(ctx)
+
 	// Execute before_script
 	if len(m.Config.BeforeScript) > 0 {
 		logger.Info("Executing before_script...")
-		_, err := helper.ExecScriptWithStdio(m.Config.BeforeScript, true)
+		_, err := helper.ExecScriptWithStdioContext(ctx, m.Config.BeforeScript, true)
 		if err != nil {
 			logger.Error(err)
 		}
@@ -114,11 +324,12 @@ func (m Model) before() {
 }
 
 // Cleanup model temp files
-func (m Model) after() {
-	logger := logger.Tag("Model")
+func (m Model) after(ctx context.Context) {
+	logger := logger.Tag("Model").WithContext(ctx)
 
 	tempDir := m.Config.TempPath
-	if viper.GetBool("useTempWorkDir") {
+	// Remove the whole temp workdir when no other models are performing
+	if viper.GetBool("useTempWorkDir") && performingCount() <= 1 {
 		tempDir = viper.GetString("workdir")
 	}
 	logger.Infof("Cleanup temp: %s/", tempDir)
@@ -0,0 +1,13 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+)
+
+func Test_tailLines(t *testing.T) {
+	assert.Equal(t, "", tailLines("", 2))
+	assert.Equal(t, "a\nb", tailLines("a\nb\n", 2))
+	assert.Equal(t, "b\nc", tailLines("a\nb\nc\n", 2))
+}
@@ -1,19 +1,25 @@
 package notifier
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/gobackup/gobackup/config"
 	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/tracing"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Base struct {
+	ctx       context.Context
 	viper     *viper.Viper
 	Name      string
 	onSuccess bool
 	onFailure bool
+	// report of the run to notify
+	report *Report
 }
 
 type Notifier interface {
@@ -23,12 +29,15 @@ type Notifier interface {
 var (
 	notifyTypeSuccess = 1
 	notifyTypeFailure = 2
+	notifyTypeMissed  = 3
 )
 
-func newNotifier(name string, config config.SubConfig) (Notifier, *Base, error) {
+func newNotifier(ctx context.Context, name string, config config.SubConfig, report *Report) (Notifier, *Base, error) {
 	base := &Base{
-		viper: config.Viper,
-		Name:  name,
+		ctx:    ctx,
+		viper:  config.Viper,
+		report: report,
+		Name:   name,
 	}
 	base.viper.SetDefault("on_success", true)
 	base.viper.SetDefault("on_failure", true)
@@ -73,42 +82,107 @@ func newNotifier(name string, config config.SubConfig) (Notifier, *Base, error)
 	return nil, nil, fmt.Errorf("Notifier: %s is not supported", name)
 }
 
-func notify(model config.ModelConfig, title, message string, notifyType int) {
-	logger := logger.Tag("Notifier")
+func notify(ctx context.Context, model config.ModelConfig, report Report, notifyType int) {
+	logger := logger.Tag("Notifier").WithContext(ctx)
 
 	logger.Infof("Running %d Notifiers", len(model.Notifiers))
 	for name, config := range model.Notifiers {
-		notifier, base, err := newNotifier(name, config)
+		notifier, base, err := newNotifier(ctx, name, config, &report)
 		if err != nil {
 			logger.Error(err)
 			continue
 		}
 
-		if notifyType == notifyTypeSuccess {
-			if base.onSuccess {
-				if err := notifier.notify(title, message); err != nil {
-					logger.Error(err)
-				}
+		if !base.shouldNotify(report) {
+			continue
+		}
+
+		var title, message string
+		if notifyType == notifyTypeSuccess && report.PreviousFailures == 0 && base.viper.GetString("digest") == digestDaily {
+			var ok bool
+			title, message, ok, err = base.digest(report)
+			if err != nil {
+				logger.Errorf("notifiers.%s: digest: %v", name, err)
 			}
-		} else if notifyType == notifyTypeFailure {
-			if base.onFailure {
-				if err := notifier.notify(title, message); err != nil {
-					logger.Error(err)
-				}
+			if !ok {
+				continue
+			}
+		} else {
+			title, message, err = base.titleAndMessage(report)
+			if err != nil {
+				// Do not lose the notification by the wrong template
+				logger.Errorf("notifiers.%s: %v, use the default template", name, err)
+				title, message, _ = (&Base{viper: viper.New()}).titleAndMessage(report)
 			}
 		}
+
+		if err := send(ctx, name, config.Type, report.Status, notifier, title, message); err != nil {
+			logger.Error(err)
+		}
 	}
 }
 
-func Success(model config.ModelConfig) {
-	title := fmt.Sprintf("[GoBackup] OK: Backup %s has successfully", model.Name)
-	message := fmt.Sprintf("Backup of %s completed successfully at %s", model.Name, time.Now().Local())
-	notify(model, title, message, notifyTypeSuccess)
+// send the notification in a span
+func send(ctx context.Context, name, notifierType, status string, notifier Notifier, title, message string) error {
+	_, span := tracing.Start(ctx, "gobackup.notifier.notify",
+		attribute.String("gobackup.notifier.name", name),
+		attribute.String("gobackup.notifier.type", notifierType),
+		attribute.String("gobackup.status", status),
+	)
+	err := notifier.notify(title, message)
+	tracing.End(span, err)
+	return err
+}
+
+// Success notifies the success of model with the report of run
+func Success(ctx context.Context, model config.ModelConfig, report Report) {
+	report.Status = "success"
+	notify(ctx, model, report, notifyTypeSuccess)
 }
 
-func Failure(model config.ModelConfig, reason string) {
-	title := fmt.Sprintf("[GoBackup] Err: Backup %s has failed", model.Name)
-	message := fmt.Sprintf("Backup of %s failed at %s:\n\n%s", model.Name, time.Now().Local(), reason)
+// Failure notifies the failure of model with the report of run
+func Failure(ctx context.Context, model config.ModelConfig, report Report) {
+	report.Status = "failure"
+	notify(ctx, model, report, notifyTypeFailure)
+}
+
+// Missed notifies the model has no successful backup in the expected time of schedule
+func Missed(ctx context.Context, model config.ModelConfig, report Report) {
+	report.Status = "missed"
+	notify(ctx, model, report, notifyTypeMissed)
+}
+
+// Digest sends the pending daily digests of the notifiers of model, it's called by the scheduler every day,
+// so the digest does not wait for the next successful run.
+func Digest(ctx context.Context, model config.ModelConfig, now time.Time) {
+	logger := logger.Tag("Notifier").WithContext(ctx)
+
+	report := Report{Model: model.Name, Status: "success"}
+	for name, config := range model.Notifiers {
+		if config.Viper == nil || config.Viper.GetString("digest") != digestDaily {
+			continue
+		}
 
-	notify(model, title, message, notifyTypeFailure)
+		notifier, base, err := newNotifier(ctx, name, config, &report)
+		if err != nil {
+			logger.Error(err)
+			continue
+		}
+		if !base.onSuccess {
+			continue
+		}
+
+		title, message, ok, err := base.pendingDigest(model.Name, now)
+		if err != nil {
+			logger.Errorf("notifiers.%s: digest: %v", name, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if err := send(ctx, name, config.Type, report.Status, notifier, title, message); err != nil {
+			logger.Error(err)
+		}
+	}
 }
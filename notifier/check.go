@@ -0,0 +1,90 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+)
+
+var (
+	// requiredKeys of each notifier type
+	requiredKeys = map[string][]string{
+		"mail":         {"username", "host", "to"},
+		"webhook":      {"url"},
+		"feishu":       {"url"},
+		"dingtalk":     {"url"},
+		"discord":      {"url"},
+		"slack":        {"url"},
+		"github":       {"url", "token"},
+		"telegram":     {"token", "chat_id"},
+		"postmark":     {"token", "from", "to"},
+		"sendgrid":     {"token", "from", "to"},
+		"ses":          {"from", "to"},
+		"resend":       {"token", "from", "to"},
+		"wxwork":       {"url"},
+		"googlechat":   {"url"},
+		"healthchecks": {"url"},
+	}
+)
+
+// Check notifiers config of model.
+// When send is true, it also sends a test notification by each notifier.
+func Check(model config.ModelConfig, send bool) (errs []error) {
+	for name, notifierConfig := range model.Notifiers {
+		report := checkReport(model)
+		notifier, base, err := newNotifier(context.Background(), name, notifierConfig, &report)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("notifiers.%s: type %q: %v", name, notifierConfig.Type, err))
+			continue
+		}
+
+		for _, key := range requiredKeys[notifierConfig.Type] {
+			if len(notifierConfig.Viper.GetString(key)) == 0 {
+				errs = append(errs, fmt.Errorf("notifiers.%s: %s is required", name, key))
+			}
+		}
+
+		if err := base.checkPolicy(); err != nil {
+			errs = append(errs, fmt.Errorf("notifiers.%s: %v", name, err))
+		}
+
+		// Render the templates with a report like a real run
+		if _, _, err := base.titleAndMessage(report); err != nil {
+			errs = append(errs, fmt.Errorf("notifiers.%s: %v", name, err))
+		}
+
+		if !send {
+			continue
+		}
+
+		title := fmt.Sprintf("[GoBackup] Check: %s", model.Name)
+		message := fmt.Sprintf("This is a test notification of %s from `gobackup check`.", model.Name)
+		if err := notifier.notify(title, message); err != nil {
+			errs = append(errs, fmt.Errorf("notifiers.%s: send failed: %v", name, err))
+		}
+	}
+
+	return
+}
+
+// checkReport returns a report of successful run for checking the templates
+func checkReport(model config.ModelConfig) Report {
+	now := time.Now()
+	report := Report{
+		Model:      model.Name,
+		RunID:      "check",
+		Status:     "success",
+		StartedAt:  now,
+		FinishedAt: now,
+	}
+	for name, db := range model.Databases {
+		report.Databases = append(report.Databases, DatabaseReport{Name: name, Type: db.Type, Status: "success"})
+	}
+	for name, storage := range model.Storages {
+		report.Storages = append(report.Storages, StorageReport{Name: name, Type: storage.Type, Keys: []string{"check.tar"}})
+	}
+
+	return report
+}
@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func TestCheck(t *testing.T) {
+	telegram := viper.New()
+	telegram.Set("token", "123213:this-is-my-token")
+
+	model := config.ModelConfig{
+		Name: "test",
+		Notifiers: map[string]config.SubConfig{
+			"telegram": {Name: "telegram", Type: "telegram", Viper: telegram},
+			"foo":      {Name: "foo", Type: "foo", Viper: viper.New()},
+		},
+	}
+
+	errs := Check(model, false)
+	assert.Equal(t, 2, len(errs))
+
+	messages := []string{errs[0].Error(), errs[1].Error()}
+	assert.Contains(t, messages, "notifiers.telegram: chat_id is required")
+	assert.Contains(t, messages, `notifiers.foo: type "foo": Notifier: foo is not supported`)
+}
+
+func TestCheck_send(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(200)
+	}))
+	defer server.Close()
+
+	webhook := viper.New()
+	webhook.Set("url", server.URL)
+
+	model := config.ModelConfig{
+		Name: "test",
+		Notifiers: map[string]config.SubConfig{
+			"webhook": {Name: "webhook", Type: "webhook", Viper: webhook},
+		},
+	}
+
+	assert.Equal(t, 0, len(Check(model, true)))
+	assert.Equal(t, 1, requests)
+}
@@ -59,7 +59,7 @@ func NewGoogleChat(base *Base) *GoogleChat {
 }
 
 func (s *GoogleChat) getLogger() logger.Logger {
-	return logger.Tag(fmt.Sprintf("Notifier: %s", s.Service))
+	return logger.Tag(fmt.Sprintf("Notifier: %s", s.Service)).WithContext(s.ctx)
 }
 
 func (s *GoogleChat) GoogleChatURL() (string, error) {
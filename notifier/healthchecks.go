@@ -40,7 +40,7 @@ func NewHealthchecks(base *Base) *Healthchecks {
 }
 
 func (s *Healthchecks) getLogger() logger.Logger {
-	return logger.Tag(fmt.Sprintf("Notifier: %s", s.Service))
+	return logger.Tag(fmt.Sprintf("Notifier: %s", s.Service)).WithContext(s.ctx)
 }
 
 func (s *Healthchecks) notify(title string, message string) error {
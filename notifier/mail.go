@@ -1,17 +1,24 @@
 package notifier
 
 import (
+	"bytes"
 	"crypto/tls"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 )
 
 type Mail struct {
 	// Base is the base notifier
+	base     *Base
 	from     string
 	to       []string
 	username string
@@ -19,8 +26,13 @@ type Mail struct {
 	host     string
 	port     string
 	tls      bool
+	// attachLog attaches the log of run to the failure notification
+	attachLog bool
 }
 
+// maxAttachmentSize is the max size of the attached log, the head of larger log will be dropped
+const maxAttachmentSize = 1 << 20
+
 func NewMail(base *Base) (*Mail, error) {
 	base.viper.SetDefault("port", "25")
 
@@ -35,13 +47,15 @@ func NewMail(base *Base) (*Mail, error) {
 	}
 
 	return &Mail{
-		username: username,
-		password: base.viper.GetString("password"),
-		to:       strings.Split(base.viper.GetString("to"), ","),
-		from:     from,
-		host:     base.viper.GetString("host"),
-		port:     base.viper.GetString("port"),
-		tls:      base.viper.GetBool("tls"),
+		base:      base,
+		attachLog: base.viper.GetBool("attach_log"),
+		username:  username,
+		password:  base.viper.GetString("password"),
+		to:        strings.Split(base.viper.GetString("to"), ","),
+		from:      from,
+		host:      base.viper.GetString("host"),
+		port:      base.viper.GetString("port"),
+		tls:       base.viper.GetBool("tls"),
 	}, nil
 }
 
@@ -77,6 +91,96 @@ func (s Mail) buildBody(title string, message string) string {
 	return fmt.Sprintf("%s\n%s", strings.Join(headerTexts, "\n"), base64.StdEncoding.EncodeToString([]byte(message)))
 }
 
+// buildBodyWithAttachment builds a multipart body with the log file attached
+func (s Mail) buildBodyWithAttachment(title, message, logFile string) (string, error) {
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxAttachmentSize {
+		data = data[len(data)-maxAttachmentSize:]
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	headers := []string{
+		fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q", writer.Boundary()),
+		fmt.Sprintf("From: %s", s.from),
+		"MIME-Version: 1.0",
+		fmt.Sprintf("Subject: %s", title),
+		fmt.Sprintf("To: %s", strings.Join(s.to, ",")),
+	}
+
+	parts := []struct {
+		header  textproto.MIMEHeader
+		content []byte
+	}{
+		{
+			header: textproto.MIMEHeader{
+				"Content-Type":              {`text/plain; charset="utf-8"`},
+				"Content-Transfer-Encoding": {"base64"},
+			},
+			content: []byte(message),
+		},
+		{
+			header: textproto.MIMEHeader{
+				"Content-Type":              {`text/plain; charset="utf-8"`},
+				"Content-Transfer-Encoding": {"base64"},
+				"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", filepath.Base(logFile))},
+			},
+			content: data,
+		},
+	}
+	for _, part := range parts {
+		w, err := writer.CreatePart(part.header)
+		if err != nil {
+			return "", err
+		}
+		encoder := base64.NewEncoder(base64.StdEncoding, &lineWriter{w: w})
+		if _, err := encoder.Write(part.content); err != nil {
+			return "", err
+		}
+		if err := encoder.Close(); err != nil {
+			return "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s\n\n%s", strings.Join(headers, "\n"), body.String()), nil
+}
+
+// maxLineLength of the base64 body lines by RFC 2045
+const maxLineLength = 76
+
+// lineWriter breaks the lines by CRLF every maxLineLength bytes
+type lineWriter struct {
+	w   io.Writer
+	col int
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if lw.col == maxLineLength {
+			if _, err := lw.w.Write([]byte("\r\n")); err != nil {
+				return written, err
+			}
+			lw.col = 0
+		}
+		n := min(len(p), maxLineLength-lw.col)
+		if _, err := lw.w.Write(p[:n]); err != nil {
+			return written, err
+		}
+		lw.col += n
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
 func (s *Mail) notify(title string, message string) error {
 	var auth smtp.Auth
 	if len(s.password) == 0 {
@@ -88,6 +192,13 @@ func (s *Mail) notify(title string, message string) error {
 	// and send the email all in one step.
 	to := s.to
 	msg := s.buildBody(title, message)
+	if report := s.base.report; s.attachLog && report != nil && report.Status == "failure" && len(report.LogFile) > 0 {
+		body, err := s.buildBodyWithAttachment(title, message, report.LogFile)
+		if err != nil {
+			return fmt.Errorf("attach log: %v", err)
+		}
+		msg = body
+	}
 
 	if s.tls {
 		return s.sendByTLS(auth, []byte(msg))
@@ -1,6 +1,11 @@
 package notifier
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/longbridgeapp/assert"
@@ -45,3 +50,47 @@ func Test_Mail(t *testing.T) {
 	body := mail.buildBody("This is title", "This is body")
 	assert.Equal(t, "Content-Transfer-Encoding: base64\nContent-Type: text/plain; charset=\"utf-8\"\nFrom: from@myhost.com\nSubject: This is title\nTo: to@myhost.com,to1@myhost.com\nVGhpcyBpcyBib2R5", body)
 }
+
+func Test_Mail_buildBodyWithAttachment(t *testing.T) {
+	base := Base{
+		viper: viper.New(),
+	}
+	base.viper.Set("username", "user@myhost.com")
+	base.viper.Set("to", "to@myhost.com")
+	base.viper.Set("attach_log", true)
+
+	mail, err := NewMail(&base)
+	assert.Nil(t, err)
+	assert.True(t, mail.attachLog)
+
+	logFile := filepath.Join(t.TempDir(), "run-1.log")
+	assert.NoError(t, os.WriteFile(logFile, []byte("dump failed"), 0640))
+
+	body, err := mail.buildBodyWithAttachment("This is title", "This is body", logFile)
+	assert.NoError(t, err)
+	assert.Contains(t, body, "Content-Type: multipart/mixed; boundary=")
+	assert.Contains(t, body, "Subject: This is title\n")
+	assert.Contains(t, body, "VGhpcyBpcyBib2R5")
+	assert.Contains(t, body, `Content-Disposition: attachment; filename="run-1.log"`)
+	assert.Contains(t, body, "ZHVtcCBmYWlsZWQ=")
+
+	// Base64 lines are wrapped at 76 characters
+	assert.NoError(t, os.WriteFile(logFile, bytes.Repeat([]byte("dump failed\n"), 1000), 0640))
+	body, err = mail.buildBodyWithAttachment("This is title", "This is body", logFile)
+	assert.NoError(t, err)
+	base64Line := regexp.MustCompile(`^[A-Za-z0-9+/=]+$`)
+	lines := 0
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		assert.True(t, len(line) <= 998, line)
+		if base64Line.MatchString(line) {
+			assert.True(t, len(line) <= 76, line)
+			lines++
+		}
+	}
+	assert.True(t, lines > 100)
+	assert.Contains(t, body, "ZHVtcCBmYWlsZWQKZHVtcCBmYWlsZWQK")
+
+	_, err = mail.buildBodyWithAttachment("This is title", "This is body", "/not-exist.log")
+	assert.Error(t, err)
+}
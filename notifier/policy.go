@@ -0,0 +1,149 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/gobackup/gobackup/history"
+)
+
+const (
+	notifyOnAlways = "always"
+	notifyOnChange = "change"
+
+	digestDaily = "daily"
+)
+
+// checkPolicy validates `notify_on`, `digest` and `escalate_after` of notifier
+func (base *Base) checkPolicy() error {
+	switch notifyOn := base.viper.GetString("notify_on"); notifyOn {
+	case "", notifyOnAlways, notifyOnChange:
+	default:
+		return fmt.Errorf("notify_on: %q is not supported, use always or change", notifyOn)
+	}
+
+	switch digest := base.viper.GetString("digest"); digest {
+	case "":
+	case digestDaily:
+		// Only the recoveries are notified on change, they are not in the digest
+		if base.viper.GetString("notify_on") == notifyOnChange {
+			return fmt.Errorf("digest: daily can't be used with notify_on: change")
+		}
+	default:
+		return fmt.Errorf("digest: %q is not supported, use daily", digest)
+	}
+
+	if base.viper.GetInt("escalate_after") < 0 {
+		return fmt.Errorf("escalate_after: must be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+// shouldNotify decides whether to notify the report immediately by the policy of notifier.
+//
+//   - notify_on: change, notify the first failure and the recovery only.
+//   - escalate_after: N, notify the failure after N consecutive failures, and the recovery from them.
+//
+// The missed backup is always notified with on_failure.
+func (base *Base) shouldNotify(report Report) bool {
+	if report.Status == "missed" {
+		return base.onFailure
+	}
+
+	failed := report.Status != "success"
+	if failed && !base.onFailure {
+		return false
+	}
+	if !failed && !base.onSuccess {
+		return false
+	}
+
+	onChange := base.viper.GetString("notify_on") == notifyOnChange
+	escalateAfter := base.viper.GetInt("escalate_after")
+	if !onChange && escalateAfter <= 0 {
+		return true
+	}
+
+	threshold := max(escalateAfter, 1)
+	if failed {
+		if onChange {
+			return report.ConsecutiveFailures == threshold
+		}
+		return report.ConsecutiveFailures >= threshold
+	}
+
+	return report.PreviousFailures >= threshold
+}
+
+// digest adds the successful report to the daily digest of notifier, instead of notifying it.
+// The pending digest of the previous days is returned when the day has changed.
+func (base *Base) digest(report Report) (title, message string, ok bool, err error) {
+	title, message, ok, err = base.pendingDigest(report.Model, report.StartedAt)
+	if err != nil || ok {
+		return title, message, ok, err
+	}
+
+	since, err := history.DigestSince(report.Model, base.Name)
+	if err != nil {
+		return "", "", false, err
+	}
+	if since.IsZero() {
+		return "", "", false, history.SetDigestSince(report.Model, base.Name, report.StartedAt)
+	}
+
+	return "", "", false, nil
+}
+
+// pendingDigest returns the digest of the successful runs before the day of now, and starts the next digest from the day.
+// It's not ok when the digest is not started, or there is no successful run in it.
+func (base *Base) pendingDigest(model string, now time.Time) (title, message string, ok bool, err error) {
+	since, err := history.DigestSince(model, base.Name)
+	if err != nil {
+		return "", "", false, err
+	}
+	if since.IsZero() || sameDay(since, now) {
+		return "", "", false, nil
+	}
+
+	y, m, d := now.Local().Date()
+	until := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+
+	records, err := history.ListSince(model, since)
+	if err != nil {
+		return "", "", false, err
+	}
+	if err := history.SetDigestSince(model, base.Name, until); err != nil {
+		return "", "", false, err
+	}
+
+	var lines []string
+	for _, record := range records {
+		if record.Status != history.StatusSuccess || record.FinishedAt == nil || !record.StartedAt.Before(until) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s  %.1fs  %s",
+			record.StartedAt.Local().Format(time.DateTime),
+			record.FinishedAt.Sub(record.StartedAt).Seconds(),
+			humanize.Bytes(uint64(record.ArchiveSize)),
+		))
+	}
+	if len(lines) == 0 {
+		return "", "", false, nil
+	}
+
+	title = fmt.Sprintf("[GoBackup] Digest: Backup %s has %d successful runs", model, len(lines))
+	message = fmt.Sprintf("Backups of %s succeeded since %s:\n\n%s",
+		model, since.Local().Format(time.DateTime), strings.Join(lines, "\n"))
+	return title, message, true, nil
+}
+
+// sameDay reports whether a and b are in the same local day
+func sameDay(a, b time.Time) bool {
+	y1, m1, d1 := a.Local().Date()
+	y2, m2, d2 := b.Local().Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
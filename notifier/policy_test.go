@@ -0,0 +1,115 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func TestBase_shouldNotify(t *testing.T) {
+	base := &Base{viper: viper.New(), onSuccess: true, onFailure: true}
+	success := Report{Status: "success"}
+	recovery := Report{Status: "success", PreviousFailures: 2}
+	firstFailure := Report{Status: "failure", ConsecutiveFailures: 1}
+	thirdFailure := Report{Status: "failure", ConsecutiveFailures: 3, PreviousFailures: 2}
+
+	assert.True(t, base.shouldNotify(success))
+	assert.True(t, base.shouldNotify(thirdFailure))
+
+	base.viper.Set("notify_on", "change")
+	assert.False(t, base.shouldNotify(success))
+	assert.True(t, base.shouldNotify(recovery))
+	assert.True(t, base.shouldNotify(firstFailure))
+	assert.False(t, base.shouldNotify(thirdFailure))
+
+	base.viper.Set("notify_on", "always")
+	base.viper.Set("escalate_after", 3)
+	assert.False(t, base.shouldNotify(success))
+	assert.False(t, base.shouldNotify(recovery))
+	assert.False(t, base.shouldNotify(firstFailure))
+	assert.True(t, base.shouldNotify(thirdFailure))
+	assert.True(t, base.shouldNotify(Report{Status: "failure", ConsecutiveFailures: 4}))
+	assert.True(t, base.shouldNotify(Report{Status: "success", PreviousFailures: 4}))
+
+	base.viper.Set("notify_on", "change")
+	assert.False(t, base.shouldNotify(Report{Status: "failure", ConsecutiveFailures: 4}))
+
+	base.onFailure = false
+	assert.False(t, base.shouldNotify(thirdFailure))
+}
+
+func TestBase_checkPolicy(t *testing.T) {
+	base := &Base{viper: viper.New()}
+	assert.NoError(t, base.checkPolicy())
+
+	base.viper.Set("notify_on", "sometimes")
+	assert.EqualError(t, base.checkPolicy(), `notify_on: "sometimes" is not supported, use always or change`)
+
+	base.viper.Set("notify_on", "change")
+	base.viper.Set("digest", "weekly")
+	assert.EqualError(t, base.checkPolicy(), `digest: "weekly" is not supported, use daily`)
+
+	base.viper.Set("digest", "daily")
+	assert.EqualError(t, base.checkPolicy(), "digest: daily can't be used with notify_on: change")
+
+	base.viper.Set("notify_on", "always")
+	base.viper.Set("escalate_after", -1)
+	assert.EqualError(t, base.checkPolicy(), "escalate_after: must be greater than or equal to 0")
+}
+
+func TestBase_digest(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	defer history.Close()
+
+	base := &Base{viper: viper.New(), Name: "slack"}
+	day := time.Date(2024, 5, 10, 0, 0, 0, 0, time.Local)
+
+	// The first run starts the digest
+	_, _, ok, err := base.digest(Report{Model: "app", StartedAt: day.Add(time.Hour)})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	for i, status := range []history.Status{history.StatusSuccess, history.StatusFailure, history.StatusSuccess} {
+		startedAt := day.Add(time.Duration(i+1) * time.Hour)
+		finishedAt := startedAt.Add(90 * time.Second)
+		record := history.Record{ID: string(rune('a' + i)), Model: "app", Status: status, StartedAt: startedAt, FinishedAt: &finishedAt, ArchiveSize: 2048}
+		assert.NoError(t, history.Save(record))
+	}
+
+	_, _, ok, err = base.digest(Report{Model: "app", StartedAt: day.Add(4 * time.Hour)})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	title, message, ok, err := base.digest(Report{Model: "app", StartedAt: day.Add(25 * time.Hour)})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "[GoBackup] Digest: Backup app has 2 successful runs", title)
+	assert.Equal(t, "Backups of app succeeded since 2024-05-10 01:00:00:\n\n2024-05-10 01:00:00  90.0s  2.0 kB\n2024-05-10 03:00:00  90.0s  2.0 kB", message)
+
+	// The next digest starts from the day
+	since, err := history.DigestSince("app", "slack")
+	assert.NoError(t, err)
+	assert.True(t, since.Equal(day.Add(24*time.Hour)))
+
+	// Sent by the daily tick without the next run
+	startedAt := day.Add(26 * time.Hour)
+	finishedAt := startedAt.Add(time.Minute)
+	assert.NoError(t, history.Save(history.Record{ID: "d", Model: "app", Status: history.StatusSuccess, StartedAt: startedAt, FinishedAt: &finishedAt, ArchiveSize: 1024}))
+	_, _, ok, err = base.pendingDigest("app", day.Add(47*time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	title, message, ok, err = base.pendingDigest("app", day.Add(48*time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "[GoBackup] Digest: Backup app has 1 successful runs", title)
+	assert.Equal(t, "Backups of app succeeded since 2024-05-11 00:00:00:\n\n2024-05-11 02:00:00  60.0s  1.0 kB", message)
+
+	// Nothing pending
+	_, _, ok, err = base.pendingDigest("app", day.Add(72*time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
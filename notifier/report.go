@@ -0,0 +1,142 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/hako/durafmt"
+)
+
+// Report of a run, it's the data of title and message templates,
+// and the JSON body of webhook notifier.
+type Report struct {
+	Model      string    `json:"model"`
+	RunID      string    `json:"run_id"`
+	Status     string    `json:"status"` // success, failure, missed
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// Duration in seconds
+	Duration float64 `json:"duration"`
+	// ArchiveSize in bytes, the size before split
+	ArchiveSize int64            `json:"archive_size"`
+	Databases   []DatabaseReport `json:"databases"`
+	Storages    []StorageReport  `json:"storages"`
+	Error       string           `json:"error,omitempty"`
+	// ConsecutiveFailures is the number of failures in a row, include this run
+	ConsecutiveFailures int `json:"consecutive_failures"`
+	// PreviousFailures is the number of failures in a row before this run
+	PreviousFailures int `json:"previous_failures"`
+	// LogTail is the last lines of log of the run
+	LogTail string `json:"log_tail,omitempty"`
+	// LogFile is the log file of the run, to attach to the failure notification
+	LogFile string `json:"-"`
+}
+
+// DatabaseReport is the result of a database dump
+type DatabaseReport struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Status   string  `json:"status"` // success, failure, skipped
+	Duration float64 `json:"duration"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// StorageReport is the result of uploading to a storage
+type StorageReport struct {
+	Name  string   `json:"name"`
+	Type  string   `json:"type"`
+	Keys  []string `json:"keys"`
+	Error string   `json:"error,omitempty"`
+}
+
+var (
+	templateFuncs = template.FuncMap{
+		"bytes": func(size int64) string {
+			return humanize.Bytes(uint64(size))
+		},
+		"duration": func(seconds float64) string {
+			return durafmt.Parse(time.Duration(seconds * float64(time.Second))).LimitFirstN(2).String()
+		},
+		"join": strings.Join,
+	}
+
+	detailsTemplate = `
+Duration: {{duration .Duration}}
+{{- if .ArchiveSize}}
+Archive size: {{bytes .ArchiveSize}}
+{{- end}}
+{{- range .Databases}}
+Database {{.Name}} ({{.Type}}): {{.Status}}
+{{- end}}
+{{- range .Storages}}
+Storage {{.Name}} ({{.Type}}): {{if .Error}}failure{{else}}{{join .Keys ", "}}{{end}}
+{{- end}}`
+
+	defaultSuccessTitle   = `[GoBackup] OK: Backup {{.Model}} has successfully`
+	defaultSuccessMessage = `Backup of {{.Model}} completed successfully at {{.FinishedAt.Local}}
+` + detailsTemplate
+	defaultMissedTitle    = `[GoBackup] Missed: Backup {{.Model}} is overdue`
+	defaultMissedMessage  = `{{.Error}}`
+	defaultFailureTitle   = `[GoBackup] Err: Backup {{.Model}} has failed`
+	defaultFailureMessage = `Backup of {{.Model}} failed at {{.FinishedAt.Local}}:
+
+{{.Error}}
+` + detailsTemplate + `
+{{- if .LogTail}}
+
+Log:
+{{.LogTail}}
+{{- end}}`
+)
+
+// parseTemplate parses the title or message template
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// render the template with the report
+func render(name, text string, report Report) (string, error) {
+	tmpl, err := parseTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, report); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// titleAndMessage renders the title and message of the report,
+// with the `title_template` and `message_template` of notifier if they are set.
+func (base *Base) titleAndMessage(report Report) (title, message string, err error) {
+	titleTemplate, messageTemplate := defaultSuccessTitle, defaultSuccessMessage
+	switch report.Status {
+	case "success":
+	case "missed":
+		titleTemplate, messageTemplate = defaultMissedTitle, defaultMissedMessage
+	default:
+		titleTemplate, messageTemplate = defaultFailureTitle, defaultFailureMessage
+	}
+	if custom := base.viper.GetString("title_template"); len(custom) > 0 {
+		titleTemplate = custom
+	}
+	if custom := base.viper.GetString("message_template"); len(custom) > 0 {
+		messageTemplate = custom
+	}
+
+	if title, err = render("title_template", titleTemplate, report); err != nil {
+		return "", "", fmt.Errorf("title_template: %v", err)
+	}
+	if message, err = render("message_template", messageTemplate, report); err != nil {
+		return "", "", fmt.Errorf("message_template: %v", err)
+	}
+
+	return strings.TrimSpace(title), message, nil
+}
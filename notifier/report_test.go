@@ -0,0 +1,62 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func newTestReport(status string) Report {
+	finishedAt := time.Date(2024, 5, 10, 4, 5, 0, 0, time.UTC)
+	return Report{
+		Model:       "app",
+		RunID:       "run-1",
+		Status:      status,
+		StartedAt:   finishedAt.Add(-90 * time.Second),
+		FinishedAt:  finishedAt,
+		Duration:    90,
+		ArchiveSize: 2048,
+		Databases: []DatabaseReport{
+			{Name: "pg", Type: "postgresql", Status: "success"},
+			{Name: "redis", Type: "redis", Status: "skipped"},
+		},
+		Storages: []StorageReport{
+			{Name: "s3", Type: "s3", Keys: []string{"2024.05.10.04.05.00.tar.gz"}},
+		},
+	}
+}
+
+func TestBase_titleAndMessage(t *testing.T) {
+	base := &Base{viper: viper.New()}
+
+	title, message, err := base.titleAndMessage(newTestReport("success"))
+	assert.NoError(t, err)
+	assert.Equal(t, "[GoBackup] OK: Backup app has successfully", title)
+	assert.Contains(t, message, "Backup of app completed successfully at ")
+	assert.Contains(t, message, "Duration: 1 minute 30 seconds\nArchive size: 2.0 kB\n")
+	assert.Contains(t, message, "Database pg (postgresql): success\nDatabase redis (redis): skipped\n")
+	assert.Contains(t, message, "Storage s3 (s3): 2024.05.10.04.05.00.tar.gz")
+
+	report := newTestReport("failure")
+	report.Error = "dump pg failed"
+	report.LogTail = "[PostgreSQL] connection refused"
+	title, message, err = base.titleAndMessage(report)
+	assert.NoError(t, err)
+	assert.Equal(t, "[GoBackup] Err: Backup app has failed", title)
+	assert.Contains(t, message, ":\n\ndump pg failed\n")
+	assert.Contains(t, message, "\n\nLog:\n[PostgreSQL] connection refused")
+
+	base.viper.Set("title_template", "{{.Status}}: {{.Model}}")
+	base.viper.Set("message_template", "{{bytes .ArchiveSize}} to {{range .Storages}}{{.Name}}{{end}}")
+	title, message, err = base.titleAndMessage(report)
+	assert.NoError(t, err)
+	assert.Equal(t, "failure: app", title)
+	assert.Equal(t, "2.0 kB to s3", message)
+
+	base.viper.Set("message_template", "{{.NotExist}}")
+	_, _, err = base.titleAndMessage(report)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "message_template: ")
+}
@@ -59,7 +59,7 @@ func (s *SES) buildEmail(title, message string) *ses.SendEmailInput {
 }
 
 func (s *SES) notify(title string, message string) error {
-	logger := logger.Tag("Notifier: SES")
+	logger := logger.Tag("Notifier: SES").WithContext(s.ctx)
 
 	logger.Info("Sending notification...")
 	_, err := s.client.SendEmail(s.buildEmail(title, message))
@@ -23,9 +23,11 @@ type Webhook struct {
 	buildHeaders    func() map[string]string
 }
 
+// webhookPayload has the title, message and the fields of report of run
 type webhookPayload struct {
 	Title   string `json:"title"`
 	Message string `json:"message"`
+	*Report
 }
 
 func NewWebhook(base *Base) *Webhook {
@@ -40,6 +42,7 @@ func NewWebhook(base *Base) *Webhook {
 			return json.Marshal(webhookPayload{
 				Title:   title,
 				Message: message,
+				Report:  base.report,
 			})
 		},
 		buildHeaders: func() map[string]string {
@@ -61,7 +64,7 @@ func NewWebhook(base *Base) *Webhook {
 }
 
 func (s *Webhook) getLogger() logger.Logger {
-	return logger.Tag(fmt.Sprintf("Notifier: %s", s.Service))
+	return logger.Tag(fmt.Sprintf("Notifier: %s", s.Service)).WithContext(s.ctx)
 }
 
 func (s *Webhook) webhookURL() (string, error) {
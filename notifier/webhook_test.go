@@ -29,6 +29,13 @@ func Test_Webhook(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, `{"title":"This is title","message":"This is body"}`, string(body))
 
+	base.report = &Report{Model: "app", RunID: "run-1", Status: "failure", Error: "dump failed"}
+	s = NewWebhook(base)
+	body, err = s.buildBody("This is title", "This is body")
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `{"title":"This is title","message":"This is body","model":"app","run_id":"run-1","status":"failure",`)
+	assert.Contains(t, string(body), `"error":"dump failed"`)
+
 	headers := s.buildHeaders()
 	assert.Equal(t, "Bearer this-is-token", headers["Authorization"])
 
@@ -0,0 +1,333 @@
+package progress
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gobackup/gobackup/logger"
+)
+
+// Kind of the transfer in progress
+const (
+	// KindDump is the size of files written by the databases and archive
+	KindDump = "dump"
+	// KindCompress is the size of archive written by the compressor
+	KindCompress = "compress"
+	// KindEncrypt is the size of encrypted archive written by the encryptor
+	KindEncrypt = "encrypt"
+	// KindUpload is the bytes uploaded to a storage
+	KindUpload = "upload"
+)
+
+var (
+	// SampleInterval is the interval to sample the size of files written by the external commands
+	SampleInterval = time.Second
+	// keepFinished is the duration to keep the progress after the run is finished
+	keepFinished = time.Minute
+
+	lock sync.Mutex
+	runs = map[string]*Progress{}
+)
+
+type storageKey struct{}
+
+// Transfer is the progress of a dump, compression, encryption or upload
+type Transfer struct {
+	// Name of storage for upload
+	Name string `json:"name,omitempty"`
+	// Bytes written or uploaded
+	Bytes int64 `json:"bytes"`
+	// Total bytes, 0 if unknown
+	Total int64 `json:"total,omitempty"`
+	// Percent of Bytes in Total, 0 if Total is unknown
+	Percent float64 `json:"percent,omitempty"`
+	// Input bytes of compression, the dump size
+	Input int64 `json:"input,omitempty"`
+	// Ratio of Bytes to Input for compression, the ratio of archive written so far to the dump size
+	Ratio float64 `json:"ratio,omitempty"`
+	// Speed in bytes per second
+	Speed     float64   `json:"speed"`
+	Done      bool      `json:"done"`
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Progress of a running backup
+type Progress struct {
+	RunID     string      `json:"run_id"`
+	Model     string      `json:"model"`
+	Stage     string      `json:"stage"`
+	StartedAt time.Time   `json:"started_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	Finished  bool        `json:"finished"`
+	Dump      *Transfer   `json:"dump,omitempty"`
+	Compress  *Transfer   `json:"compress,omitempty"`
+	Encrypt   *Transfer   `json:"encrypt,omitempty"`
+	Uploads   []*Transfer `json:"uploads"`
+}
+
+// Start tracking the progress of run
+func Start(runID, model string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	now := time.Now()
+	runs[runID] = &Progress{RunID: runID, Model: model, StartedAt: now, UpdatedAt: now, Uploads: []*Transfer{}}
+}
+
+// Finish the progress of run, it's kept for a while for the watchers to get the final state
+func Finish(runID string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	p, ok := runs[runID]
+	if !ok {
+		return
+	}
+	p.Finished = true
+	p.UpdatedAt = time.Now()
+
+	time.AfterFunc(keepFinished, func() {
+		lock.Lock()
+		defer lock.Unlock()
+		if runs[runID] == p {
+			delete(runs, runID)
+		}
+	})
+}
+
+// Get returns a copy of the progress of run
+func Get(runID string) (Progress, bool) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	p, ok := runs[runID]
+	if !ok {
+		return Progress{}, false
+	}
+
+	result := *p
+	result.Dump, result.Compress, result.Encrypt = copyTransfer(p.Dump), copyTransfer(p.Compress), copyTransfer(p.Encrypt)
+	result.Uploads = make([]*Transfer, len(p.Uploads))
+	for i, upload := range p.Uploads {
+		result.Uploads[i] = copyTransfer(upload)
+	}
+	return result, true
+}
+
+func copyTransfer(t *Transfer) *Transfer {
+	if t == nil {
+		return nil
+	}
+	result := *t
+	return &result
+}
+
+// SetStage sets the current stage of the run in ctx
+func SetStage(ctx context.Context, stage string) {
+	update(ctx, func(p *Progress) {
+		p.Stage = stage
+	})
+}
+
+// WithStorage returns a copy of ctx with the storage name, for the uploads in ctx
+func WithStorage(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, storageKey{}, name)
+}
+
+// storageFrom returns the storage name in ctx
+func storageFrom(ctx context.Context) string {
+	name, _ := ctx.Value(storageKey{}).(string)
+	return name
+}
+
+// Begin a transfer of the run in ctx, the name is the storage in ctx for upload
+func Begin(ctx context.Context, kind string, total int64) {
+	name := storageFrom(ctx)
+	update(ctx, func(p *Progress) {
+		now := time.Now()
+		t := &Transfer{Total: total, StartedAt: now, UpdatedAt: now}
+		switch kind {
+		case KindDump:
+			p.Dump = t
+		case KindCompress:
+			t.Input, t.Total = total, 0
+			p.Compress = t
+		case KindEncrypt:
+			p.Encrypt = t
+		case KindUpload:
+			t.Name = name
+			for i, upload := range p.Uploads {
+				if upload.Name == name {
+					p.Uploads[i] = t
+					return
+				}
+			}
+			p.Uploads = append(p.Uploads, t)
+		}
+	})
+}
+
+// Add n bytes to the transfer
+func Add(ctx context.Context, kind string, n int64) {
+	name := storageFrom(ctx)
+	update(ctx, func(p *Progress) {
+		if t := p.transfer(kind, name); t != nil {
+			t.set(t.Bytes + n)
+		}
+	})
+}
+
+// Set the bytes of the transfer
+func Set(ctx context.Context, kind string, bytes int64) {
+	name := storageFrom(ctx)
+	update(ctx, func(p *Progress) {
+		if t := p.transfer(kind, name); t != nil {
+			t.set(bytes)
+		}
+	})
+}
+
+// End the transfer with the error if failed
+func End(ctx context.Context, kind string, err error) {
+	name := storageFrom(ctx)
+	update(ctx, func(p *Progress) {
+		t := p.transfer(kind, name)
+		if t == nil {
+			return
+		}
+		if err != nil {
+			t.Error = err.Error()
+		} else if t.Total > 0 && t.Bytes < t.Total {
+			// The storages without progress report only the end
+			t.set(t.Total)
+		}
+		t.Done = true
+		t.UpdatedAt = time.Now()
+	})
+}
+
+// Watch begins the transfer and samples the size of path, a file or a directory, until stop is called with the result
+func Watch(ctx context.Context, kind, path string, total int64) (stop func(err error)) {
+	if len(logger.FieldsFrom(ctx).RunID) == 0 {
+		return func(error) {}
+	}
+
+	Begin(ctx, kind, total)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(SampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				Set(ctx, kind, Size(path))
+			}
+		}
+	}()
+
+	return func(err error) {
+		close(done)
+		wg.Wait()
+		Set(ctx, kind, Size(path))
+		End(ctx, kind, err)
+	}
+}
+
+// NewReader returns a reader adds the bytes read to the upload of the storage in ctx
+func NewReader(ctx context.Context, r io.Reader) io.Reader {
+	return &reader{ctx: ctx, r: r}
+}
+
+type reader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		Add(r.ctx, KindUpload, int64(n))
+	}
+	return n, err
+}
+
+// Size returns the size of file, or the total size of files in directory
+func Size(path string) int64 {
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// The files may be removed during the walk
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// update the progress of the run in ctx
+func update(ctx context.Context, fn func(p *Progress)) {
+	runID := logger.FieldsFrom(ctx).RunID
+	if len(runID) == 0 {
+		return
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if p, ok := runs[runID]; ok && !p.Finished {
+		fn(p)
+		p.UpdatedAt = time.Now()
+	}
+}
+
+// transfer returns the transfer of kind, nil if not begun
+func (p *Progress) transfer(kind, name string) *Transfer {
+	switch kind {
+	case KindDump:
+		return p.Dump
+	case KindCompress:
+		return p.Compress
+	case KindEncrypt:
+		return p.Encrypt
+	case KindUpload:
+		for _, upload := range p.Uploads {
+			if upload.Name == name {
+				return upload
+			}
+		}
+	}
+	return nil
+}
+
+// set the bytes, and update the percent, ratio and speed
+func (t *Transfer) set(bytes int64) {
+	t.Bytes = bytes
+	t.UpdatedAt = time.Now()
+	if t.Total > 0 {
+		t.Percent = min(float64(t.Bytes)/float64(t.Total)*100, 100)
+	}
+	if t.Input > 0 {
+		t.Ratio = float64(t.Bytes) / float64(t.Input)
+	}
+	if elapsed := t.UpdatedAt.Sub(t.StartedAt).Seconds(); elapsed > 0 {
+		t.Speed = float64(t.Bytes) / elapsed
+	}
+}
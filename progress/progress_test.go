@@ -0,0 +1,115 @@
+package progress
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/longbridgeapp/assert"
+
+	"github.com/gobackup/gobackup/logger"
+)
+
+func TestProgress(t *testing.T) {
+	ctx := logger.NewContext(context.Background(), logger.Fields{Model: "app", RunID: "run-1"})
+	Start("run-1", "app")
+
+	SetStage(ctx, "compressor")
+	Begin(ctx, KindCompress, 1000)
+	Set(ctx, KindCompress, 250)
+
+	s3 := WithStorage(ctx, "s3")
+	Begin(s3, KindUpload, 100)
+	_, err := io.Copy(io.Discard, NewReader(s3, strings.NewReader(strings.Repeat("a", 40))))
+	assert.NoError(t, err)
+
+	local := WithStorage(ctx, "local")
+	Begin(local, KindUpload, 100)
+	End(local, KindUpload, nil)
+
+	ftp := WithStorage(ctx, "ftp")
+	Begin(ftp, KindUpload, 100)
+	End(ftp, KindUpload, errors.New("connection refused"))
+
+	p, ok := Get("run-1")
+	assert.True(t, ok)
+	assert.Equal(t, "app", p.Model)
+	assert.Equal(t, "compressor", p.Stage)
+	assert.Nil(t, p.Dump)
+	assert.Equal(t, int64(1000), p.Compress.Input)
+	assert.Equal(t, int64(250), p.Compress.Bytes)
+	assert.Equal(t, 0.25, p.Compress.Ratio)
+
+	assert.Equal(t, 3, len(p.Uploads))
+	assert.Equal(t, "s3", p.Uploads[0].Name)
+	assert.Equal(t, int64(40), p.Uploads[0].Bytes)
+	assert.Equal(t, float64(40), p.Uploads[0].Percent)
+	assert.False(t, p.Uploads[0].Done)
+	assert.Equal(t, int64(100), p.Uploads[1].Bytes)
+	assert.True(t, p.Uploads[1].Done)
+	assert.Equal(t, "connection refused", p.Uploads[2].Error)
+	assert.Equal(t, int64(0), p.Uploads[2].Bytes)
+
+	// Get returns a copy
+	p.Uploads[0].Bytes = 0
+	p, _ = Get("run-1")
+	assert.Equal(t, int64(40), p.Uploads[0].Bytes)
+
+	Finish("run-1")
+	p, ok = Get("run-1")
+	assert.True(t, ok)
+	assert.True(t, p.Finished)
+
+	// No updates after finished
+	Set(ctx, KindCompress, 500)
+	p, _ = Get("run-1")
+	assert.Equal(t, int64(250), p.Compress.Bytes)
+
+	// Outside of run
+	SetStage(context.Background(), "database")
+	_, ok = Get("")
+	assert.False(t, ok)
+}
+
+func TestWatch(t *testing.T) {
+	interval := SampleInterval
+	SampleInterval = 10 * time.Millisecond
+	defer func() {
+		SampleInterval = interval
+	}()
+
+	dir := t.TempDir()
+	ctx := logger.NewContext(context.Background(), logger.Fields{RunID: "run-2"})
+	Start("run-2", "app")
+	defer Finish("run-2")
+
+	stop := Watch(ctx, KindDump, dir, 0)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.sql"), make([]byte, 100), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b.sql"), make([]byte, 50), 0644))
+	time.Sleep(50 * time.Millisecond)
+
+	p, _ := Get("run-2")
+	assert.Equal(t, int64(150), p.Dump.Bytes)
+	assert.False(t, p.Dump.Done)
+
+	stop(nil)
+	p, _ = Get("run-2")
+	assert.True(t, p.Dump.Done)
+
+	// Nothing to do outside of run
+	Watch(context.Background(), KindDump, dir, 0)(nil)
+}
+
+func TestSize(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a"), make([]byte, 10), 0644))
+	assert.Equal(t, int64(10), Size(filepath.Join(dir, "a")))
+	assert.Equal(t, int64(10), Size(dir))
+	assert.Equal(t, int64(0), Size(filepath.Join(dir, "not-exist")))
+}
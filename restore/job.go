@@ -0,0 +1,137 @@
+package restore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
+	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/progress"
+)
+
+// logExcerptLines is the number of the last log lines kept in the run history
+const logExcerptLines = 100
+
+// ErrCanceled is the cause of the job canceled by Cancel
+var ErrCanceled = errors.New("canceled")
+
+var (
+	jobsLock sync.Mutex
+	// cancel functions of the running jobs by run ID
+	jobs = map[string]context.CancelCauseFunc{}
+)
+
+// StartVerify runs Verify in background, it returns the run ID to follow the job by the runs and progress API
+func StartVerify(model config.ModelConfig, storageName, key string) (string, error) {
+	return start(history.KindVerify, model, storageName, key, "")
+}
+
+// StartRestore runs Restore in background, it returns the run ID to follow the job by the runs and progress API
+func StartRestore(model config.ModelConfig, storageName, key, target string) (string, error) {
+	if err := checkTarget(target); err != nil {
+		return "", err
+	}
+
+	return start(history.KindRestore, model, storageName, key, filepath.Clean(target))
+}
+
+// Cancel the running job by run ID, it returns false if the job is not found
+func Cancel(id string) bool {
+	jobsLock.Lock()
+	defer jobsLock.Unlock()
+
+	cancel, ok := jobs[id]
+	if ok {
+		cancel(ErrCanceled)
+	}
+	return ok
+}
+
+// start the job in background, the invalid params are returned at once
+func start(kind history.Kind, model config.ModelConfig, storageName, key, target string) (string, error) {
+	if _, ok := model.Storages[storageName]; !ok {
+		return "", fmt.Errorf("Storage %s not found", storageName)
+	}
+	key, err := checkKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	id := uuid.NewString()
+	record := history.Record{
+		ID:        id,
+		Model:     model.Name,
+		Kind:      kind,
+		Trigger:   history.TriggerAPI,
+		Status:    history.StatusRunning,
+		StartedAt: time.Now(),
+		Storages:  []string{storageName},
+	}
+	if err := history.Save(record); err != nil {
+		return "", err
+	}
+
+	ctx := logger.NewContext(context.Background(), logger.Fields{Model: model.Name, RunID: id})
+	ctx, cancel := context.WithCancelCause(ctx)
+	jobsLock.Lock()
+	jobs[id] = cancel
+	jobsLock.Unlock()
+
+	capture := logger.StartCapture(id, logExcerptLines)
+	progress.Start(id, model.Name)
+
+	go func() {
+		defer func() {
+			jobsLock.Lock()
+			delete(jobs, id)
+			jobsLock.Unlock()
+			cancel(nil)
+		}()
+		defer progress.Finish(id)
+		defer capture.Stop()
+
+		runLogErr := logger.OpenRunLog(id, history.LogPath(id))
+		defer logger.CloseRunLog(id)
+
+		logger := logger.Tag("Restore").WithContext(ctx)
+		if runLogErr != nil {
+			logger.Errorf("Failed to open run log: %v", runLogErr)
+		}
+
+		result, err := run(ctx, model, storageName, key, target)
+		if ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
+
+		finishedAt := time.Now()
+		record.FinishedAt = &finishedAt
+		switch {
+		case err == nil:
+			record.Status = history.StatusSuccess
+			record.ArchiveSize = result.Size
+			record.Result, _ = json.Marshal(result)
+		case errors.Is(err, ErrCanceled):
+			record.Status = history.StatusCanceled
+			record.Error = err.Error()
+		default:
+			logger.Errorf("%s %s failed: %v", kind, key, err)
+			record.Status = history.StatusFailure
+			record.Error = err.Error()
+		}
+		record.Log = capture.String()
+
+		if err := history.Save(record); err != nil {
+			logger.Errorf("Failed to save run history: %v", err)
+		}
+	}()
+
+	return id, nil
+}
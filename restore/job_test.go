@@ -0,0 +1,85 @@
+package restore
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/longbridgeapp/assert"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
+	"github.com/gobackup/gobackup/progress"
+)
+
+// waitFinished waits for the job of id finished, and returns its record
+func waitFinished(t *testing.T, id string) *history.Record {
+	t.Helper()
+
+	for i := 0; i < 500; i++ {
+		record, err := history.Get(id)
+		assert.NoError(t, err)
+		if record.Status != history.StatusRunning {
+			return record
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s is not finished", id)
+	return nil
+}
+
+func TestStartVerify(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	defer history.Close()
+	model := newTestModel(t, "2024.05.10.04.05.00.tar.gz", false)
+
+	id, err := StartVerify(model, "local", "2024.05.10.04.05.00.tar.gz")
+	assert.NoError(t, err)
+	_, ok := progress.Get(id)
+	assert.True(t, ok)
+
+	record := waitFinished(t, id)
+	assert.Equal(t, history.StatusSuccess, record.Status)
+	assert.Equal(t, history.KindVerify, record.Kind)
+	assert.Equal(t, history.TriggerAPI, record.Trigger)
+	assert.Equal(t, []string{"local"}, record.Storages)
+	assert.True(t, record.ArchiveSize > 0)
+	assert.Contains(t, record.Log, "Verified 2024.05.10.04.05.00.tar.gz, 2 entries")
+
+	result := Result{}
+	assert.NoError(t, json.Unmarshal(record.Result, &result))
+	assert.Equal(t, 2, result.Entries)
+	assert.Equal(t, 64, len(result.SHA256))
+
+	// Failed in background
+	id, err = StartVerify(model, "local", "not-exist.tar.gz")
+	assert.NoError(t, err)
+	record = waitFinished(t, id)
+	assert.Equal(t, history.StatusFailure, record.Status)
+	assert.True(t, len(record.Error) > 0)
+	assert.False(t, Cancel(id))
+
+	// Invalid params are returned at once
+	_, err = StartVerify(model, "local", "../2024.05.10.04.05.00.tar.gz")
+	assert.EqualError(t, err, `invalid key: "../2024.05.10.04.05.00.tar.gz"`)
+	_, err = StartVerify(model, "s3", "2024.05.10.04.05.00.tar.gz")
+	assert.EqualError(t, err, "Storage s3 not found")
+	_, err = StartRestore(model, "local", "2024.05.10.04.05.00.tar.gz", "restore")
+	assert.EqualError(t, err, `target must be an absolute path: "restore"`)
+}
+
+func TestCancel(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	defer history.Close()
+	model := newTestModel(t, "2024.05.10.04.05.00.tar.gz", false)
+
+	id, err := StartRestore(model, "local", "2024.05.10.04.05.00.tar.gz", t.TempDir())
+	assert.NoError(t, err)
+	assert.True(t, Cancel(id))
+
+	record := waitFinished(t, id)
+	assert.Equal(t, history.StatusCanceled, record.Status)
+	assert.Equal(t, history.KindRestore, record.Kind)
+	assert.Equal(t, "canceled", record.Error)
+	assert.False(t, Cancel("not-exist"))
+}
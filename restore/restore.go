@@ -0,0 +1,189 @@
+package restore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/encryptor"
+	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/progress"
+	"github.com/gobackup/gobackup/storage"
+)
+
+// maxFiles is the max number of archive entries in result
+const maxFiles = 100
+
+// splitSuffix of the split files, e.g. 2024.05.10.04.05.00.tar.gz-000
+var splitSuffix = regexp.MustCompile(`-\d+$`)
+
+// Result of verify or restore
+type Result struct {
+	Model   string `json:"model"`
+	Storage string `json:"storage"`
+	Key     string `json:"key"`
+	// Size of the package in storage
+	Size int64 `json:"size"`
+	// SHA256 of the package, the split files are joined
+	SHA256 string `json:"sha256"`
+	// Entries is the number of files and directories in archive
+	Entries int `json:"entries"`
+	// Files are the first 100 entries in archive
+	Files []string `json:"files"`
+	// Target directory of restore
+	Target string `json:"target,omitempty"`
+	// Duration in seconds
+	Duration float64 `json:"duration"`
+}
+
+// Verify downloads the backup package from storage, decrypts it and lists the archive,
+// so that it's known to be restorable.
+func Verify(ctx context.Context, model config.ModelConfig, storageName, key string) (*Result, error) {
+	return run(ctx, model, storageName, key, "")
+}
+
+// Restore downloads the backup package from storage, decrypts it and extracts the archive into target.
+// The target must be an absolute path of a directory that is empty or not exist.
+func Restore(ctx context.Context, model config.ModelConfig, storageName, key, target string) (*Result, error) {
+	if err := checkTarget(target); err != nil {
+		return nil, err
+	}
+
+	return run(ctx, model, storageName, key, filepath.Clean(target))
+}
+
+// checkTarget checks the target is an absolute path of a directory that is empty or not exist
+func checkTarget(target string) error {
+	if !filepath.IsAbs(target) {
+		return fmt.Errorf("target must be an absolute path: %q", target)
+	}
+	if entries, err := os.ReadDir(target); err == nil && len(entries) > 0 {
+		return fmt.Errorf("target %s is not empty", target)
+	} else if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// checkKey returns the key without the leading `/`, the key out of storage is invalid
+func checkKey(key string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	if len(key) == 0 || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid key: %q", key)
+	}
+	return key, nil
+}
+
+func run(ctx context.Context, model config.ModelConfig, storageName, key, target string) (*Result, error) {
+	logger := logger.Tag("Restore").WithContext(ctx)
+	startedAt := time.Now()
+
+	key, err := checkKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp(viper.GetString("workdir"), "gobackup-restore-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	progress.SetStage(ctx, "fetch")
+	paths, err := storage.Fetch(ctx, model, storageName, key, filepath.Join(dir, "fetch"))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Model: model.Name, Storage: storageName, Key: key, Target: target, Files: []string{}}
+	archivePath, err := join(paths, dir, result)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("Fetched %s (%d bytes, sha256: %s)", key, result.Size, result.SHA256)
+
+	if strings.HasSuffix(archivePath, ".enc") {
+		progress.SetStage(ctx, "decrypt")
+		if archivePath, err = encryptor.Decrypt(ctx, archivePath, model); err != nil {
+			return nil, err
+		}
+	}
+
+	progress.SetStage(ctx, "verify")
+	output, err := helper.ExecContext(ctx, "tar", "-tf", archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("archive %s is broken: %v", key, err)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); len(line) == 0 {
+			continue
+		}
+		result.Entries++
+		if len(result.Files) < maxFiles {
+			result.Files = append(result.Files, line)
+		}
+	}
+	if result.Entries == 0 {
+		return nil, fmt.Errorf("archive %s is empty", key)
+	}
+	logger.Infof("Verified %s, %d entries", key, result.Entries)
+
+	if len(target) > 0 {
+		progress.SetStage(ctx, "extract")
+		if err := helper.MkdirP(target); err != nil {
+			return nil, err
+		}
+		if _, err := helper.ExecContext(ctx, "tar", "-xf", archivePath, "-C", target); err != nil {
+			return nil, fmt.Errorf("extract %s to %s: %v", key, target, err)
+		}
+		logger.Infof("Restored %s to %s", key, target)
+	}
+
+	result.Duration = time.Since(startedAt).Seconds()
+	return result, nil
+}
+
+// join the fetched files into the archive in dir, and sets the size and SHA256 of result
+func join(paths []string, dir string, result *Result) (string, error) {
+	name := filepath.Base(paths[0])
+	if len(paths) > 1 {
+		name = splitSuffix.ReplaceAllString(name, "")
+	}
+	archivePath := filepath.Join(dir, name)
+
+	out, err := os.Create(archivePath)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	hash := sha256.New()
+	for _, path := range paths {
+		in, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		n, err := io.Copy(io.MultiWriter(out, hash), in)
+		in.Close()
+		if err != nil {
+			return "", err
+		}
+		result.Size += n
+		// Free the space of the parts
+		os.Remove(path)
+	}
+	result.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	return archivePath, out.Close()
+}
@@ -0,0 +1,115 @@
+package restore
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
+)
+
+// newTestModel returns a model with a local storage, which has a backup package of key
+func newTestModel(t *testing.T, key string, encrypt bool) config.ModelConfig {
+	storagePath := t.TempDir()
+	source := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(source, "data"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(source, "data", "dump.sql"), []byte("select 1;"), 0644))
+
+	archivePath := filepath.Join(storagePath, key)
+	_, err := helper.Exec("tar", "-czf", archivePath, "-C", source, "data")
+	assert.NoError(t, err)
+
+	v := viper.New()
+	v.Set("path", storagePath)
+	model := config.ModelConfig{
+		Name:  "restore_test",
+		Viper: viper.New(),
+		Storages: map[string]config.SubConfig{
+			"local": {Name: "local", Type: "local", Viper: v},
+		},
+		EncryptWith: config.SubConfig{Viper: viper.New()},
+	}
+
+	if encrypt {
+		model.EncryptWith.Type = "openssl"
+		model.EncryptWith.Viper.Set("password", "gobackup-123")
+		_, err := helper.Exec("openssl", "aes-256-cbc", "-salt", "-k", "gobackup-123", "-in", archivePath, "-out", archivePath+".enc")
+		assert.NoError(t, err)
+		assert.NoError(t, os.Remove(archivePath))
+	}
+
+	return model
+}
+
+func TestVerify(t *testing.T) {
+	model := newTestModel(t, "2024.05.10.04.05.00.tar.gz", false)
+
+	result, err := Verify(context.Background(), model, "local", "/2024.05.10.04.05.00.tar.gz")
+	assert.NoError(t, err)
+	assert.Equal(t, "restore_test", result.Model)
+	assert.Equal(t, "local", result.Storage)
+	assert.Equal(t, "2024.05.10.04.05.00.tar.gz", result.Key)
+	assert.True(t, result.Size > 0)
+	assert.Equal(t, 64, len(result.SHA256))
+	assert.Equal(t, 2, result.Entries)
+	assert.Equal(t, []string{"data/", "data/dump.sql"}, result.Files)
+	assert.Equal(t, "", result.Target)
+
+	_, err = Verify(context.Background(), model, "local", "not-exist.tar.gz")
+	assert.Error(t, err)
+
+	_, err = Verify(context.Background(), model, "local", "../2024.05.10.04.05.00.tar.gz")
+	assert.EqualError(t, err, `invalid key: "../2024.05.10.04.05.00.tar.gz"`)
+}
+
+func TestRestore(t *testing.T) {
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl is not installed")
+	}
+
+	model := newTestModel(t, "2024.05.10.04.05.00.tar.gz", true)
+	target := filepath.Join(t.TempDir(), "restore")
+
+	result, err := Restore(context.Background(), model, "local", "2024.05.10.04.05.00.tar.gz.enc", target)
+	assert.NoError(t, err)
+	assert.Equal(t, target, result.Target)
+	assert.Equal(t, 2, result.Entries)
+	data, err := os.ReadFile(filepath.Join(target, "data", "dump.sql"))
+	assert.NoError(t, err)
+	assert.Equal(t, "select 1;", string(data))
+
+	_, err = Restore(context.Background(), model, "local", "2024.05.10.04.05.00.tar.gz.enc", target)
+	assert.EqualError(t, err, "target "+target+" is not empty")
+
+	_, err = Restore(context.Background(), model, "local", "2024.05.10.04.05.00.tar.gz.enc", "restore")
+	assert.EqualError(t, err, `target must be an absolute path: "restore"`)
+
+	model.EncryptWith.Viper.Set("password", "wrong")
+	_, err = Verify(context.Background(), model, "local", "2024.05.10.04.05.00.tar.gz.enc")
+	assert.Error(t, err)
+}
+
+func Test_join(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "a.tar.gz-000"), filepath.Join(dir, "a.tar.gz-001")}
+	assert.NoError(t, os.WriteFile(paths[0], []byte("hello "), 0644))
+	assert.NoError(t, os.WriteFile(paths[1], []byte("gobackup"), 0644))
+
+	result := &Result{}
+	archivePath, err := join(paths, dir, result)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "a.tar.gz"), archivePath)
+	assert.Equal(t, int64(14), result.Size)
+	assert.Equal(t, "6cc7feee0fae77018fb282212b8ddc18d96b8df59af6bc09cd06f658a860db50", result.SHA256)
+	data, _ := os.ReadFile(archivePath)
+	assert.Equal(t, "hello gobackup", string(data))
+
+	// The parts are removed
+	assert.False(t, helper.IsExistsPath(paths[0]))
+}
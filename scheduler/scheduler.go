@@ -1,8 +1,14 @@
 package scheduler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -11,12 +17,24 @@ import (
 	"github.com/fsnotify/fsnotify"
 	"github.com/go-co-op/gocron"
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/history"
+	"github.com/gobackup/gobackup/leader"
 	superlogger "github.com/gobackup/gobackup/logger"
 	"github.com/gobackup/gobackup/model"
+	"github.com/gobackup/gobackup/notifier"
 )
 
 var (
-	mycron *gocron.Scheduler
+	mycron     *gocron.Scheduler
+	mywatchdog *watchdog
+	// lock of mycron and paused
+	lock sync.Mutex
+	// paused models, they are saved in pausedPath to keep after restart
+	paused map[string]bool
+
+	// ErrNotScheduled is returned when the model is not scheduled
+	ErrNotScheduled = errors.New("model is not scheduled")
 
 	// Regex to match duration strings with extended units like "1day", "2weeks", etc.
 	extendedDurationRegex = regexp.MustCompile(`^(\d+)\s*(day|days|d|week|weeks|w|month|months)$`)
@@ -62,9 +80,14 @@ func init() {
 func Start() error {
 	logger := superlogger.Tag("Scheduler")
 
-	mycron = gocron.NewScheduler(time.Local)
+	lock.Lock()
+	defer lock.Unlock()
 
-	mu := sync.Mutex{}
+	paused = loadPaused()
+	if err := leader.Setup(config.LeaderElection); err != nil {
+		return err
+	}
+	mycron = gocron.NewScheduler(time.Local)
 
 	for _, modelConfig := range config.Models {
 		if !modelConfig.Schedule.Enabled {
@@ -75,9 +98,9 @@ func Start() error {
 
 		var scheduler *gocron.Scheduler
 		if modelConfig.Schedule.Cron != "" {
-			scheduler = mycron.Cron(modelConfig.Schedule.Cron)
+			scheduler = mycron.Tag(modelConfig.Name).Cron(modelConfig.Schedule.Cron)
 		} else {
-			scheduler = mycron.Every(modelConfig.Schedule.Every)
+			scheduler = mycron.Tag(modelConfig.Name).Every(modelConfig.Schedule.Every)
 			if len(modelConfig.Schedule.At) > 0 {
 				scheduler = scheduler.At(modelConfig.Schedule.At)
 			} else {
@@ -88,16 +111,23 @@ func Start() error {
 		}
 
 		if _, err := scheduler.Do(func(modelConfig config.ModelConfig) {
-			defer mu.Unlock()
 			logger := superlogger.Tag(fmt.Sprintf("Scheduler: %s", modelConfig.Name))
 
+			if IsPaused(modelConfig.Name) {
+				logger.Info("Paused, skip.")
+				return
+			}
+			if !leader.IsLeader() {
+				logger.Info("Not the leader, skip.")
+				return
+			}
+
 			logger.Info("Performing...")
 
 			m := model.Model{
 				Config: modelConfig,
 			}
-			mu.Lock()
-			if err := m.Perform(); err != nil {
+			if err := m.Perform(history.WithTrigger(context.Background(), history.TriggerSchedule)); err != nil {
 				logger.Errorf("Failed to perform: %s", err.Error())
 			}
 			logger.Info("Done.")
@@ -106,11 +136,31 @@ func Start() error {
 		}
 	}
 
+	// The daily digests are sent at midnight, not waiting for the next successful run
+	if _, err := mycron.Cron("0 0 * * *").Do(sendDigests); err != nil {
+		logger.Errorf("Failed to register digest job: %s", err.Error())
+	}
+
 	mycron.StartAsync()
 
+	mywatchdog = newWatchdog()
+	mywatchdog.start()
+
 	return nil
 }
 
+// sendDigests sends the pending daily digests of all models by the leader
+func sendDigests() {
+	if !leader.IsLeader() {
+		return
+	}
+
+	now := time.Now()
+	for _, modelConfig := range config.Models {
+		notifier.Digest(context.Background(), modelConfig, now)
+	}
+}
+
 func Restart() error {
 	logger := superlogger.Tag("Scheduler")
 	logger.Info("Reloading...")
@@ -119,7 +169,121 @@ func Restart() error {
 }
 
 func Stop() {
+	lock.Lock()
 	if mycron != nil {
 		mycron.Stop()
 	}
+	w := mywatchdog
+	mywatchdog = nil
+	lock.Unlock()
+
+	// The watchdog reads the paused models with lock
+	if w != nil {
+		w.close()
+	}
+}
+
+// NextRun returns the next run time of the scheduled model
+func NextRun(model string) (time.Time, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if mycron == nil {
+		return time.Time{}, ErrNotScheduled
+	}
+	jobs, err := mycron.FindJobsByTag(model)
+	if err != nil || len(jobs) == 0 {
+		return time.Time{}, ErrNotScheduled
+	}
+
+	return jobs[0].NextRun(), nil
+}
+
+// IsPaused returns true when the schedule of model is paused
+func IsPaused(model string) bool {
+	lock.Lock()
+	defer lock.Unlock()
+
+	// Not started, e.g. `gobackup perform --from-schedule`
+	if paused == nil {
+		paused = loadPaused()
+	}
+	return paused[model]
+}
+
+// Pause the schedule of model, the scheduled runs are skipped until it's resumed
+func Pause(model string) error {
+	return setPaused(model, true)
+}
+
+// Resume the paused schedule of model
+func Resume(model string) error {
+	return setPaused(model, false)
+}
+
+func setPaused(model string, value bool) error {
+	modelConfig := config.GetModelConfigByName(model)
+	if modelConfig == nil || !modelConfig.Schedule.Enabled {
+		return ErrNotScheduled
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if paused == nil {
+		paused = map[string]bool{}
+	}
+	if value {
+		paused[model] = true
+	} else {
+		delete(paused, model)
+	}
+
+	return savePaused(paused)
+}
+
+// pausedPath is the file of the paused models
+func pausedPath() string {
+	return filepath.Join(config.GoBackupDir, "paused.json")
+}
+
+func loadPaused() map[string]bool {
+	result := map[string]bool{}
+
+	data, err := os.ReadFile(pausedPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			superlogger.Tag("Scheduler").Errorf("Failed to load paused models: %v", err)
+		}
+		return result
+	}
+
+	var models []string
+	if err := json.Unmarshal(data, &models); err != nil {
+		superlogger.Tag("Scheduler").Errorf("Failed to load paused models: %v", err)
+		return result
+	}
+	for _, model := range models {
+		result[model] = true
+	}
+
+	return result
+}
+
+func savePaused(paused map[string]bool) error {
+	models := make([]string, 0, len(paused))
+	for model := range paused {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+
+	data, err := json.Marshal(models)
+	if err != nil {
+		return err
+	}
+	if err := helper.MkdirP(config.GoBackupDir); err != nil {
+		return err
+	}
+
+	return os.WriteFile(pausedPath(), data, 0660)
 }
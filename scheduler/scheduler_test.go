@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gobackup/gobackup/config"
 	"github.com/longbridgeapp/assert"
 )
 
@@ -59,3 +60,38 @@ func Test_parseDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestPause(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	models := config.Models
+	defer func() {
+		config.Models = models
+		Stop()
+	}()
+	config.Models = []config.ModelConfig{
+		{Name: "daily", Schedule: config.ScheduleConfig{Enabled: true, Cron: "5 4 * * *"}},
+		{Name: "manual"},
+	}
+
+	assert.NoError(t, Start())
+
+	next, err := NextRun("daily")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, next.Hour())
+	assert.Equal(t, 5, next.Minute())
+	_, err = NextRun("manual")
+	assert.Equal(t, ErrNotScheduled, err)
+
+	assert.Equal(t, ErrNotScheduled, Pause("manual"))
+	assert.NoError(t, Pause("daily"))
+	assert.True(t, IsPaused("daily"))
+
+	// Keep paused after restart
+	assert.NoError(t, Restart())
+	assert.True(t, IsPaused("daily"))
+
+	assert.NoError(t, Resume("daily"))
+	assert.False(t, IsPaused("daily"))
+	assert.NoError(t, Restart())
+	assert.False(t, IsPaused("daily"))
+}
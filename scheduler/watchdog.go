@@ -0,0 +1,154 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
+	"github.com/gobackup/gobackup/leader"
+	superlogger "github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/metrics"
+	"github.com/gobackup/gobackup/notifier"
+)
+
+// watchdogInterval is the interval to check the missed backups
+var watchdogInterval = time.Minute
+
+// watchdogState is kept when the scheduler restarts on reload, so the alerts are not sent again
+var watchdogState = struct {
+	// startedAt is the baseline of the models never succeeded
+	startedAt time.Time
+	// alerted is the deadline has been notified of each model
+	alerted map[string]time.Time
+}{alerted: map[string]time.Time{}}
+
+// watchdog reports the models have no successful backup in the expected time of schedule,
+// e.g. the daemon was down or the cron expression is wrong.
+type watchdog struct {
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+func newWatchdog() *watchdog {
+	if watchdogState.startedAt.IsZero() {
+		watchdogState.startedAt = time.Now()
+	}
+
+	return &watchdog{
+		stop: make(chan struct{}),
+	}
+}
+
+func (w *watchdog) start() {
+	metrics.NextExpectedTimestamp.Reset()
+
+	w.done.Add(1)
+	go func() {
+		defer w.done.Done()
+
+		ticker := time.NewTicker(watchdogInterval)
+		defer ticker.Stop()
+
+		w.check(time.Now())
+		for {
+			select {
+			case <-w.stop:
+				return
+			case now := <-ticker.C:
+				w.check(now)
+			}
+		}
+	}()
+}
+
+func (w *watchdog) close() {
+	close(w.stop)
+	w.done.Wait()
+}
+
+// check the deadline of next successful backup of each scheduled model
+func (w *watchdog) check(now time.Time) {
+	logger := superlogger.Tag("Watchdog")
+
+	// The leader runs the schedules, the missed backups are counted since it became the leader
+	if !leader.IsLeader() {
+		watchdogState.startedAt = now
+		metrics.NextExpectedTimestamp.Reset()
+		return
+	}
+
+	for _, modelConfig := range config.Models {
+		if !modelConfig.Schedule.Enabled {
+			continue
+		}
+		if IsPaused(modelConfig.Name) {
+			metrics.NextExpectedTimestamp.DeleteLabelValues(modelConfig.Name)
+			continue
+		}
+
+		lastSuccess, err := history.LastSuccess(modelConfig.Name)
+		if err != nil {
+			logger.Errorf("%s: failed to read run history: %v", modelConfig.Name, err)
+			continue
+		}
+		since := lastSuccess
+		if since.IsZero() {
+			since = watchdogState.startedAt
+		}
+
+		expected, err := nextRun(modelConfig.Schedule, since)
+		if err != nil {
+			logger.Errorf("%s: %v", modelConfig.Name, err)
+			continue
+		}
+		if expected.IsZero() {
+			continue
+		}
+
+		deadline := expected.Add(modelConfig.Schedule.Grace)
+		metrics.NextExpectedTimestamp.WithLabelValues(modelConfig.Name).Set(float64(deadline.Unix()))
+
+		if !now.After(deadline) || watchdogState.alerted[modelConfig.Name].Equal(deadline) {
+			continue
+		}
+		watchdogState.alerted[modelConfig.Name] = deadline
+
+		message := fmt.Sprintf("No successful backup of %s since %s, the next one was expected by %s (%s).",
+			modelConfig.Name, since.Local().Format(time.DateTime), deadline.Local().Format(time.DateTime), modelConfig.Schedule.String())
+		if lastSuccess.IsZero() {
+			message = fmt.Sprintf("No successful backup of %s since the daemon started at %s, the first one was expected by %s (%s).",
+				modelConfig.Name, since.Local().Format(time.DateTime), deadline.Local().Format(time.DateTime), modelConfig.Schedule.String())
+		}
+		logger.Warn(message)
+
+		ctx := superlogger.NewContext(context.Background(), superlogger.Fields{Model: modelConfig.Name, Stage: "watchdog"})
+		notifier.Missed(ctx, modelConfig, notifier.Report{
+			Model:      modelConfig.Name,
+			StartedAt:  since,
+			FinishedAt: now,
+			Error:      message,
+		})
+	}
+}
+
+// nextRun returns the next time of schedule after t, zero if it will never run
+func nextRun(schedule config.ScheduleConfig, t time.Time) (time.Time, error) {
+	if len(schedule.Cron) > 0 {
+		s, err := cron.ParseStandard(schedule.Cron)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid cron %q: %v", schedule.Cron, err)
+		}
+		return s.Next(t.In(time.Local)), nil
+	}
+
+	every, err := parseDuration(schedule.Every)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(every), nil
+}
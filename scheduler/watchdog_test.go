@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/longbridgeapp/assert"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
+	"github.com/gobackup/gobackup/metrics"
+)
+
+func Test_nextRun(t *testing.T) {
+	at := time.Date(2024, 5, 10, 4, 0, 0, 0, time.Local)
+
+	next, err := nextRun(config.ScheduleConfig{Cron: "5 4 * * *"}, at)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 10, 4, 5, 0, 0, time.Local), next)
+
+	next, err = nextRun(config.ScheduleConfig{Every: "1day", At: "04:00"}, at)
+	assert.NoError(t, err)
+	assert.Equal(t, at.Add(24*time.Hour), next)
+
+	_, err = nextRun(config.ScheduleConfig{Cron: "5 4 * *"}, at)
+	assert.Error(t, err)
+
+	// Never run
+	next, err = nextRun(config.ScheduleConfig{Cron: "0 0 30 2 *"}, at)
+	assert.NoError(t, err)
+	assert.True(t, next.IsZero())
+}
+
+func TestWatchdog_check(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	defer history.Close()
+
+	var payloads []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads = append(payloads, payload)
+		w.WriteHeader(200)
+	}))
+	defer server.Close()
+
+	webhook := viper.New()
+	webhook.Set("url", server.URL)
+	models := config.Models
+	defer func() {
+		config.Models = models
+	}()
+	config.Models = []config.ModelConfig{
+		{
+			Name:      "hourly",
+			Schedule:  config.ScheduleConfig{Enabled: true, Every: "1h", Grace: 10 * time.Minute},
+			Notifiers: map[string]config.SubConfig{"webhook": {Name: "webhook", Type: "webhook", Viper: webhook}},
+		},
+		{Name: "manual"},
+	}
+
+	watchdogState.startedAt = time.Time{}
+	watchdogState.alerted = map[string]time.Time{}
+	w := newWatchdog()
+	lastSuccess := watchdogState.startedAt.Add(-2 * time.Hour)
+	assert.NoError(t, history.Save(history.Record{ID: "run-1", Model: "hourly", Status: history.StatusSuccess, StartedAt: lastSuccess, FinishedAt: &lastSuccess}))
+
+	deadline := lastSuccess.Add(70 * time.Minute)
+	w.check(deadline)
+	assert.Equal(t, 0, len(payloads))
+	assert.Equal(t, float64(deadline.Unix()), testutil.ToFloat64(metrics.NextExpectedTimestamp.WithLabelValues("hourly")))
+
+	w.check(deadline.Add(time.Second))
+	assert.Equal(t, 1, len(payloads))
+	assert.Equal(t, "missed", payloads[0]["status"])
+	assert.Equal(t, "[GoBackup] Missed: Backup hourly is overdue", payloads[0]["title"])
+	assert.Contains(t, payloads[0]["message"], "No successful backup of hourly since ")
+
+	// Notify once for the deadline
+	w.check(deadline.Add(time.Minute))
+	assert.Equal(t, 1, len(payloads))
+
+	// Not notified again after the scheduler restarted on reload
+	startedAt := watchdogState.startedAt
+	w = newWatchdog()
+	assert.Equal(t, startedAt, watchdogState.startedAt)
+	w.check(deadline.Add(2 * time.Minute))
+	assert.Equal(t, 1, len(payloads))
+
+	// The deadline moves with the new success
+	now := time.Now()
+	assert.NoError(t, history.Save(history.Record{ID: "run-2", Model: "hourly", Status: history.StatusSuccess, StartedAt: now, FinishedAt: &now}))
+	w.check(now.Add(time.Minute))
+	assert.Equal(t, 1, len(payloads))
+	assert.Equal(t, float64(now.Add(70*time.Minute).Unix()), testutil.ToFloat64(metrics.NextExpectedTimestamp.WithLabelValues("hourly")))
+}
@@ -1,8 +1,10 @@
 package splitter
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -14,8 +16,8 @@ import (
 )
 
 // Run splitter
-func Run(archivePath string, model config.ModelConfig) (archiveDirPath string, err error) {
-	logger := logger.Tag("Splitter")
+func Run(ctx context.Context, archivePath string, model config.ModelConfig) (archiveDirPath string, err error) {
+	logger := logger.Tag("Splitter").WithContext(ctx)
 
 	splitter := model.Splitter
 	if splitter == nil {
@@ -43,7 +45,7 @@ func Run(archivePath string, model config.ModelConfig) (archiveDirPath string, e
 
 	opts := options(splitter)
 	opts = append(opts, archivePath, splitSuffix)
-	_, err = helper.Exec("split", opts...)
+	_, err = helper.ExecContext(ctx, "split", opts...)
 	if err != nil {
 		return
 	}
@@ -58,6 +60,22 @@ func Run(archivePath string, model config.ModelConfig) (archiveDirPath string, e
 	return
 }
 
+// Check split_with config of model
+func Check(model config.ModelConfig) (errs []error) {
+	if model.Splitter == nil {
+		return nil
+	}
+
+	if len(model.Splitter.GetString("chunk_size")) == 0 {
+		errs = append(errs, fmt.Errorf("split_with: chunk_size option is required"))
+	}
+	if _, err := exec.LookPath("split"); err != nil {
+		errs = append(errs, fmt.Errorf("split_with: split cannot be found in $PATH"))
+	}
+
+	return
+}
+
 func options(splitter *viper.Viper) (opts []string) {
 	bytes := splitter.GetString("chunk_size")
 	opts = append(opts, "-b", bytes)
@@ -76,9 +76,10 @@ func (s Azure) getBucketURL() string {
 }
 
 func (s *Azure) upload(fileKey string) (err error) {
-	logger := logger.Tag("Azure")
+	logger := logger.Tag("Azure").WithContext(s.ctx)
 
-	var ctx = context.Background()
+	// Abort the upload when the run is canceled, the uncommitted data is discarded
+	var ctx = s.ctx
 	var cancel context.CancelFunc
 
 	if s.timeout.Seconds() > 0 {
@@ -112,7 +113,7 @@ func (s *Azure) upload(fileKey string) (err error) {
 		}
 		defer f.Close()
 
-		progress := helper.NewProgressBar(logger, f)
+		progress := helper.NewProgressBar(s.ctx, logger, f)
 		if _, err = s.client.UploadStream(ctx, s.container, remotePath, progress.Reader, nil); err != nil {
 			return progress.Errorf("Azure upload error: %v", err)
 		}
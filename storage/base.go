@@ -1,20 +1,30 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gobackup/gobackup/config"
 	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/metrics"
+	"github.com/gobackup/gobackup/progress"
+	"github.com/gobackup/gobackup/tracing"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Base storage
 // When `archivePath` is a directory, `fileKeys` stores files in the `archivePath` with directory prefix
 type Base struct {
+	// ctx of the upload, the storage will be closed when it's done
+	ctx         context.Context
 	model       config.ModelConfig
 	archivePath string
 	fileKeys    []string
@@ -75,11 +85,12 @@ func newBase(model config.ModelConfig, archivePath string, storageConfig config.
 	}
 
 	base = Base{
+		ctx:         context.Background(),
 		model:       model,
 		archivePath: archivePath,
 		fileKeys:    keys,
 		viper:       storageConfig.Viper,
-		cycler:      &Cycler{name: cyclerName},
+		cycler:      &Cycler{ctx: context.Background(), name: cyclerName},
 	}
 
 	if base.viper != nil {
@@ -89,11 +100,13 @@ func newBase(model config.ModelConfig, archivePath string, storageConfig config.
 	return
 }
 
-func new(model config.ModelConfig, archivePath string, storageConfig config.SubConfig) (Base, Storage) {
+func new(ctx context.Context, model config.ModelConfig, archivePath string, storageConfig config.SubConfig) (Base, Storage, error) {
 	base, err := newBase(model, archivePath, storageConfig)
 	if err != nil {
 		panic(err)
 	}
+	base.ctx = ctx
+	base.cycler.ctx = ctx
 
 	var s Storage
 	switch storageConfig.Type {
@@ -138,63 +151,161 @@ func new(model config.ModelConfig, archivePath string, storageConfig config.SubC
 	case "azure":
 		s = &Azure{Base: base}
 	default:
-		logger.Errorf("[%s] storage type has not implement.", storageConfig.Type)
+		return base, nil, fmt.Errorf("[%s] storage type has not implement", storageConfig.Type)
 	}
 
-	return base, s
+	return base, s, nil
 }
 
-// run storage
-func runModel(model config.ModelConfig, archivePath string, storageConfig config.SubConfig) (err error) {
-	logger := logger.Tag("Storage")
+// Result of uploading to a storage
+type Result struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Keys of the files uploaded, relative to the path of storage
+	Keys  []string `json:"keys"`
+	Error string   `json:"error,omitempty"`
+}
+
+// run storage, returns the keys of files uploaded
+func runModel(ctx context.Context, model config.ModelConfig, archivePath string, storageConfig config.SubConfig) (keys []string, err error) {
+	logger := logger.Tag("Storage").WithContext(ctx)
 
 	newFileKey := filepath.Base(archivePath)
-	base, s := new(model, archivePath, storageConfig)
+	ctx = progress.WithStorage(ctx, storageConfig.Name)
+	base, s, err := new(ctx, model, archivePath, storageConfig)
+	if err != nil {
+		return nil, err
+	}
 
 	logger.Info("=> Storage | " + storageConfig.Type)
 	err = s.open()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer s.close()
 
+	// Close the storage to abort the upload when ctx is done,
+	// most of clients (FTP, SFTP, WebDAV...) do not support context.
+	closeOnce := sync.OnceFunc(s.close)
+	stop := context.AfterFunc(ctx, closeOnce)
+	defer func() {
+		stop()
+		closeOnce()
+	}()
+
+	size := uploadSize(archivePath, base.fileKeys)
+	tracing.SetAttributes(ctx, attribute.Int64("gobackup.storage.upload.size", size))
+	startedAt := time.Now()
+	progress.Begin(ctx, progress.KindUpload, size)
 	err = s.upload(newFileKey)
+	progress.End(ctx, progress.KindUpload, err)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		err = fmt.Errorf("upload to %s canceled: %w", storageConfig.Name, ctxErr)
+	}
+	observeUpload(model.Name, storageConfig.Name, size, time.Since(startedAt), err)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	base.cycler.run(s, newFileKey, base.fileKeys, base.keep, s.delete)
-	return nil
+	deleted := base.cycler.run(s, newFileKey, base.fileKeys, size, base.keep, s.delete)
+	metrics.RetentionDeletions.WithLabelValues(model.Name, storageConfig.Name).Add(float64(deleted))
+	tracing.SetAttributes(ctx, attribute.Int("gobackup.storage.retention.deleted", deleted))
+	observePackages(model.Name, storageConfig.Name, base.cycler)
+
+	if len(base.fileKeys) > 0 {
+		return base.fileKeys, nil
+	}
+	return []string{newFileKey}, nil
+}
+
+// observeUpload records the metrics of upload
+func observeUpload(model, storage string, size int64, duration time.Duration, err error) {
+	status := "success"
+	if err != nil {
+		status = "failure"
+		metrics.StorageUploadErrors.WithLabelValues(model, storage).Inc()
+	} else {
+		metrics.StorageUploadBytes.WithLabelValues(model, storage).Add(float64(size))
+	}
+	metrics.StorageUploadDurationSeconds.WithLabelValues(model, storage, status).Observe(duration.Seconds())
+}
+
+// observePackages records the number and size of packages in the state of cycler
+func observePackages(model, storage string, cycler *Cycler) {
+	count, size := cycler.total()
+	metrics.RemotePackages.WithLabelValues(model, storage).Set(float64(count))
+	metrics.RemotePackageBytes.WithLabelValues(model, storage).Set(float64(size))
 }
 
-// Run storage
-func Run(model config.ModelConfig, archivePath string) (err error) {
+// uploadSize returns the size of archive, or the total size of the split files
+func uploadSize(archivePath string, fileKeys []string) int64 {
+	if len(fileKeys) == 0 {
+		return progress.Size(archivePath)
+	}
+
+	var size int64
+	for _, key := range fileKeys {
+		size += progress.Size(filepath.Join(filepath.Dir(archivePath), key))
+	}
+	return size
+}
+
+// Run storage, returns the results of storages sorted by name
+func Run(ctx context.Context, model config.ModelConfig, archivePath string) (results []Result, err error) {
 	var errors []error
 
+	names := make([]string, 0, len(model.Storages))
+	for name := range model.Storages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
 	n := len(model.Storages)
-	for _, storageConfig := range model.Storages {
-		err := runModel(model, archivePath, storageConfig)
+	for _, name := range names {
+		storageConfig := model.Storages[name]
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return results, ctxErr
+		}
+
+		result := Result{Name: storageConfig.Name, Type: storageConfig.Type}
+		spanCtx, span := tracing.Start(ctx, "gobackup.storage.upload",
+			attribute.String("gobackup.storage.name", storageConfig.Name),
+			attribute.String("gobackup.storage.type", storageConfig.Type),
+		)
+		result.Keys, err = runModel(spanCtx, model, archivePath, storageConfig)
+		span.SetAttributes(attribute.StringSlice("gobackup.storage.keys", result.Keys))
+		tracing.End(span, err)
 		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
 			if n == 1 {
-				return err
+				return results, err
 			} else {
 				errors = append(errors, err)
 				continue
 			}
 		}
+		results = append(results, result)
 	}
 
 	if len(errors) != 0 {
-		return fmt.Errorf("Storage errors: %v", errors)
+		return results, fmt.Errorf("Storage errors: %v", errors)
 	}
 
-	return nil
+	return results, nil
 }
 
-// List return file list of storage
+// List return file list of the default storage
 func List(model config.ModelConfig, parent string) (items []FileItem, err error) {
-	if storageConfig, ok := model.Storages[model.DefaultStorage]; ok {
-		_, s := new(model, "", storageConfig)
+	return ListStorage(model, model.DefaultStorage, parent)
+}
+
+// ListStorage return file list of the storage of model
+func ListStorage(model config.ModelConfig, storageName, parent string) (items []FileItem, err error) {
+	if storageConfig, ok := model.Storages[storageName]; ok {
+		_, s, err := new(context.Background(), model, "", storageConfig)
+		if err != nil {
+			return nil, err
+		}
 		err = s.open()
 		if err != nil {
 			return nil, err
@@ -221,13 +332,22 @@ func List(model config.ModelConfig, parent string) (items []FileItem, err error)
 		return items, nil
 	}
 
-	return []FileItem{}, fmt.Errorf("Storage %s not found", model.DefaultStorage)
+	return []FileItem{}, fmt.Errorf("Storage %s not found", storageName)
 }
 
+// Download returns the download URL of the file in the default storage
 func Download(model config.ModelConfig, fileKey string) (string, error) {
-	if storageConfig, ok := model.Storages[model.DefaultStorage]; ok {
-		_, s := new(model, "", storageConfig)
-		err := s.open()
+	return DownloadStorage(model, model.DefaultStorage, fileKey)
+}
+
+// DownloadStorage returns the download URL of the file in the storage of model
+func DownloadStorage(model config.ModelConfig, storageName, fileKey string) (string, error) {
+	if storageConfig, ok := model.Storages[storageName]; ok {
+		_, s, err := new(context.Background(), model, "", storageConfig)
+		if err != nil {
+			return "", err
+		}
+		err = s.open()
 		if err != nil {
 			return "", err
 		}
@@ -236,5 +356,39 @@ func Download(model config.ModelConfig, fileKey string) (string, error) {
 		return s.download(fileKey)
 	}
 
-	return "", fmt.Errorf("Storage %s not found", model.DefaultStorage)
+	return "", fmt.Errorf("Storage %s not found", storageName)
+}
+
+// Delete deletes the backup package by fileKey in the storage of model, and removes it from the state of cycler.
+// The fileKey is the key of package without the `path` of storage, e.g. 2024.05.10.04.05.00.tar.gz,
+// only the packages in the state of cycler can be deleted.
+func Delete(model config.ModelConfig, storageName, fileKey string) (*Package, error) {
+	storageConfig, ok := model.Storages[storageName]
+	if !ok {
+		return nil, fmt.Errorf("Storage %s not found", storageName)
+	}
+	if err := checkKey(fileKey); err != nil {
+		return nil, err
+	}
+
+	base, s, err := new(context.Background(), model, "", storageConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	defer s.close()
+
+	pkg, err := base.cycler.remove(s, fileKey, s.delete)
+	observePackages(model.Name, storageName, base.cycler)
+	return pkg, err
+}
+
+// checkKey rejects the empty, absolute keys and the keys with `..`, which may be out of the storage
+func checkKey(fileKey string) error {
+	if len(fileKey) == 0 || path.IsAbs(fileKey) || filepath.IsAbs(fileKey) || strings.Contains(fileKey, "..") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, fileKey)
+	}
+	return nil
 }
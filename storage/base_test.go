@@ -1,10 +1,17 @@
 package storage
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/metrics"
 	"github.com/longbridgeapp/assert"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
 )
 
 func TestBase_newBase(t *testing.T) {
@@ -17,3 +24,68 @@ func TestBase_newBase(t *testing.T) {
 	assert.Equal(t, s.viper, model.Viper)
 	assert.Equal(t, s.keep, 0)
 }
+
+func TestDelete(t *testing.T) {
+	originalCyclerPath := cyclerPath
+	cyclerPath = t.TempDir()
+	defer func() { cyclerPath = originalCyclerPath }()
+
+	backupPath := t.TempDir()
+	for _, name := range []string{"a.tar.gz", "b.tar.gz", "c.tar.gz"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(backupPath, name), []byte(name), 0644))
+	}
+
+	v := viper.New()
+	v.Set("path", backupPath)
+	model := config.ModelConfig{
+		Name:     "app",
+		Storages: map[string]config.SubConfig{"local": {Name: "local", Type: "local", Viper: v}},
+	}
+
+	cycler := Cycler{name: "app_local", isLoaded: true}
+	cycler.add("a.tar.gz", nil)
+	cycler.add("b.tar.gz", nil)
+	cycler.save(filepath.Join(cyclerPath, "app_local.json"))
+
+	pkg, err := Delete(model, "local", "a.tar.gz")
+	assert.NoError(t, err)
+	assert.Equal(t, "a.tar.gz", pkg.FileKey)
+	assert.False(t, fileExists(filepath.Join(backupPath, "a.tar.gz")))
+
+	state := Cycler{name: "app_local"}
+	state.load(filepath.Join(cyclerPath, "app_local.json"))
+	assert.Equal(t, 1, len(state.packages))
+	assert.Equal(t, "b.tar.gz", state.packages[0].FileKey)
+
+	// Not in the state
+	_, err = Delete(model, "local", "c.tar.gz")
+	assert.True(t, errors.Is(err, ErrPackageNotFound))
+	assert.True(t, fileExists(filepath.Join(backupPath, "c.tar.gz")))
+
+	_, err = Delete(model, "local", "not-exist.tar.gz")
+	assert.True(t, errors.Is(err, ErrPackageNotFound))
+
+	// Out of the storage
+	outside := filepath.Join(t.TempDir(), "outside.tar.gz")
+	assert.NoError(t, os.WriteFile(outside, []byte("outside"), 0644))
+	for _, key := range []string{"", "../outside.tar.gz", "b/../../outside.tar.gz", outside} {
+		_, err = Delete(model, "local", key)
+		assert.True(t, errors.Is(err, ErrInvalidKey), key)
+	}
+	assert.True(t, fileExists(outside))
+
+	_, err = Delete(model, "s3", "b.tar.gz")
+	assert.EqualError(t, err, "Storage s3 not found")
+}
+
+func Test_observeUpload(t *testing.T) {
+	observeUpload("observe_test", "s3", 1024, 2*time.Second, nil)
+	observeUpload("observe_test", "s3", 1024, time.Second, errors.New("timeout"))
+	assert.Equal(t, float64(1024), testutil.ToFloat64(metrics.StorageUploadBytes.WithLabelValues("observe_test", "s3")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.StorageUploadErrors.WithLabelValues("observe_test", "s3")))
+
+	cycler := &Cycler{packages: PackageList{{FileKey: "a.tar.gz", Size: 100}, {FileKey: "b.tar.gz"}}}
+	observePackages("observe_test", "s3", cycler)
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.RemotePackages.WithLabelValues("observe_test", "s3")))
+	assert.Equal(t, float64(100), testutil.ToFloat64(metrics.RemotePackageBytes.WithLabelValues("observe_test", "s3")))
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/gobackup/gobackup/config"
+)
+
+var (
+	// requiredKeys of each storage type, any of the keys in a group must be present
+	requiredKeys = map[string][][]string{
+		"local":  {{"path"}},
+		"ftp":    {{"host"}},
+		"scp":    {{"host"}},
+		"sftp":   {{"host"}},
+		"webdav": {{"root"}},
+		"gcs":    {{"bucket"}},
+		"azure":  {{"account", "bucket"}, {"tenant_id"}, {"client_id"}, {"client_secret"}},
+		"s3":     {{"bucket"}, {"access_key_id"}, {"secret_access_key", "access_key_secret"}},
+	}
+)
+
+// Check storages config of model.
+// When connect is true, it also opens each storage and lists the files.
+func Check(model config.ModelConfig, connect bool) (errs []error) {
+	if _, ok := model.Storages[model.DefaultStorage]; !ok {
+		errs = append(errs, fmt.Errorf("default_storage %s not found in storages", model.DefaultStorage))
+	}
+
+	for name, storageConfig := range model.Storages {
+		_, s, err := new(context.Background(), model, "", storageConfig)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("storages.%s: %v", name, err))
+			continue
+		}
+
+		keys, ok := requiredKeys[storageConfig.Type]
+		if !ok {
+			// All S3 compatible services
+			keys = requiredKeys["s3"]
+		}
+		for _, group := range keys {
+			if !hasAnyKey(storageConfig, group) {
+				errs = append(errs, fmt.Errorf("storages.%s: %s is required", name, group[0]))
+			}
+		}
+
+		if storageConfig.Type == "local" {
+			if _, err := exec.LookPath("cp"); err != nil {
+				errs = append(errs, fmt.Errorf("storages.%s: cp cannot be found in $PATH", name))
+			}
+		}
+
+		if !connect {
+			continue
+		}
+
+		if err := s.open(); err != nil {
+			errs = append(errs, fmt.Errorf("storages.%s: open failed: %v", name, err))
+			continue
+		}
+		if _, err := s.list("/"); err != nil {
+			errs = append(errs, fmt.Errorf("storages.%s: list failed: %v", name, err))
+		}
+		s.close()
+	}
+
+	return
+}
+
+func hasAnyKey(storageConfig config.SubConfig, keys []string) bool {
+	for _, key := range keys {
+		if len(storageConfig.Viper.GetString(key)) > 0 {
+			return true
+		}
+	}
+	return false
+}
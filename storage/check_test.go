@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func newCheckStorageConfig(name, storageType string, values map[string]any) config.SubConfig {
+	v := viper.New()
+	for key, value := range values {
+		v.Set(key, value)
+	}
+	return config.SubConfig{Name: name, Type: storageType, Viper: v}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+
+	model := config.ModelConfig{
+		Name:           "test",
+		DefaultStorage: "missing",
+		Storages: map[string]config.SubConfig{
+			"local":   newCheckStorageConfig("local", "local", map[string]any{"path": dir}),
+			"s3":      newCheckStorageConfig("s3", "s3", map[string]any{"bucket": "foo", "access_key_id": "bar"}),
+			"oss":     newCheckStorageConfig("oss", "oss", map[string]any{"bucket": "foo", "access_key_id": "bar", "access_key_secret": "baz"}),
+			"dropbox": newCheckStorageConfig("dropbox", "dropbox", nil),
+		},
+	}
+
+	errs := Check(model, false)
+	messages := []string{}
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+
+	assert.Equal(t, 3, len(messages))
+	assert.Contains(t, messages, "default_storage missing not found in storages")
+	assert.Contains(t, messages, "storages.s3: secret_access_key is required")
+	assert.Contains(t, messages, "storages.dropbox: [dropbox] storage type has not implement")
+}
+
+func TestCheck_connect(t *testing.T) {
+	model := config.ModelConfig{
+		Name:           "test",
+		DefaultStorage: "local",
+		Storages: map[string]config.SubConfig{
+			"local": newCheckStorageConfig("local", "local", map[string]any{"path": t.TempDir()}),
+		},
+	}
+
+	assert.Equal(t, 0, len(Check(model, true)))
+}
@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -12,6 +15,8 @@ import (
 	"github.com/gobackup/gobackup/config"
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type PackageList []Package
@@ -21,15 +26,23 @@ type Package struct {
 	FileKey   string    `json:"file_key"`
 	FileKeys  []string  `json:"file_keys,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
+	// Size of the archive or the split files in bytes, 0 if unknown
+	Size int64 `json:"size,omitempty"`
 }
 
 var (
+	// ErrPackageNotFound is returned by Delete when the package is not in the state of cycler
+	ErrPackageNotFound = errors.New("package not found")
+	// ErrInvalidKey is returned by Delete when the key is empty, absolute or has `..`
+	ErrInvalidKey = errors.New("invalid key")
+
 	cyclerPath = filepath.Join(config.GoBackupDir, "cycler")
 	// Remote state path prefix used for storing cycler state on remote storage
 	remoteStatePath = ".gobackup-state"
 )
 
 type Cycler struct {
+	ctx      context.Context
 	name     string
 	packages PackageList
 	isLoaded bool
@@ -53,14 +66,14 @@ func (c *Cycler) shiftByKeep(keep int) (first *Package) {
 	return
 }
 
-func (c *Cycler) run(storage Storage, fileKey string, fileKeys []string, keep int, deletePackage func(fileKey string) error) {
-	logger := logger.Tag("Cycler")
-
+// run adds the uploaded package with its size, and deletes the packages out of keep, returns the number of packages deleted
+func (c *Cycler) run(storage Storage, fileKey string, fileKeys []string, size int64, keep int, deletePackage func(fileKey string) error) (deleted int) {
 	cyclerFileName := filepath.Join(cyclerPath, c.name+".json")
 	remoteStateKey := filepath.Join(remoteStatePath, c.name+".json")
 
 	c.loadRemote(storage, cyclerFileName, remoteStateKey)
 	c.add(fileKey, fileKeys)
+	c.packages[len(c.packages)-1].Size = size
 	defer c.saveRemote(storage, cyclerFileName, remoteStateKey)
 
 	if keep == 0 {
@@ -73,20 +86,92 @@ func (c *Cycler) run(storage Storage, fileKey string, fileKeys []string, keep in
 			break
 		}
 
-		fk := pkg.FileKey
-		if len(pkg.FileKeys) != 0 && !strings.HasSuffix(fk, "/") {
-			fk += "/"
-		}
-		for _, k := range append(pkg.FileKeys, fk) {
-			// deletePackage() should handle directory case which has `/` suffix
-			err := deletePackage(k)
-			if err != nil {
-				logger.Warnf("Remove %s failed: %v", k, err)
-			} else {
-				logger.Info("Removed", k)
+		c.deleteFiles(*pkg, deletePackage)
+		deleted++
+	}
+	return
+}
+
+// total returns the number of packages in state, and the total size of them
+func (c *Cycler) total() (count int, size int64) {
+	for _, p := range c.packages {
+		size += p.Size
+	}
+	return len(c.packages), size
+}
+
+// deleteFiles deletes the files of package, it returns the first error and keeps deleting the others
+func (c *Cycler) deleteFiles(pkg Package, deletePackage func(fileKey string) error) (err error) {
+	logger := logger.Tag("Cycler").WithContext(c.ctx)
+
+	_, span := tracing.Start(c.ctx, "gobackup.storage.delete",
+		attribute.String("gobackup.package.key", pkg.FileKey),
+		attribute.Int("gobackup.package.files", max(len(pkg.FileKeys), 1)),
+		attribute.Int64("gobackup.package.size", pkg.Size),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	fk := pkg.FileKey
+	if len(pkg.FileKeys) != 0 && !strings.HasSuffix(fk, "/") {
+		fk += "/"
+	}
+	for _, k := range append(pkg.FileKeys, fk) {
+		// deletePackage() should handle directory case which has `/` suffix
+		if deleteErr := deletePackage(k); deleteErr != nil {
+			logger.Warnf("Remove %s failed: %v", k, deleteErr)
+			if err == nil {
+				err = fmt.Errorf("remove %s: %v", k, deleteErr)
 			}
+		} else {
+			logger.Info("Removed", k)
+		}
+	}
+
+	return err
+}
+
+// remove deletes the package by fileKey and removes it from the state,
+// it returns ErrPackageNotFound when the package is not in the state.
+func (c *Cycler) remove(storage Storage, fileKey string, deletePackage func(fileKey string) error) (*Package, error) {
+	cyclerFileName := filepath.Join(cyclerPath, c.name+".json")
+	remoteStateKey := filepath.Join(remoteStatePath, c.name+".json")
+
+	c.loadRemote(storage, cyclerFileName, remoteStateKey)
+
+	i := c.index(fileKey)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, fileKey)
+	}
+	pkg := c.packages[i]
+	c.packages = append(c.packages[:i:i], c.packages[i+1:]...)
+	defer c.saveRemote(storage, cyclerFileName, remoteStateKey)
+
+	return &pkg, c.deleteFiles(pkg, deletePackage)
+}
+
+// find returns the package by fileKey in the state, or the package of a single file when it's not in the state
+func (c *Cycler) find(storage Storage, fileKey string) Package {
+	cyclerFileName := filepath.Join(cyclerPath, c.name+".json")
+	remoteStateKey := filepath.Join(remoteStatePath, c.name+".json")
+
+	c.loadRemote(storage, cyclerFileName, remoteStateKey)
+
+	if i := c.index(fileKey); i >= 0 {
+		return c.packages[i]
+	}
+	return Package{FileKey: fileKey}
+}
+
+// index returns the index of package by fileKey, -1 if not found
+func (c *Cycler) index(fileKey string) int {
+	for i, p := range c.packages {
+		if strings.TrimSuffix(p.FileKey, "/") == strings.TrimSuffix(fileKey, "/") {
+			return i
 		}
 	}
+	return -1
 }
 
 // loadRemote tries to load cycler state from remote storage first,
@@ -94,7 +179,7 @@ func (c *Cycler) run(storage Storage, fileKey string, fileKeys []string, keep in
 // This ensures retention policy works correctly in containerized environments
 // where local filesystem is ephemeral.
 func (c *Cycler) loadRemote(storage Storage, cyclerFileName string, remoteStateKey string) {
-	logger := logger.Tag("Cycler")
+	logger := logger.Tag("Cycler").WithContext(c.ctx)
 
 	// Load from remote storage
 	if storage == nil {
@@ -149,7 +234,7 @@ func (c *Cycler) loadRemote(storage Storage, cyclerFileName string, remoteStateK
 }
 
 func (c *Cycler) load(cyclerFileName string) {
-	logger := logger.Tag("Cycler")
+	logger := logger.Tag("Cycler").WithContext(c.ctx)
 
 	if err := helper.MkdirP(cyclerPath); err != nil {
 		logger.Errorf("Failed to mkdir cycler path %s: %v", cyclerPath, err)
@@ -180,7 +265,7 @@ func (c *Cycler) load(cyclerFileName string) {
 // Remote storage ensures persistence across container restarts.
 // Local storage provides faster access and serves as a fallback.
 func (c *Cycler) saveRemote(storage Storage, cyclerFileName string, remoteStateKey string) {
-	logger := logger.Tag("Cycler")
+	logger := logger.Tag("Cycler").WithContext(c.ctx)
 
 	if !c.isLoaded {
 		logger.Warn("Skip save cycler.json because it is not loaded")
@@ -251,7 +336,7 @@ func (c *Cycler) saveRemote(storage Storage, cyclerFileName string, remoteStateK
 }
 
 func (c *Cycler) save(cyclerFileName string) {
-	logger := logger.Tag("Cycler")
+	logger := logger.Tag("Cycler").WithContext(c.ctx)
 
 	if err := helper.MkdirP(cyclerPath); err != nil {
 		logger.Errorf("Failed to mkdir cycler path %s: %v", cyclerPath, err)
@@ -191,14 +191,18 @@ func TestCycler_run(t *testing.T) {
 	cycler.isLoaded = true
 
 	// Run with keep=2, adding a new package should trigger deletion of old1.tar.gz
-	cycler.run(nil, "new.tar.gz", []string{}, 2, deletePackage)
+	deleted := cycler.run(nil, "new.tar.gz", []string{}, 1024, 2, deletePackage)
 
 	// Should have deleted old1.tar.gz
+	assert.Equal(t, 1, deleted)
 	assert.Equal(t, 1, len(deletedFiles))
 	assert.Equal(t, "old1.tar.gz", deletedFiles[0])
 
 	// Should have 2 packages left (old2.tar.gz and new.tar.gz)
 	assert.Equal(t, 2, len(cycler.packages))
+	count, size := cycler.total()
+	assert.Equal(t, 2, count)
+	assert.Equal(t, int64(1024), size)
 }
 
 func TestCycler_run_with_directory(t *testing.T) {
@@ -227,7 +231,7 @@ func TestCycler_run_with_directory(t *testing.T) {
 	cycler.isLoaded = true
 
 	// Adding new package with keep=1 should delete the directory and its files
-	cycler.run(nil, "new.tar.gz", []string{}, 1, deletePackage)
+	cycler.run(nil, "new.tar.gz", []string{}, 0, 1, deletePackage)
 
 	// Should have deleted: file1.txt, file2.txt, and backup-dir/
 	assert.Equal(t, 3, len(deletedFiles))
@@ -255,7 +259,7 @@ func TestCycler_run_keep_zero(t *testing.T) {
 	cycler.isLoaded = true
 
 	// Run with keep=0 should not delete anything
-	cycler.run(nil, "new.tar.gz", []string{}, 0, deletePackage)
+	cycler.run(nil, "new.tar.gz", []string{}, 0, 0, deletePackage)
 
 	assert.Equal(t, 0, len(deletedFiles))
 	assert.Equal(t, 1, len(cycler.packages)) // Only new package added
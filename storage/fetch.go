@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/logger"
+)
+
+// reader is implemented by the storages can read the files directly,
+// the others are read by the download URL.
+type reader interface {
+	// read the file by fileKey, it's joined with the `path` of storage like upload
+	read(fileKey string) (io.ReadCloser, error)
+}
+
+// Fetch downloads the files of the backup package into dir, returns the local paths in order.
+// The fileKey is the key of package without the `path` of storage, e.g. 2024.05.10.04.05.00.tar.gz,
+// the split files are fetched when the package is in the state of cycler.
+func Fetch(ctx context.Context, model config.ModelConfig, storageName, fileKey, dir string) ([]string, error) {
+	logger := logger.Tag("Storage").WithContext(ctx)
+
+	storageConfig, ok := model.Storages[storageName]
+	if !ok {
+		return nil, fmt.Errorf("Storage %s not found", storageName)
+	}
+
+	base, s, err := new(ctx, model, "", storageConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	defer s.close()
+
+	pkg := base.cycler.find(s, fileKey)
+	keys := pkg.FileKeys
+	if len(keys) == 0 {
+		keys = []string{pkg.FileKey}
+	}
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		localPath := filepath.Join(dir, filepath.Base(key))
+		logger.Infof("-> Fetching %s from %s", key, storageName)
+		if err := fetchFile(ctx, s, base, key, localPath); err != nil {
+			return nil, fmt.Errorf("fetch %s from %s: %v", key, storageName, err)
+		}
+		paths = append(paths, localPath)
+	}
+
+	return paths, nil
+}
+
+// fetchFile downloads the file by key to localPath
+func fetchFile(ctx context.Context, s Storage, base Base, key, localPath string) error {
+	var body io.ReadCloser
+	if r, ok := s.(reader); ok {
+		var err error
+		if body, err = r.read(key); err != nil {
+			return err
+		}
+	} else {
+		// download() expects the full key with the `path` of storage, see Cycler.loadRemote
+		fullKey := key
+		if base.viper != nil && base.viper.GetString("path") != "" {
+			fullKey = filepath.Join(base.viper.GetString("path"), key)
+		}
+		url, err := s.download(fullKey)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return err
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return fmt.Errorf("download failed: HTTP %d", res.StatusCode)
+		}
+		body = res.Body
+	}
+	defer body.Close()
+
+	f, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, body); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+
+	"github.com/gobackup/gobackup/config"
+)
+
+func TestFetch(t *testing.T) {
+	originalCyclerPath := cyclerPath
+	cyclerPath = t.TempDir()
+	defer func() { cyclerPath = originalCyclerPath }()
+
+	storagePath := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(storagePath, "2024.05.10.04.05.00.tar.gz"), []byte("single"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(storagePath, "2024.05.11.04.05.00"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(storagePath, "2024.05.11.04.05.00", "2024.05.11.04.05.00.tar.gz-000"), []byte("part0"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(storagePath, "2024.05.11.04.05.00", "2024.05.11.04.05.00.tar.gz-001"), []byte("part1"), 0644))
+
+	cycler := Cycler{name: "fetch_test_local"}
+	cycler.add("2024.05.11.04.05.00", []string{"2024.05.11.04.05.00/2024.05.11.04.05.00.tar.gz-000", "2024.05.11.04.05.00/2024.05.11.04.05.00.tar.gz-001"})
+	cycler.isLoaded = true
+	cycler.save(filepath.Join(cyclerPath, "fetch_test_local.json"))
+
+	v := viper.New()
+	v.Set("path", storagePath)
+	model := config.ModelConfig{
+		Name: "fetch_test",
+		Storages: map[string]config.SubConfig{
+			"local": {Name: "local", Type: "local", Viper: v},
+		},
+	}
+
+	dir := t.TempDir()
+	paths, err := Fetch(context.Background(), model, "local", "2024.05.10.04.05.00.tar.gz", dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "2024.05.10.04.05.00.tar.gz")}, paths)
+	data, _ := os.ReadFile(paths[0])
+	assert.Equal(t, "single", string(data))
+
+	// Split files in order
+	paths, err = Fetch(context.Background(), model, "local", "2024.05.11.04.05.00/", dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(paths))
+	data, _ = os.ReadFile(paths[1])
+	assert.Equal(t, "part1", string(data))
+
+	_, err = Fetch(context.Background(), model, "local", "not-exist.tar.gz", dir)
+	assert.Error(t, err)
+
+	_, err = Fetch(context.Background(), model, "s3", "2024.05.10.04.05.00.tar.gz", dir)
+	assert.EqualError(t, err, "Storage s3 not found")
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"crypto/tls"
 	"fmt"
+	"io"
 	"net/textproto"
 	"os"
 	"path"
@@ -99,7 +100,7 @@ func (s *FTP) close() {
 }
 
 func (s *FTP) mkdir(rpath string) error {
-	logger := logger.Tag("FTP")
+	logger := logger.Tag("FTP").WithContext(s.ctx)
 	_, err := s.client.GetEntry(rpath)
 	logger.Debugf("GetEntry %s: %v", rpath, err)
 	if err != nil {
@@ -117,7 +118,7 @@ func (s *FTP) mkdir(rpath string) error {
 }
 
 func (s *FTP) upload(fileKey string) error {
-	logger := logger.Tag("FTP")
+	logger := logger.Tag("FTP").WithContext(s.ctx)
 	logger.Info("-> Uploading...")
 
 	var fileKeys []string
@@ -149,7 +150,7 @@ func (s *FTP) upload(fileKey string) error {
 		}
 		defer f.Close()
 
-		progress := helper.NewProgressBar(logger, f)
+		progress := helper.NewProgressBar(s.ctx, logger, f)
 		if err := s.client.Stor(remotePath, progress.Reader); err != nil {
 			return progress.Errorf("upload failed %v", err)
 		}
@@ -161,7 +162,7 @@ func (s *FTP) upload(fileKey string) error {
 }
 
 func (s *FTP) delete(fileKey string) error {
-	logger := logger.Tag("FTP")
+	logger := logger.Tag("FTP").WithContext(s.ctx)
 	remotePath := path.Join(s.path, fileKey)
 	logger.Info("-> remove", remotePath)
 	if !strings.HasSuffix(fileKey, "/") {
@@ -196,6 +197,10 @@ func (s *FTP) list(parent string) ([]FileItem, error) {
 	return items, nil
 }
 
+func (s *FTP) read(fileKey string) (io.ReadCloser, error) {
+	return s.client.Retr(path.Join(s.path, fileKey))
+}
+
 // Get FTP download URL
 func (s *FTP) download(fileKey string) (string, error) {
 	return "", fmt.Errorf("FTP download is not supported")
@@ -78,9 +78,10 @@ func (s *GCS) close() {
 }
 
 func (s *GCS) upload(fileKey string) (err error) {
-	logger := logger.Tag("GCS")
+	logger := logger.Tag("GCS").WithContext(s.ctx)
 
-	var ctx = context.Background()
+	// Abort the upload when the run is canceled, the uncommitted data is discarded
+	var ctx = s.ctx
 	var cancel context.CancelFunc
 
 	if s.timeout.Seconds() > 0 {
@@ -111,7 +112,7 @@ func (s *GCS) upload(fileKey string) (err error) {
 		}
 		defer f.Close()
 
-		progress := helper.NewProgressBar(logger, f)
+		progress := helper.NewProgressBar(s.ctx, logger, f)
 		object := s.client.Bucket(s.bucket).Object(remotePath).If(storage.Conditions{DoesNotExist: true})
 		writer := object.NewWriter(ctx)
 
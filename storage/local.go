@@ -2,6 +2,7 @@ package storage
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -28,7 +29,7 @@ func (s *Local) open() error {
 func (s *Local) close() {}
 
 func (s *Local) upload(fileKey string) (err error) {
-	logger := logger.Tag("Local")
+	logger := logger.Tag("Local").WithContext(s.ctx)
 
 	// Related path
 	if !path.IsAbs(s.path) {
@@ -41,7 +42,7 @@ func (s *Local) upload(fileKey string) (err error) {
 		logger.Errorf("failed to mkdir %q, %v", targetDir, err)
 	}
 
-	_, err = helper.Exec("cp", "-a", s.archivePath, targetPath)
+	_, err = helper.ExecContext(s.ctx, "cp", "-a", s.archivePath, targetPath)
 	if err != nil {
 		return err
 	}
@@ -79,6 +80,15 @@ func (s *Local) list(parent string) ([]FileItem, error) {
 	return items, nil
 }
 
+func (s *Local) read(fileKey string) (io.ReadCloser, error) {
+	// Related path
+	if !path.IsAbs(s.path) {
+		s.path = path.Join(s.model.WorkDir, s.path)
+	}
+
+	return os.Open(filepath.Join(s.path, fileKey))
+}
+
 func (s *Local) download(fileKey string) (string, error) {
 	return "", fmt.Errorf("Local is not support download")
 }
@@ -207,7 +207,7 @@ func (s *S3) init() {
 func (s *S3) open() (err error) {
 	s.init()
 
-	logger := logger.Tag(s.providerName())
+	logger := logger.Tag(s.providerName()).WithContext(s.ctx)
 
 	cfg := aws.NewConfig()
 	endpoint := s.viper.GetString("endpoint")
@@ -261,7 +261,7 @@ func (s *S3) close() {
 }
 
 func (s *S3) upload(fileKey string) (err error) {
-	logger := logger.Tag(s.providerName())
+	logger := logger.Tag(s.providerName()).WithContext(s.ctx)
 
 	var fileKeys []string
 	if len(s.fileKeys) != 0 {
@@ -284,7 +284,7 @@ func (s *S3) upload(fileKey string) (err error) {
 		}
 		defer f.Close()
 
-		progress := helper.NewProgressBar(logger, f)
+		progress := helper.NewProgressBar(s.ctx, logger, f)
 
 		input := &s3manager.UploadInput{
 			Bucket: aws.String(s.bucket),
@@ -299,7 +299,7 @@ func (s *S3) upload(fileKey string) (err error) {
 			input.StorageClass = aws.String(s.storageClass)
 		}
 
-		result, err := s.client.Upload(input, func(uploader *s3manager.Uploader) {
+		result, err := s.client.UploadWithContext(s.ctx, input, func(uploader *s3manager.Uploader) {
 			// set the part size as low as possible to avoid timeouts and aborts
 			// also set concurrency to 1 for the same reason
 			var partSize int64 = 64 * 1024 * 1024 // 64MiB
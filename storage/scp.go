@@ -1,7 +1,6 @@
 package storage
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"os/user"
@@ -116,7 +115,7 @@ func (s *SCP) close() {
 }
 
 func (s *SCP) upload(fileKey string) error {
-	logger := logger.Tag("SCP")
+	logger := logger.Tag("SCP").WithContext(s.ctx)
 
 	var fileKeys []string
 	if len(s.fileKeys) != 0 {
@@ -149,7 +148,7 @@ func (s *SCP) upload(fileKey string) error {
 }
 
 func (s *SCP) up(localPath, remotePath string) error {
-	logger := logger.Tag("SCP")
+	logger := logger.Tag("SCP").WithContext(s.ctx)
 
 	client, err := scp.NewClientBySSH(s.client)
 	if err != nil {
@@ -166,8 +165,8 @@ func (s *SCP) up(localPath, remotePath string) error {
 	}
 	defer file.Close()
 
-	progress := helper.NewProgressBar(logger, file)
-	if err := client.CopyFile(context.Background(), progress.Reader, remotePath, "0644"); err != nil {
+	progress := helper.NewProgressBar(s.ctx, logger, file)
+	if err := client.CopyFile(s.ctx, progress.Reader, remotePath, "0644"); err != nil {
 		return progress.Errorf("store %s failed: %v", remotePath, err)
 	}
 	progress.Done(remotePath)
@@ -176,7 +175,7 @@ func (s *SCP) up(localPath, remotePath string) error {
 }
 
 func (s *SCP) delete(fileKey string) (err error) {
-	logger := logger.Tag("SCP")
+	logger := logger.Tag("SCP").WithContext(s.ctx)
 
 	remotePath := path.Join(s.path, fileKey)
 	logger.Info("-> remove", remotePath)
@@ -86,7 +86,7 @@ func (s *SFTP) close() {
 }
 
 func (s *SFTP) upload(fileKey string) error {
-	logger := logger.Tag("SFTP")
+	logger := logger.Tag("SFTP").WithContext(s.ctx)
 
 	var fileKeys []string
 	if len(s.fileKeys) != 0 {
@@ -121,7 +121,7 @@ func (s *SFTP) upload(fileKey string) error {
 }
 
 func (s *SFTP) up(localPath, remotePath string) error {
-	logger := logger.Tag("SFTP")
+	logger := logger.Tag("SFTP").WithContext(s.ctx)
 
 	file, err := os.Open(localPath)
 	if err != nil {
@@ -148,7 +148,7 @@ func (s *SFTP) up(localPath, remotePath string) error {
 }
 
 func (s *SFTP) delete(fileKey string) error {
-	logger := logger.Tag("SFTP")
+	logger := logger.Tag("SFTP").WithContext(s.ctx)
 
 	remotePath := path.Join(s.path, fileKey)
 	logger.Info("-> remove", remotePath)
@@ -159,6 +159,10 @@ func (s *SFTP) delete(fileKey string) error {
 	return nil
 }
 
+func (s *SFTP) read(fileKey string) (io.ReadCloser, error) {
+	return s.client.Open(path.Join(s.path, fileKey))
+}
+
 func (s *SFTP) list(parent string) ([]FileItem, error) {
 	remotePath := path.Join(s.path, parent)
 	var items []FileItem
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
@@ -57,7 +58,7 @@ func (s *WebDAV) open() error {
 func (s *WebDAV) close() {}
 
 func (s *WebDAV) upload(fileKey string) error {
-	logger := logger.Tag("WebDAV")
+	logger := logger.Tag("WebDAV").WithContext(s.ctx)
 	logger.Info("-> Uploading...")
 
 	var fileKeys []string
@@ -89,7 +90,7 @@ func (s *WebDAV) upload(fileKey string) error {
 		}
 		defer f.Close()
 
-		progress := helper.NewProgressBar(logger, f)
+		progress := helper.NewProgressBar(s.ctx, logger, f)
 		if err := s.client.WriteStream(remotePath, progress.Reader, 0644); err != nil {
 			return progress.Errorf("upload failed %v", err)
 		}
@@ -101,12 +102,16 @@ func (s *WebDAV) upload(fileKey string) error {
 }
 
 func (s *WebDAV) delete(fileKey string) error {
-	logger := logger.Tag("WebDAV")
+	logger := logger.Tag("WebDAV").WithContext(s.ctx)
 	remotePath := path.Join(s.path, fileKey)
 	logger.Info("-> remove", remotePath)
 	return s.client.Remove(remotePath)
 }
 
+func (s *WebDAV) read(fileKey string) (io.ReadCloser, error) {
+	return s.client.ReadStream(path.Join(s.path, fileKey))
+}
+
 // List all files from storage
 func (s *WebDAV) list(parent string) ([]FileItem, error) {
 	remotePath := filepath.Join(s.path, parent)
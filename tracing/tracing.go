@@ -0,0 +1,203 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/gobackup/gobackup/logger"
+)
+
+// Types of exporter
+const (
+	// ExporterOTLPGRPC exports traces by OTLP over gRPC, default endpoint: localhost:4317
+	ExporterOTLPGRPC = "otlp_grpc"
+	// ExporterOTLPHTTP exports traces by OTLP over HTTP, default endpoint: localhost:4318
+	ExporterOTLPHTTP = "otlp_http"
+	// ExporterStdout writes traces to stdout as JSON, for testing
+	ExporterStdout = "stdout"
+)
+
+// instrumentationName of the tracer
+const instrumentationName = "github.com/gobackup/gobackup"
+
+// shutdownTimeout is the max time to flush the spans when the exporter is replaced or GoBackup exits
+const shutdownTimeout = 10 * time.Second
+
+var (
+	lock     sync.Mutex
+	current  Exporter
+	provider *sdktrace.TracerProvider
+
+	// Version of GoBackup, the service.version of traces
+	Version = "master"
+
+	// stdout of the stdout exporter
+	stdout io.Writer = os.Stdout
+)
+
+// Exporter of traces, tracing is disabled when Type is empty
+type Exporter struct {
+	// Type of exporter: otlp_grpc, otlp_http, stdout
+	Type string
+	// Endpoint of OTLP, host:port, the OTEL_EXPORTER_OTLP_* env is used when it's empty
+	Endpoint string
+	// Insecure to export OTLP without TLS
+	Insecure bool
+	// Headers of OTLP requests, e.g. authorization
+	Headers map[string]string
+	// ServiceName of traces, default: gobackup
+	ServiceName string
+}
+
+// Setup replaces the global tracer provider by the exporter, the previous one is flushed and shutdown.
+// It does nothing when the exporter is not changed.
+func Setup(exporter Exporter) error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if provider != nil && reflect.DeepEqual(exporter, current) {
+		return nil
+	}
+	if provider == nil && len(exporter.Type) == 0 {
+		return nil
+	}
+
+	var next *sdktrace.TracerProvider
+	if len(exporter.Type) > 0 {
+		spanExporter, err := newSpanExporter(exporter)
+		if err != nil {
+			return err
+		}
+
+		serviceName := exporter.ServiceName
+		if len(serviceName) == 0 {
+			serviceName = "gobackup"
+		}
+		res := resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(serviceName),
+			semconv.ServiceVersion(Version),
+		)
+		next = sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(spanExporter),
+			sdktrace.WithResource(res),
+		)
+	}
+
+	if provider != nil {
+		shutdown(provider)
+	}
+	provider, current = next, exporter
+	if provider == nil {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		logger.Tag("Tracing").Info("Tracing disabled")
+		return nil
+	}
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	logger.Tag("Tracing").Infof("Export traces by %s", exporter.Type)
+	return nil
+}
+
+func newSpanExporter(exporter Exporter) (sdktrace.SpanExporter, error) {
+	ctx := context.Background()
+
+	switch exporter.Type {
+	case ExporterOTLPGRPC:
+		opts := []otlptracegrpc.Option{}
+		if len(exporter.Endpoint) > 0 {
+			opts = append(opts, otlptracegrpc.WithEndpoint(exporter.Endpoint))
+		}
+		if exporter.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(exporter.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(exporter.Headers))
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP:
+		opts := []otlptracehttp.Option{}
+		if len(exporter.Endpoint) > 0 {
+			opts = append(opts, otlptracehttp.WithEndpoint(exporter.Endpoint))
+		}
+		if exporter.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(exporter.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(exporter.Headers))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(stdout))
+	default:
+		return nil, fmt.Errorf("unsupported exporter: %s", exporter.Type)
+	}
+}
+
+// Shutdown flushes the spans and shutdown the exporter, it should be called before GoBackup exits
+func Shutdown() {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if provider != nil {
+		shutdown(provider)
+		provider, current = nil, Exporter{}
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	}
+}
+
+func shutdown(provider *sdktrace.TracerProvider) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := provider.Shutdown(ctx); err != nil {
+		logger.Tag("Tracing").Errorf("Failed to flush traces: %v", err)
+	}
+}
+
+// Start a span in ctx, it's a no-op span when tracing is disabled
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.GetTracerProvider().Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End the span with the status of err
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "")
+	}
+	span.End()
+}
+
+// SetAttributes sets the attributes of the span in ctx
+func SetAttributes(ctx context.Context, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
+
+// AddEvent adds an event to the span in ctx
+func AddEvent(ctx context.Context, name string, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).AddEvent(name, trace.WithAttributes(attrs...))
+}
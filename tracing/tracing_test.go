@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestSetup(t *testing.T) {
+	var buf bytes.Buffer
+	stdout = &buf
+	defer Shutdown()
+
+	assert.NoError(t, Setup(Exporter{Type: ExporterStdout, ServiceName: "backup-test"}))
+	first := provider
+	// Not changed
+	assert.NoError(t, Setup(Exporter{Type: ExporterStdout, ServiceName: "backup-test"}))
+	assert.Equal(t, first, provider)
+
+	ctx, span := Start(context.Background(), "gobackup.perform", attribute.String("gobackup.model", "app"))
+	_, child := Start(ctx, "gobackup.database.dump")
+	AddEvent(ctx, "exec", attribute.Int("process.exit.code", 2))
+	End(child, errors.New("mysqldump: access denied"))
+	SetAttributes(ctx, attribute.Int64("gobackup.archive.size", 1024))
+	End(span, nil)
+
+	Shutdown()
+	assert.Nil(t, provider)
+
+	out := buf.String()
+	assert.Contains(t, out, `"Name":"gobackup.perform"`)
+	assert.Contains(t, out, `"Name":"gobackup.database.dump"`)
+	assert.Contains(t, out, `"Value":"backup-test"`)
+	assert.Contains(t, out, `"Key":"gobackup.archive.size"`)
+	assert.Contains(t, out, `"Description":"mysqldump: access denied"`)
+	assert.Contains(t, out, `"Name":"exec"`)
+
+	// Disabled
+	buf.Reset()
+	assert.NoError(t, Setup(Exporter{}))
+	_, span = Start(nil, "gobackup.perform") //nolint:staticcheck
+	End(span, nil)
+	assert.False(t, span.SpanContext().IsValid())
+	assert.Equal(t, "", buf.String())
+}
+
+func TestSetup_unsupported(t *testing.T) {
+	err := Setup(Exporter{Type: "zipkin"})
+	assert.EqualError(t, err, "unsupported exporter: zipkin")
+	assert.Nil(t, provider)
+}
+
+func TestSetup_otlp(t *testing.T) {
+	defer Shutdown()
+
+	// The exporters connect lazily
+	assert.NoError(t, Setup(Exporter{Type: ExporterOTLPHTTP, Endpoint: "127.0.0.1:4318", Insecure: true, Headers: map[string]string{"authorization": "token"}}))
+	assert.NotNil(t, provider)
+	assert.NoError(t, Setup(Exporter{Type: ExporterOTLPGRPC, Endpoint: "127.0.0.1:4317", Insecure: true}))
+	assert.Equal(t, ExporterOTLPGRPC, current.Type)
+}
@@ -2,22 +2,28 @@ package web
 
 import (
 	"bufio"
+	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/static"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/stoicperlman/fls"
 
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
 	"github.com/gobackup/gobackup/logger"
 	"github.com/gobackup/gobackup/model"
+	"github.com/gobackup/gobackup/progress"
+	"github.com/gobackup/gobackup/restore"
 	"github.com/gobackup/gobackup/storage"
 
 	// Register Prometheus metrics
@@ -26,7 +32,6 @@ import (
 
 //go:embed dist
 var staticFS embed.FS
-var logFile *os.File
 
 type embedFileSystem struct {
 	http.FileSystem
@@ -52,17 +57,15 @@ func (e embedFileSystem) Exists(prefix string, path string) bool {
 func StartHTTP(version string) (err error) {
 	logger := logger.Tag("API")
 
-	if len(config.Web.Password) == 0 {
-		logger.Warn("You are running with insecure API server. Please don't forget setup `web.password` in config file for more safety.")
+	if !config.Web.AuthEnabled() {
+		logger.Warn("You are running with insecure API server. Please don't forget setup `web.users` or `web.tokens` in config file for more safety.")
 	}
 
-	logFile, err = os.Open(config.LogFilePath)
-	if err != nil {
-		return err
+	scheme := "http"
+	if config.Web.TLS != nil {
+		scheme = "https"
 	}
-	defer logFile.Close()
-
-	logger.Infof("Starting API server on port http://%s:%s", config.Web.Host, config.Web.Port)
+	logger.Infof("Starting API server on port %s://%s:%s", scheme, config.Web.Host, config.Web.Port)
 
 	if os.Getenv("GO_ENV") == "dev" {
 		go func() {
@@ -77,13 +80,7 @@ func StartHTTP(version string) (err error) {
 
 	r := setupRouter(version)
 
-	// Enable baseAuth
-	if len(config.Web.Username) > 0 && len(config.Web.Password) > 0 {
-		r.Use(gin.BasicAuth(gin.Accounts{
-			config.Web.Username: config.Web.Password,
-		}))
-	}
-
+	// The web UI is after the authenticate middleware too
 	fe, _ := fs.Sub(staticFS, "dist")
 	embedFs := embedFileSystem{http.FS(fe), true}
 	r.Use(static.Serve("/", embedFs))
@@ -91,21 +88,22 @@ func StartHTTP(version string) (err error) {
 		c.FileFromFS("/", embedFs)
 	})
 
-	return r.Run(config.Web.Host + ":" + config.Web.Port)
+	server, err := newServer(config.Web.Host+":"+config.Web.Port, r)
+	if err != nil {
+		return err
+	}
+	if server.TLSConfig != nil {
+		// The certificate is served by TLSConfig
+		return server.ListenAndServeTLS("", "")
+	}
+	return server.ListenAndServe()
 }
 
 func setupRouter(version string) *gin.Engine {
 	r := gin.Default()
 
-	r.GET("/status", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"message": "GoBackup is running.",
-			"version": version,
-		})
-	})
-
-	// Prometheus metrics endpoint
-	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	// All the routes below require authentication
+	r.Use(authenticate)
 
 	r.Use(func(c *gin.Context) {
 		c.Next()
@@ -121,19 +119,201 @@ func setupRouter(version string) *gin.Engine {
 
 	})
 
+	setupAuthRouter(r)
+
+	r.GET("/status", require(config.ScopeRead), func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"message": "GoBackup is running.",
+			"version": version,
+		})
+	})
+
+	// Prometheus metrics endpoint
+	r.GET("/metrics", requireAll(config.ScopeRead), gin.WrapH(promhttp.Handler()))
+
 	group := r.Group("/api")
-	group.GET("/config", getConfig)
-	group.GET("/list", list)
-	group.GET("/download", download)
-	group.POST("/perform", perform)
-	group.GET("/log", log)
+	group.GET("/config", require(config.ScopeRead), getConfig)
+	group.GET("/list", require(config.ScopeRead), list)
+	group.GET("/download", require(config.ScopeDownload), download)
+	group.POST("/perform", require(config.ScopePerform), perform)
+	group.GET("/log", requireAll(config.ScopeRead), log)
+	group.GET("/schema", require(config.ScopeRead), schema)
+	group.GET("/runs", require(config.ScopeRead), listRuns)
+	group.GET("/runs/:id", require(config.ScopeRead), getRun)
+	group.GET("/runs/:id/log", require(config.ScopeRead), getRunLog)
+	group.GET("/runs/:id/progress", require(config.ScopeRead), getRunProgress)
+	group.GET("/runs/:id/progress/stream", require(config.ScopeRead), streamRunProgress)
+	group.DELETE("/runs/:id", require(config.ScopePerform), cancelRun)
+
+	setupRouterV1(r)
 	return r
 }
 
+// GET /api/runs?model=xxx&limit=50
+func listRuns(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		c.AbortWithError(400, fmt.Errorf("Invalid limit: %s", c.Query("limit")))
+		return
+	}
+
+	records, err := history.List(c.Query("model"), history.Kind(c.Query("kind")), limit)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+
+	user := currentUser(c)
+	runs := []history.Record{}
+	for _, record := range records {
+		if user.CanAccess(record.Model) {
+			runs = append(runs, record)
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"runs": runs,
+	})
+}
+
+// GET /api/runs/:id
+func getRun(c *gin.Context) {
+	record := findRun(c)
+	if record == nil {
+		return
+	}
+
+	c.JSON(200, record)
+}
+
+// findRun returns the run in path that the user can access, or aborts with 404
+func findRun(c *gin.Context) *history.Record {
+	id := c.Param("id")
+	record, err := history.Get(id)
+	if errors.Is(err, history.ErrNotFound) || (err == nil && !currentUser(c).CanAccess(record.Model)) {
+		c.AbortWithError(404, fmt.Errorf("Run: \"%s\" not found", id))
+		return nil
+	}
+	if err != nil {
+		c.AbortWithError(500, err)
+		return nil
+	}
+
+	return record
+}
+
+// GET /api/runs/:id/progress
+func getRunProgress(c *gin.Context) {
+	record := findRun(c)
+	if record == nil {
+		return
+	}
+
+	p, ok := progress.Get(record.ID)
+	if !ok {
+		c.AbortWithError(404, fmt.Errorf("Run: \"%s\" is not running", record.ID))
+		return
+	}
+
+	c.JSON(200, p)
+}
+
+// GET /api/runs/:id/progress/stream
+//
+// Server-Sent Events of the progress, a `progress` event when it's changed,
+// and a `done` event with the run when it's finished.
+func streamRunProgress(c *gin.Context) {
+	record := findRun(c)
+	if record == nil {
+		return
+	}
+
+	ticker := time.NewTicker(progress.SampleInterval)
+	defer ticker.Stop()
+
+	var updatedAt time.Time
+	c.Stream(func(w io.Writer) bool {
+		p, ok := progress.Get(record.ID)
+		if ok && !p.UpdatedAt.Equal(updatedAt) {
+			updatedAt = p.UpdatedAt
+			c.SSEvent("progress", p)
+		}
+		if !ok || p.Finished {
+			if finished, err := history.Get(record.ID); err == nil {
+				record = finished
+			}
+			c.SSEvent("done", record)
+			return false
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-ticker.C:
+			return true
+		}
+	})
+}
+
+// DELETE /api/runs/:id
+func cancelRun(c *gin.Context) {
+	id := c.Param("id")
+	for _, r := range model.Runs() {
+		if r.ID == id && !authorizeModel(c, r.Model) {
+			return
+		}
+	}
+	if !model.CancelRun(id) && !cancelJob(c, id) {
+		if !c.IsAborted() {
+			c.AbortWithError(404, fmt.Errorf("Run: \"%s\" not found", id))
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{"message": fmt.Sprintf("Run: %s is canceling.", id)})
+}
+
+// cancelJob cancels the verify or restore running in background, if the user can access its model
+func cancelJob(c *gin.Context, id string) bool {
+	record, err := history.Get(id)
+	if err != nil || record.Kind == history.KindBackup {
+		return false
+	}
+	if !authorizeModel(c, record.Model) {
+		return false
+	}
+	return restore.Cancel(id)
+}
+
+// GET /api/runs/:id/log
+func getRunLog(c *gin.Context) {
+	id := c.Param("id")
+	if findRun(c) == nil {
+		return
+	}
+
+	logPath := history.LogPath(id)
+	if _, err := os.Stat(logPath); err != nil {
+		c.AbortWithError(404, fmt.Errorf("Log of run: \"%s\" not found", id))
+		return
+	}
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.File(logPath)
+}
+
+// GET /api/schema
+func schema(c *gin.Context) {
+	c.JSON(200, config.Schema())
+}
+
 // GET /api/config
 func getConfig(c *gin.Context) {
 	models := map[string]any{}
 	for _, m := range model.GetModels() {
+		if !currentUser(c).CanAccess(m.Config.Name) {
+			continue
+		}
 		models[m.Config.Name] = gin.H{
 			"description":   m.Config.Description,
 			"schedule":      m.Config.Schedule,
@@ -157,6 +337,9 @@ func perform(c *gin.Context) {
 		logger.Errorf("Bind error: %v", err)
 	}
 
+	if !authorizeModel(c, param.Model) {
+		return
+	}
 	m := model.GetModelByName(param.Model)
 	if m == nil {
 		c.AbortWithError(404, fmt.Errorf("Model: \"%s\" not found", param.Model))
@@ -164,7 +347,7 @@ func perform(c *gin.Context) {
 	}
 
 	go func() {
-		if err := m.Perform(); err != nil {
+		if err := m.Perform(history.WithTrigger(context.Background(), history.TriggerAPI)); err != nil {
 			logger.Errorf("Perform error: %v", err)
 		}
 	}()
@@ -221,49 +404,132 @@ func download(c *gin.Context) {
 // GET /api/log
 func log(c *gin.Context) {
 	// https://github.com/gin-gonic/examples/blob/master/realtime-chat/main.go#L27
-	chanStream := tailFile()
-	clientGone := c.Request.Context().Done()
+	chanStream, err := tailFile(c.Request.Context(), config.LogFilePath)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
 
 	c.Stream(func(w io.Writer) bool {
-		select {
-		case <-clientGone:
+		msg, ok := <-chanStream
+		if !ok {
 			println("Client gone, close stream.")
 			return false
-		case msg := <-chanStream:
-			if os.Getenv("GO_ENV") == "dev" {
-				println(msg)
-			}
+		}
 
-			if _, err := c.Writer.WriteString(msg + "\n"); err != nil {
-				logger.Errorf("Failed to write to stream: %v", err)
-			}
-			c.Writer.Flush()
-			return true
+		if os.Getenv("GO_ENV") == "dev" {
+			println(msg)
+		}
+
+		if _, err := c.Writer.WriteString(msg + "\n"); err != nil {
+			logger.Errorf("Failed to write to stream: %v", err)
 		}
+		c.Writer.Flush()
+		return true
 	})
 }
 
-// tailFile tail the log file and make a chain to stream output log
-func tailFile() chan string {
-	out_chan := make(chan string)
-
-	file := fls.LineFile(logFile)
-	if _, err := file.SeekLine(-50, io.SeekEnd); err != nil {
+// tailFile tails the last 50 lines and the new lines of the log file, until ctx is done.
+// It follows the new log file when the log file is rotated.
+func tailFile(ctx context.Context, path string) (chan string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := seekLastLines(file, 50); err != nil {
 		logger.Errorf("Failed to seek log file: %v", err)
 	}
-	bf := bufio.NewReader(file)
 
+	outChan := make(chan string)
 	go func() {
+		defer close(outChan)
+		defer func() {
+			file.Close()
+		}()
+
+		bf := bufio.NewReader(file)
+		pending := ""
 		for {
-			line, _, _ := bf.ReadLine()
+			line, err := bf.ReadString('\n')
+			pending += line
+			if err == nil {
+				select {
+				case outChan <- strings.TrimSuffix(pending, "\n"):
+					pending = ""
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
 
-			if len(line) == 0 {
-				time.Sleep(50 * time.Millisecond)
-			} else {
-				out_chan <- string(line)
+			// Reached the end, reopen the log file if it's rotated
+			if rotated(file, path) {
+				if newFile, err := os.Open(path); err == nil {
+					file.Close()
+					file = newFile
+					bf.Reset(file)
+					pending = ""
+				}
+			}
+
+			select {
+			case <-time.After(50 * time.Millisecond):
+			case <-ctx.Done():
+				return
 			}
 		}
 	}()
 
-	return out_chan
+	return outChan, nil
+}
+
+// rotated returns true when the path is not the opened file any more
+func rotated(file *os.File, path string) bool {
+	opened, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	return !os.SameFile(opened, current)
+}
+
+// seekLastLines seeks the file to the start of the last n lines, by reading backward from the end
+func seekLastLines(file *os.File, n int) error {
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	const chunkSize = 4096
+	buf := make([]byte, chunkSize)
+	offset := size
+	newlines := 0
+	for offset > 0 {
+		readSize := int64(chunkSize)
+		if offset < readSize {
+			readSize = offset
+		}
+		offset -= readSize
+
+		if _, err := file.ReadAt(buf[:readSize], offset); err != nil {
+			return err
+		}
+		for i := readSize - 1; i >= 0; i-- {
+			if buf[i] != '\n' || offset+i == size-1 {
+				continue
+			}
+			newlines++
+			if newlines == n {
+				_, err := file.Seek(offset+i+1, io.SeekStart)
+				return err
+			}
+		}
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	return err
 }
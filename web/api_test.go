@@ -2,13 +2,22 @@ package web
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
+	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/progress"
 	"github.com/longbridgeapp/assert"
 )
 
@@ -39,6 +48,10 @@ func invokeHttp(method string, path string, headers map[string]string, data map[
 	for key := range headers {
 		req.Header.Add(key, headers[key])
 	}
+	// The admin user in gobackup_test.yml
+	if _, ok := headers["Authorization"]; !ok {
+		req.SetBasicAuth("gobackup", "123456")
+	}
 
 	if len(data) > 0 {
 		req.Header.Add("Content-Type", "application/json")
@@ -68,3 +81,144 @@ func TestAPIPostPeform(t *testing.T) {
 	assert.Equal(t, 200, code)
 	assertMatchJSON(t, gin.H{"message": "Backup: test_model performed in background."}, body)
 }
+
+func TestAPISchema(t *testing.T) {
+	code, body := invokeHttp("GET", "/api/schema", nil, nil)
+
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, `"$id":"`+config.SchemaID+`"`)
+}
+
+func TestAPIRuns(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	defer history.Close()
+
+	err := history.Save(history.Record{ID: "run-1", Model: "base_test", Status: history.StatusSuccess, StartedAt: time.Now()})
+	assert.NoError(t, err)
+
+	code, body := invokeHttp("GET", "/api/runs?model=base_test", nil, nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, `"runs":[{"id":"run-1","model":"base_test"`)
+
+	code, _ = invokeHttp("GET", "/api/runs?limit=a", nil, nil)
+	assert.Equal(t, 400, code)
+
+	code, body = invokeHttp("GET", "/api/runs/run-1", nil, nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, `"status":"success"`)
+
+	code, body = invokeHttp("GET", "/api/runs/run-1/log", nil, nil)
+	assert.Equal(t, 404, code)
+	assertMatchJSON(t, gin.H{"message": "Error #01: Log of run: \"run-1\" not found\n"}, body)
+
+	assert.NoError(t, os.MkdirAll(filepath.Dir(history.LogPath("run-1")), 0750))
+	assert.NoError(t, os.WriteFile(history.LogPath("run-1"), []byte("dump succeeded\n"), 0640))
+	code, body = invokeHttp("GET", "/api/runs/run-1/log", nil, nil)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "dump succeeded\n", body)
+
+	code, body = invokeHttp("GET", "/api/runs/not-exist", nil, nil)
+	assert.Equal(t, 404, code)
+	assertMatchJSON(t, gin.H{"message": "Error #01: Run: \"not-exist\" not found\n"}, body)
+
+	code, body = invokeHttp("DELETE", "/api/runs/not-exist", nil, nil)
+	assert.Equal(t, 404, code)
+	assertMatchJSON(t, gin.H{"message": "Error #01: Run: \"not-exist\" not found\n"}, body)
+}
+
+func TestAPIRunProgress(t *testing.T) {
+	config.GoBackupDir = t.TempDir()
+	defer history.Close()
+
+	err := history.Save(history.Record{ID: "run-2", Model: "base_test", Status: history.StatusRunning, StartedAt: time.Now()})
+	assert.NoError(t, err)
+
+	code, body := invokeHttp("GET", "/api/runs/run-2/progress", nil, nil)
+	assert.Equal(t, 404, code)
+	assertMatchJSON(t, gin.H{"message": "Error #01: Run: \"run-2\" is not running\n"}, body)
+
+	progress.Start("run-2", "base_test")
+	ctx := logger.NewContext(context.Background(), logger.Fields{RunID: "run-2"})
+	progress.SetStage(ctx, "storage")
+	progress.Begin(progress.WithStorage(ctx, "local"), progress.KindUpload, 2048)
+
+	code, body = invokeHttp("GET", "/api/v1/runs/run-2/progress", nil, nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, `"stage":"storage"`)
+	assert.Contains(t, body, `"uploads":[{"name":"local","bytes":0,"total":2048`)
+
+	progress.Finish("run-2")
+	// The stream requires a real connection
+	server := httptest.NewServer(setupRouter("master"))
+	defer server.Close()
+	req, _ := http.NewRequest("GET", server.URL+"/api/runs/run-2/progress/stream", nil)
+	req.SetBasicAuth("gobackup", "123456")
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+	body = string(data)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	assert.Contains(t, body, "event:progress\ndata:{\"run_id\":\"run-2\"")
+	assert.Contains(t, body, `"finished":true`)
+	assert.Contains(t, body, "event:done\ndata:{\"id\":\"run-2\"")
+
+	code, _ = invokeHttp("GET", "/api/runs/not-exist/progress/stream", nil, nil)
+	assert.Equal(t, 404, code)
+}
+
+func TestSeekLastLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gobackup.log")
+	content := ""
+	for i := 0; i < 2000; i++ {
+		content += fmt.Sprintf("line %d\n", i)
+	}
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0640))
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	assert.NoError(t, seekLastLines(file, 3))
+	rest, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "line 1997\nline 1998\nline 1999\n", string(rest))
+
+	assert.NoError(t, seekLastLines(file, 5000))
+	rest, err = io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(rest))
+}
+
+func TestTailFile_rotated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gobackup.log")
+	assert.NoError(t, os.WriteFile(path, []byte("line 1\nline 2\n"), 0640))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lines, err := tailFile(ctx, path)
+	assert.NoError(t, err)
+	assert.Equal(t, "line 1", <-lines)
+	assert.Equal(t, "line 2", <-lines)
+
+	// Rotate the log file
+	assert.NoError(t, os.Rename(path, path+".1"))
+	assert.NoError(t, os.WriteFile(path, []byte("line 3\n"), 0640))
+
+	select {
+	case line := <-lines:
+		assert.Equal(t, "line 3", line)
+	case <-time.After(time.Second):
+		t.Fatal("the rotated log file is not followed")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-lines:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("tail is not stopped")
+	}
+}
@@ -0,0 +1,357 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/logger"
+)
+
+const (
+	userKey         = "user"
+	sessionCookie   = "gobackup_session"
+	stateCookie     = "gobackup_oidc_state"
+	sessionDuration = 12 * time.Hour
+)
+
+var (
+	// sessionSecret signs the session cookies, the sessions are expired after restart
+	sessionSecret = randomBytes(32)
+
+	// anonymous is the user when auth is not configured
+	anonymous = config.WebUser{Name: "anonymous", Scopes: []string{config.ScopeAdmin}}
+
+	oidcLock     sync.Mutex
+	oidcProvider *oidc.Provider
+	oidcIssuer   string
+)
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// authenticate finds the user of request by client certificate, API token, basic auth or the session of OIDC login.
+// It's the first middleware, so that every route is protected.
+func authenticate(c *gin.Context) {
+	if !config.Web.AuthEnabled() {
+		c.Set(userKey, anonymous)
+		return
+	}
+
+	// Login and callback of OIDC
+	if strings.HasPrefix(c.Request.URL.Path, "/auth/") {
+		return
+	}
+
+	if user, ok := findUser(c.Request); ok {
+		c.Set(userKey, user)
+		return
+	}
+
+	// Redirect the web UI to login page
+	if config.Web.OIDC != nil && c.Request.Method == "GET" && !isAPIPath(c.Request.URL.Path) {
+		c.Redirect(302, "/auth/login?return_to="+url.QueryEscape(c.Request.URL.RequestURI()))
+		c.Abort()
+		return
+	}
+
+	if len(config.Web.Users) > 0 {
+		c.Header("WWW-Authenticate", `Basic realm="GoBackup"`)
+	}
+	c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized"})
+}
+
+// require the scope, and the access of the model in path or query if present
+func require(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := currentUser(c)
+		if !user.HasScope(scope) {
+			c.AbortWithStatusJSON(403, gin.H{"message": fmt.Sprintf("Forbidden: %s scope is required", scope)})
+			return
+		}
+
+		modelName := c.Param("model")
+		if len(modelName) == 0 {
+			modelName = c.Query("model")
+		}
+		if len(modelName) > 0 && !user.CanAccess(modelName) {
+			c.AbortWithStatusJSON(403, gin.H{"message": fmt.Sprintf("Forbidden: no access to model %s", modelName)})
+		}
+	}
+}
+
+// requireAll requires the scope and the access of all models, for the data across models
+func requireAll(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := currentUser(c)
+		if !user.HasScope(scope) || !user.CanAccessAll() {
+			c.AbortWithStatusJSON(403, gin.H{"message": fmt.Sprintf("Forbidden: %s scope of all models is required", scope)})
+		}
+	}
+}
+
+// authorizeModel checks the access of the model which is not in path or query, it aborts with 403 if not allowed
+func authorizeModel(c *gin.Context, modelName string) bool {
+	if !currentUser(c).CanAccess(modelName) {
+		c.AbortWithStatusJSON(403, gin.H{"message": fmt.Sprintf("Forbidden: no access to model %s", modelName)})
+		return false
+	}
+	return true
+}
+
+func currentUser(c *gin.Context) config.WebUser {
+	if user, ok := c.Get(userKey); ok {
+		return user.(config.WebUser)
+	}
+	return config.WebUser{}
+}
+
+func isAPIPath(path string) bool {
+	return strings.HasPrefix(path, "/api/") || path == "/status" || path == "/metrics"
+}
+
+// findUser by the client certificate, the `Authorization` header or the session cookie
+func findUser(r *http.Request) (config.WebUser, bool) {
+	if user, ok := findClient(r); ok {
+		return user, true
+	}
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		for _, user := range config.Web.Tokens {
+			if subtle.ConstantTimeCompare([]byte(user.Token), []byte(token)) == 1 {
+				return user, true
+			}
+		}
+		return config.WebUser{}, false
+	}
+
+	if username, password, ok := r.BasicAuth(); ok {
+		for _, user := range config.Web.Users {
+			if user.Name == username && checkPassword(user.Password, password) {
+				return user, true
+			}
+		}
+		return config.WebUser{}, false
+	}
+
+	if config.Web.OIDC != nil {
+		if cookie, err := r.Cookie(sessionCookie); err == nil {
+			if email, ok := verifySession(cookie.Value); ok {
+				return findOIDCUser(email)
+			}
+		}
+	}
+
+	return config.WebUser{}, false
+}
+
+// checkPassword compares the password with the plain text or bcrypt hash
+func checkPassword(expected, password string) bool {
+	if strings.HasPrefix(expected, "$2a$") || strings.HasPrefix(expected, "$2b$") || strings.HasPrefix(expected, "$2y$") {
+		return bcrypt.CompareHashAndPassword([]byte(expected), []byte(password)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(password)) == 1
+}
+
+func findOIDCUser(email string) (config.WebUser, bool) {
+	for _, user := range config.Web.OIDC.Users {
+		if strings.EqualFold(user.Name, email) {
+			return user, true
+		}
+	}
+	return config.WebUser{}, false
+}
+
+// signSession returns the session of email: <email>|<expires>|<signature>
+func signSession(email string, expiresAt time.Time) string {
+	payload := email + "|" + strconv.FormatInt(expiresAt.Unix(), 10)
+	mac := hmac.New(sha256.New, sessionSecret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload + "|" + hex.EncodeToString(mac.Sum(nil))))
+}
+
+// verifySession returns the email of session if it's valid and not expired
+func verifySession(value string) (string, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return "", false
+	}
+
+	i := strings.LastIndex(string(data), "|")
+	if i < 0 {
+		return "", false
+	}
+	payload, signature := string(data[:i]), string(data[i+1:])
+	mac := hmac.New(sha256.New, sessionSecret)
+	mac.Write([]byte(payload))
+	if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(signature)) {
+		return "", false
+	}
+
+	email, expires, ok := strings.Cut(payload, "|")
+	if !ok {
+		return "", false
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return "", false
+	}
+
+	return email, true
+}
+
+// oauth2Config returns the OAuth2 config of OIDC, the provider is discovered once for the issuer
+func oauth2Config(c *gin.Context) (*oauth2.Config, *oidc.Provider, error) {
+	oidcConfig := config.Web.OIDC
+
+	oidcLock.Lock()
+	defer oidcLock.Unlock()
+
+	if oidcProvider == nil || oidcIssuer != oidcConfig.Issuer {
+		provider, err := oidc.NewProvider(c.Request.Context(), oidcConfig.Issuer)
+		if err != nil {
+			return nil, nil, err
+		}
+		oidcProvider, oidcIssuer = provider, oidcConfig.Issuer
+	}
+
+	return &oauth2.Config{
+		ClientID:     oidcConfig.ClientID,
+		ClientSecret: oidcConfig.ClientSecret,
+		RedirectURL:  oidcConfig.RedirectURL,
+		Endpoint:     oidcProvider.Endpoint(),
+		Scopes:       []string{oidc.ScopeOpenID, "email"},
+	}, oidcProvider, nil
+}
+
+func setupAuthRouter(r *gin.Engine) {
+	group := r.Group("/auth")
+	group.GET("/login", login)
+	group.GET("/callback", loginCallback)
+	group.GET("/logout", logout)
+}
+
+// GET /auth/login?return_to=/
+func login(c *gin.Context) {
+	if config.Web.OIDC == nil {
+		c.AbortWithStatusJSON(404, gin.H{"message": "OIDC login is not enabled"})
+		return
+	}
+
+	oauth2Config, _, err := oauth2Config(c)
+	if err != nil {
+		logger.Tag("Auth").Errorf("OIDC discovery failed: %v", err)
+		c.AbortWithStatusJSON(502, gin.H{"message": "OIDC provider is unavailable"})
+		return
+	}
+
+	state := hex.EncodeToString(randomBytes(16))
+	returnTo := c.Query("return_to")
+	// Only redirect to the local path after login
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") {
+		returnTo = "/"
+	}
+	setCookie(c, stateCookie, state+"|"+returnTo, 600)
+	c.Redirect(302, oauth2Config.AuthCodeURL(state))
+}
+
+// GET /auth/callback?code=&state=
+func loginCallback(c *gin.Context) {
+	logger := logger.Tag("Auth")
+
+	if config.Web.OIDC == nil {
+		c.AbortWithStatusJSON(404, gin.H{"message": "OIDC login is not enabled"})
+		return
+	}
+
+	stateValue, err := c.Cookie(stateCookie)
+	state, returnTo, _ := strings.Cut(stateValue, "|")
+	if err != nil || len(state) == 0 || c.Query("state") != state {
+		c.AbortWithStatusJSON(400, gin.H{"message": "Invalid state of login"})
+		return
+	}
+	setCookie(c, stateCookie, "", -1)
+
+	oauth2Config, provider, err := oauth2Config(c)
+	if err != nil {
+		logger.Errorf("OIDC discovery failed: %v", err)
+		c.AbortWithStatusJSON(502, gin.H{"message": "OIDC provider is unavailable"})
+		return
+	}
+
+	token, err := oauth2Config.Exchange(c.Request.Context(), c.Query("code"))
+	if err != nil {
+		logger.Errorf("OIDC exchange failed: %v", err)
+		c.AbortWithStatusJSON(401, gin.H{"message": "Login failed"})
+		return
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		c.AbortWithStatusJSON(401, gin.H{"message": "Login failed: no id_token"})
+		return
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: oauth2Config.ClientID}).Verify(c.Request.Context(), rawIDToken)
+	if err != nil {
+		logger.Errorf("OIDC verify failed: %v", err)
+		c.AbortWithStatusJSON(401, gin.H{"message": "Login failed"})
+		return
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified *bool  `json:"email_verified"`
+	}
+	if err := idToken.Claims(&claims); err != nil || len(claims.Email) == 0 {
+		c.AbortWithStatusJSON(401, gin.H{"message": "Login failed: no email"})
+		return
+	}
+	if claims.EmailVerified != nil && !*claims.EmailVerified {
+		c.AbortWithStatusJSON(403, gin.H{"message": "Login failed: email is not verified"})
+		return
+	}
+	if _, ok := findOIDCUser(claims.Email); !ok {
+		logger.Warnf("OIDC user %s is not allowed", claims.Email)
+		c.AbortWithStatusJSON(403, gin.H{"message": fmt.Sprintf("Forbidden: %s is not allowed", claims.Email)})
+		return
+	}
+
+	logger.Infof("OIDC user %s logged in", claims.Email)
+	setCookie(c, sessionCookie, signSession(claims.Email, time.Now().Add(sessionDuration)), int(sessionDuration.Seconds()))
+	c.Redirect(302, returnTo)
+}
+
+// GET /auth/logout
+func logout(c *gin.Context) {
+	setCookie(c, sessionCookie, "", -1)
+	c.Redirect(302, "/")
+}
+
+func setCookie(c *gin.Context, name, value string, maxAge int) {
+	secure := c.Request.TLS != nil
+	if oidcConfig := config.Web.OIDC; oidcConfig != nil {
+		secure = secure || strings.HasPrefix(oidcConfig.RedirectURL, "https://")
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(name, value, maxAge, "/", "", secure, true)
+}
@@ -0,0 +1,157 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/longbridgeapp/assert"
+)
+
+func invokeWithBasicAuth(method, path, username, password string) (int, string) {
+	r := setupRouter("master")
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	req.SetBasicAuth(username, password)
+	r.ServeHTTP(w, req)
+	return w.Code, w.Body.String()
+}
+
+func TestAuthenticate(t *testing.T) {
+	r := setupRouter("master")
+	for _, path := range []string{"/status", "/metrics", "/api/config", "/api/v1/models", "/"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 401, w.Code)
+		assert.Equal(t, `Basic realm="GoBackup"`, w.Header().Get("WWW-Authenticate"))
+	}
+
+	code, _ := invokeWithBasicAuth("GET", "/status", "gobackup", "wrong")
+	assert.Equal(t, 401, code)
+
+	code, _ = invokeHttp("GET", "/status", map[string]string{"Authorization": "Bearer wrong"}, nil)
+	assert.Equal(t, 401, code)
+}
+
+func TestAuthorize_readOnlyUser(t *testing.T) {
+	code, _ := invokeWithBasicAuth("GET", "/api/v1/models/base_test", "oncall", "oncall-password")
+	assert.Equal(t, 200, code)
+	code, _ = invokeWithBasicAuth("GET", "/metrics", "oncall", "oncall-password")
+	assert.Equal(t, 200, code)
+
+	code, body := invokeWithBasicAuth("GET", "/api/download?model=base_test&path=foo.tar.gz", "oncall", "oncall-password")
+	assert.Equal(t, 403, code)
+	assert.Equal(t, `{"message":"Forbidden: download scope is required"}`, body)
+
+	code, _ = invokeWithBasicAuth("GET", "/api/v1/models/base_test/storages/local/download?path=foo.tar.gz", "oncall", "oncall-password")
+	assert.Equal(t, 403, code)
+	code, _ = invokeWithBasicAuth("POST", "/api/v1/models/base_test/perform", "oncall", "oncall-password")
+	assert.Equal(t, 403, code)
+	code, _ = invokeWithBasicAuth("POST", "/api/v1/models/base_test/pause", "oncall", "oncall-password")
+	assert.Equal(t, 403, code)
+}
+
+func TestAuthorize_modelToken(t *testing.T) {
+	headers := map[string]string{"Authorization": "Bearer ci-token"}
+
+	code, body := invokeHttp("GET", "/api/v1/models", headers, nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, `"name":"test_model"`)
+	assert.NotContains(t, body, `"name":"base_test"`)
+
+	code, body = invokeHttp("GET", "/api/v1/models/base_test", headers, nil)
+	assert.Equal(t, 403, code)
+	assert.Equal(t, `{"message":"Forbidden: no access to model base_test"}`, body)
+
+	code, _ = invokeHttp("POST", "/api/perform", headers, map[string]any{"model": "base_test"})
+	assert.Equal(t, 403, code)
+	code, _ = invokeHttp("POST", "/api/perform", headers, map[string]any{"model": "test_model"})
+	assert.Equal(t, 200, code)
+
+	// The data across models
+	code, _ = invokeHttp("GET", "/metrics", headers, nil)
+	assert.Equal(t, 403, code)
+	code, _ = invokeHttp("GET", "/api/log", headers, nil)
+	assert.Equal(t, 403, code)
+}
+
+func TestAuthenticate_disabled(t *testing.T) {
+	web := config.Web
+	defer func() {
+		config.Web = web
+	}()
+	config.Web = config.WebConfig{}
+
+	r := setupRouter("master")
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/status", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestAuthenticate_oidcRedirect(t *testing.T) {
+	web := config.Web
+	defer func() {
+		config.Web = web
+	}()
+	config.Web.OIDC = &config.OIDCConfig{
+		Issuer:      "https://accounts.example.com",
+		ClientID:    "gobackup",
+		RedirectURL: "https://backup.example.com/auth/callback",
+		Users:       []config.WebUser{{Name: "alice@example.com", Scopes: []string{config.ScopeRead}}},
+	}
+
+	r := setupRouter("master")
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/runs/base_test", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 302, w.Code)
+	assert.Equal(t, "/auth/login?return_to=%2Fruns%2Fbase_test", w.Header().Get("Location"))
+
+	// The API is not redirected
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/config", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code)
+
+	// Logged in by session
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/config", nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookie, Value: signSession("Alice@example.com", time.Now().Add(time.Hour))})
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	// Not in the users
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/config", nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookie, Value: signSession("bob@example.com", time.Now().Add(time.Hour))})
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code)
+}
+
+func TestSession(t *testing.T) {
+	session := signSession("alice@example.com", time.Now().Add(time.Hour))
+	email, ok := verifySession(session)
+	assert.True(t, ok)
+	assert.Equal(t, "alice@example.com", email)
+
+	_, ok = verifySession(signSession("alice@example.com", time.Now().Add(-time.Second)))
+	assert.False(t, ok)
+
+	_, ok = verifySession(session[:len(session)-2] + "AA")
+	assert.False(t, ok)
+	_, ok = verifySession("invalid")
+	assert.False(t, ok)
+}
+
+func Test_checkPassword(t *testing.T) {
+	assert.True(t, checkPassword("123456", "123456"))
+	assert.False(t, checkPassword("123456", "1234567"))
+
+	hash := "$2a$04$pS3ME5JRFVogSJ1sTskCeewMZOWRk2dRcSjqxylXecC9Suo9B8HlW"
+	assert.True(t, checkPassword(hash, "oncall-password"))
+	assert.False(t, checkPassword(hash, "wrong"))
+}
@@ -1,8 +1,10 @@
-import { Button, notification, Popconfirm, Skeleton } from 'antd';
+import { Button, notification, Popconfirm, Skeleton, Tag } from 'antd';
 import { useEffect, useState } from 'react';
 import { LazyLog, ScrollFollow } from 'react-lazylog';
 import { Link } from 'react-router-dom';
 import Icon from './icon';
+import { formatBytes, RunProgress, STATUS_COLORS } from './RunList';
+import Trend from './Trend';
 
 const API_URL = '/api';
 
@@ -33,77 +35,145 @@ const LogView = () => {
   );
 };
 
+const RUN_HISTORY_LIMIT = 30;
+
+const formatSeconds = (seconds: number) => `${seconds.toFixed(1)}s`;
+
+const runDuration = (run: any) =>
+  (new Date(run.finished_at).getTime() - new Date(run.started_at).getTime()) /
+  1000;
+
 const ModelList = ({}) => {
   const [loading, setLoading] = useState(false);
-  const [models, setModels] = useState({});
+  const [models, setModels] = useState<any[]>([]);
 
   useEffect(() => {
     reloadModels();
   }, []);
 
-  const performBackup = (model: string) => {
-    fetch(`${API_URL}/perform`, {
-      method: 'POST',
-      headers: {
-        'Content-Type': 'application/json',
-      },
-      body: JSON.stringify({ model }),
-    })
-      .then((res) => res.json())
-      .then((data) => {
-        notification.success({
-          message: 'Backup',
-          description: `Backup for ${model} performed successfully.`,
-        });
-      })
-      .catch((data) => {
-        notification.error({
-          message: 'Backup Failed',
-          description: data.message,
-        });
-      });
-  };
-
   const reloadModels = () => {
     setLoading(true);
-    fetch(`${API_URL}/config`)
+    fetch(`${API_URL}/v1/models`)
       .then((res) => res.json())
       .then((data) => {
-        setModels(data.models);
+        setModels(data.models || []);
         setLoading(false);
       });
   };
 
-  const ModelItem = ({ modelKey }: { modelKey: string }) => {
-    const model = models[modelKey];
-    const scheduleEnable = model.schedule?.enabled;
+  const ModelItem = ({ model }: { model: any }) => {
+    const [lastRun, setLastRun] = useState<any>(model.last_run);
+    const [runs, setRuns] = useState<any[]>([]);
+
+    const reloadRuns = () => {
+      let query = new URLSearchParams({
+        model: model.name,
+        kind: 'backup',
+        limit: RUN_HISTORY_LIMIT.toString(),
+      });
+
+      fetch(`${API_URL}/v1/runs?` + query.toString())
+        .then((res) => res.json())
+        .then((data) => {
+          const runs = data.runs || [];
+          setRuns(runs);
+          setLastRun(runs[0]);
+        });
+    };
+
+    useEffect(() => {
+      reloadRuns();
+    }, [model.name]);
+
+    const performBackup = () => {
+      fetch(`${API_URL}/v1/models/${model.name}/perform`, { method: 'POST' })
+        .then((res) => res.json())
+        .then((data) => {
+          notification.success({
+            message: 'Backup',
+            description: data.message,
+          });
+          // The run is recorded when it's started in background
+          setTimeout(reloadRuns, 1000);
+        })
+        .catch((data) => {
+          notification.error({
+            message: 'Backup Failed',
+            description: data.message,
+          });
+        });
+    };
+
+    // Trends of the finished runs, from the oldest
+    const finished = runs
+      .filter((run) => run.status === 'success' && run.finished_at)
+      .reverse();
 
     return (
       <div className="model-list-item">
-        <div className="text-base">
-          <div className="text-base font-medium uppercase">{modelKey}</div>
-          {scheduleEnable && (
-            <div className="text-green text-sm">{model.schedule_info}</div>
-          )}
-          {model.description && (
-            <div className="text-gray-400 truncate text-xs my-1">
-              {model.description}
+        <div className="flex-1 min-w-0">
+          <div className="text-base">
+            <div className="flex items-center space-x-2">
+              <div className="text-base font-medium uppercase">
+                {model.name}
+              </div>
+              {lastRun && (
+                <Tag
+                  color={STATUS_COLORS[lastRun.status]}
+                  title={new Date(lastRun.started_at).toLocaleString()}
+                >
+                  {lastRun.status}
+                </Tag>
+              )}
+              {model.schedule?.paused && <Tag>paused</Tag>}
             </div>
+            {model.schedule?.enabled && (
+              <div className="text-green text-sm">{model.schedule.info}</div>
+            )}
+            {model.description && (
+              <div className="text-gray-400 truncate text-xs my-1">
+                {model.description}
+              </div>
+            )}
+          </div>
+          <div className="flex flex-wrap gap-x-4">
+            <Trend
+              label="Size"
+              values={finished.map((run) => run.archive_size || 0)}
+              format={formatBytes}
+            />
+            <Trend
+              label="Duration"
+              values={finished.map(runDuration)}
+              format={formatSeconds}
+            />
+          </div>
+          {lastRun?.status === 'running' && (
+            <RunProgress id={lastRun.id} onDone={reloadRuns} />
           )}
         </div>
         <div className="flex items-center space-x-1">
-          <Link to={`/browser/${modelKey}`}>
-            <Button size="small">
+          <Link to={`/browser/${model.name}`}>
+            <Button size="small" title="Browse backup files">
               <Icon name="folders" />
             </Button>
           </Link>
+          <Link to={`/runs/${model.name}`}>
+            <Button size="small" title="Run history">
+              <Icon name="history" />
+            </Button>
+          </Link>
 
           <Popconfirm
             title="Perform Backup"
             description="Are you sure to perform backup now?"
-            onConfirm={() => performBackup(modelKey)}
+            onConfirm={performBackup}
           >
-            <Button size="small" title="Perform backup now!">
+            <Button
+              size="small"
+              title="Run now"
+              disabled={lastRun?.status === 'running'}
+            >
               <Icon name="play" mode="fill" />
             </Button>
           </Popconfirm>
@@ -115,9 +185,14 @@ const ModelList = ({}) => {
   return (
     <div className="model-list-wrapper">
       <div className="model-list-header">
-        <div className="flex items-center space-x-2">
-          <Icon name="stack" />
-          <div className="text-text text-base">Models</div>
+        <div className="flex items-center justify-between">
+          <div className="flex items-center space-x-2">
+            <Icon name="stack" />
+            <div className="text-text text-base">Models</div>
+          </div>
+          <Button size="small" onClick={reloadModels} title="Refresh">
+            <Icon name="refresh" loading={loading} />
+          </Button>
         </div>
       </div>
       <div className="model-list-scrollview">
@@ -128,8 +203,8 @@ const ModelList = ({}) => {
         )}
         {!loading && (
           <>
-            {Object.keys(models).map((key: string, idx: number) => (
-              <ModelItem modelKey={key} key={idx} />
+            {models.map((model: any) => (
+              <ModelItem model={model} key={model.name} />
             ))}
           </>
         )}
@@ -1,4 +1,13 @@
-import { Button, Empty, Skeleton } from 'antd';
+import {
+  Button,
+  Empty,
+  Input,
+  Modal,
+  notification,
+  Popconfirm,
+  Select,
+  Skeleton,
+} from 'antd';
 import { filesize } from 'filesize';
 import { FC, useEffect, useState } from 'react';
 import { useParams } from 'react-router-dom';
@@ -12,20 +21,35 @@ const FileList: FC<{}> = () => {
   const [loading, setLoading] = useState(true);
   const [files, setFiles] = useState<any[]>([]);
   const [parent, setParent] = useState('/');
+  const [storages, setStorages] = useState<string[]>([]);
+  const [storage, setStorage] = useState('');
+  const [pending, setPending] = useState('');
+
+  const storageURL = `/api/v1/models/${model}/storages/${storage}`;
 
   const Time = ({ value }: { value: string }) => {
     if (!value) return <></>;
     return <span title={value}>{new Date(value).toLocaleString()}</span>;
   };
 
+  const reloadStorages = () => {
+    fetch(`/api/v1/models/${model}`)
+      .then((res) => res.json())
+      .then((data) => {
+        setStorages((data.storages || []).map((s: any) => s.name));
+        setStorage(data.default_storage || '');
+      });
+  };
+
   const reloadList = () => {
+    if (!storage) return;
+
     setLoading(true);
     let query = new URLSearchParams({
-      model,
       parent,
     });
 
-    fetch(`/api/list?` + query.toString())
+    fetch(`${storageURL}/files?` + query.toString())
       .then((res) => res.json())
       .then((data) => {
         setFiles(data.files || []);
@@ -34,9 +58,97 @@ const FileList: FC<{}> = () => {
   };
 
   useEffect(() => {
-    reloadList();
+    reloadStorages();
   }, [model]);
 
+  useEffect(() => {
+    reloadList();
+  }, [storage]);
+
+  const ResultView = ({ result }: { result: any }) => {
+    return (
+      <div className="space-y-1 text-sm">
+        <div>Size: {filesize(result.size || 0, { base: 2 }).toString()}</div>
+        <div className="break-all">SHA256: {result.sha256}</div>
+        <div>Entries: {result.entries}</div>
+        {result.target && <div>Target: {result.target}</div>}
+        <pre className="run-log">{(result.files || []).join('\n')}</pre>
+      </div>
+    );
+  };
+
+  // verify or restore the backup package in background, and wait for the run finished
+  const checkPackage = (action: 'verify' | 'restore', body: any) => {
+    const failed = (description: string) => {
+      setPending('');
+      notification.error({
+        message: action === 'verify' ? 'Verify Failed' : 'Restore Failed',
+        description,
+      });
+    };
+
+    const waitRun = (id: string) => {
+      fetch(`/api/v1/runs/${id}`)
+        .then((res) => res.json())
+        .then((run) => {
+          if (run.status === 'running') {
+            setTimeout(() => waitRun(id), 1000);
+            return;
+          }
+          if (run.status !== 'success') {
+            failed(run.error || run.message);
+            return;
+          }
+          setPending('');
+          Modal.success({
+            title:
+              action === 'verify'
+                ? `Package: ${body.key} is verified.`
+                : `Package: ${body.key} is restored to ${body.target}.`,
+            width: 640,
+            content: <ResultView result={run.result} />,
+          });
+        });
+    };
+
+    setPending(body.key);
+    fetch(`${storageURL}/${action}`, {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+      },
+      body: JSON.stringify(body),
+    })
+      .then((res) => res.json())
+      .then((data) => {
+        if (!data.run_id) {
+          failed(data.message);
+          return;
+        }
+        waitRun(data.run_id);
+      });
+  };
+
+  const restorePackage = (key: string) => {
+    let target = '';
+    Modal.confirm({
+      title: `Restore ${key}`,
+      content: (
+        <div className="space-y-2">
+          <div className="text-sm text-gray-600">
+            Absolute path of an empty directory on the server:
+          </div>
+          <Input
+            placeholder="/tmp/restore"
+            onChange={(e) => (target = e.target.value)}
+          />
+        </div>
+      ),
+      okText: 'Restore',
+      onOk: () => checkPackage('restore', { key, target }),
+    });
+  };
+
   const FileItem = ({
     file,
     type = 'file',
@@ -45,11 +157,11 @@ const FileList: FC<{}> = () => {
     type?: 'file' | 'folder';
   }) => {
     const downloadURL =
-      `/api/download?` +
+      `${storageURL}/download?` +
       new URLSearchParams({
-        model,
         path: file.filename,
       }).toString();
+    const checking = pending === file.filename;
 
     const fsize = filesize(file.size || 0, { base: 2 }).toString();
 
@@ -69,12 +181,35 @@ const FileList: FC<{}> = () => {
               <div>
                 <Time value={file.last_modified} />
               </div>
-              <div>
+              <div className="flex items-center space-x-1">
                 <Button size="small" title="Download backup file.">
                   <a href={downloadURL}>
                     <Icon name="download-cloud" mode="fill" />
                   </a>
                 </Button>
+                <Popconfirm
+                  title="Verify Backup"
+                  description="Download, decrypt and list the archive on the server?"
+                  onConfirm={() =>
+                    checkPackage('verify', { key: file.filename })
+                  }
+                >
+                  <Button
+                    size="small"
+                    title="Verify backup file."
+                    disabled={!!pending}
+                  >
+                    <Icon name="shield-check" loading={checking} />
+                  </Button>
+                </Popconfirm>
+                <Button
+                  size="small"
+                  title="Restore backup file."
+                  disabled={!!pending}
+                  onClick={() => restorePackage(file.filename)}
+                >
+                  <Icon name="inbox-unarchive" />
+                </Button>
               </div>
             </div>
           </>
@@ -95,6 +230,13 @@ const FileList: FC<{}> = () => {
         backTo={`/`}
         extra={
           <>
+            <Select
+              size="small"
+              value={storage}
+              onChange={setStorage}
+              options={storages.map((name) => ({ value: name, label: name }))}
+              className="min-w-[120px]"
+            />
             <Button size="small" onClick={reloadList} title="Refresh">
               <Icon name="refresh" loading={loading} />
             </Button>
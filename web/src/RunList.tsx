@@ -0,0 +1,242 @@
+import {
+  Button,
+  Empty,
+  notification,
+  Popconfirm,
+  Progress,
+  Skeleton,
+  Tag,
+} from 'antd';
+import { filesize } from 'filesize';
+import { FC, useEffect, useState } from 'react';
+import { useParams } from 'react-router-dom';
+import { PageTitle } from './components';
+
+import Icon from './icon';
+
+export const STATUS_COLORS: Record<string, string> = {
+  queued: 'default',
+  running: 'processing',
+  success: 'success',
+  failure: 'error',
+  canceled: 'warning',
+  skipped: 'default',
+};
+
+export const formatBytes = (bytes: number) =>
+  filesize(bytes || 0, { base: 2 }).toString();
+
+const TransferItem = ({ label, transfer }: { label: string; transfer: any }) => {
+  if (!transfer) return <></>;
+
+  let detail = formatBytes(transfer.bytes);
+  if (transfer.total > 0) {
+    detail += ` / ${formatBytes(transfer.total)}`;
+  }
+  if (transfer.ratio > 0) {
+    detail += `, ratio ${transfer.ratio.toFixed(2)}`;
+  }
+  if (!transfer.done && transfer.speed > 0) {
+    detail += `, ${formatBytes(transfer.speed)}/s`;
+  }
+
+  return (
+    <div>
+      <div className="flex justify-between text-xs text-gray-600">
+        <span>{label}</span>
+        <span className={transfer.error ? 'text-red' : ''}>
+          {transfer.error || detail}
+        </span>
+      </div>
+      {transfer.total > 0 && (
+        <Progress
+          percent={Math.floor(transfer.percent || 0)}
+          size="small"
+          status={
+            transfer.error ? 'exception' : transfer.done ? 'success' : 'active'
+          }
+        />
+      )}
+    </div>
+  );
+};
+
+/**
+ * RunProgress follows the progress of a running run by Server-Sent Events
+ */
+export const RunProgress = ({ id, onDone }: { id: string; onDone: () => void }) => {
+  const [progress, setProgress] = useState<any>(null);
+
+  useEffect(() => {
+    const source = new EventSource(`/api/runs/${id}/progress/stream`);
+    source.addEventListener('progress', (e) => {
+      setProgress(JSON.parse((e as MessageEvent).data));
+    });
+    source.addEventListener('done', () => {
+      source.close();
+      onDone();
+    });
+    return () => source.close();
+  }, [id]);
+
+  if (!progress) return <></>;
+
+  return (
+    <div className="mt-2 space-y-1">
+      <div className="text-xs text-gray-400">Stage: {progress.stage}</div>
+      <TransferItem label="Dump" transfer={progress.dump} />
+      <TransferItem label="Compress" transfer={progress.compress} />
+      <TransferItem label="Encrypt" transfer={progress.encrypt} />
+      {(progress.uploads || []).map((upload: any) => (
+        <TransferItem
+          key={upload.name}
+          label={`Upload: ${upload.name}`}
+          transfer={upload}
+        />
+      ))}
+    </div>
+  );
+};
+
+const RunList: FC<{}> = () => {
+  let { model = '' } = useParams();
+
+  const [loading, setLoading] = useState(true);
+  const [runs, setRuns] = useState<any[]>([]);
+  const [expanded, setExpanded] = useState('');
+
+  const reloadList = () => {
+    setLoading(true);
+    let query = new URLSearchParams({
+      model,
+    });
+
+    fetch(`/api/runs?` + query.toString())
+      .then((res) => res.json())
+      .then((data) => {
+        setRuns(data.runs || []);
+        setLoading(false);
+      });
+  };
+
+  useEffect(() => {
+    reloadList();
+  }, [model]);
+
+  const cancelRun = (id: string) => {
+    fetch(`/api/runs/${id}`, { method: 'DELETE' })
+      .then((res) => res.json())
+      .then((data) => {
+        notification.info({
+          message: 'Cancel',
+          description: data.message,
+        });
+      });
+  };
+
+  const Duration = ({ run }: { run: any }) => {
+    if (!run.finished_at) return <></>;
+    const seconds =
+      (new Date(run.finished_at).getTime() -
+        new Date(run.started_at).getTime()) /
+      1000;
+    return <span>{seconds.toFixed(1)}s</span>;
+  };
+
+  const RunItem = ({ run }: { run: any }) => {
+    const isExpanded = expanded === run.id;
+
+    return (
+      <div className="py-2 px-2 hover:bg-gray-50">
+        <div
+          className="flex flex-col lg:flex-row lg:items-center justify-between gap-2 cursor-pointer"
+          onClick={() => setExpanded(isExpanded ? '' : run.id)}
+        >
+          <div className="flex items-center space-x-2">
+            <Tag color={STATUS_COLORS[run.status]}>{run.status}</Tag>
+            <span title={run.started_at}>
+              {new Date(run.started_at).toLocaleString()}
+            </span>
+            <span className="text-xs text-gray-400">{run.trigger}</span>
+            {run.kind && run.kind !== 'backup' && <Tag>{run.kind}</Tag>}
+          </div>
+          <div className="flex items-center justify-between text-sm space-x-4 text-gray-400">
+            <Duration run={run} />
+            {run.archive_size > 0 && (
+              <div>{filesize(run.archive_size, { base: 2 }).toString()}</div>
+            )}
+            <div>{(run.storages || []).join(', ')}</div>
+            {(run.status === 'running' || run.status === 'queued') && (
+              <Popconfirm
+                title="Cancel Backup"
+                description="Are you sure to cancel this backup?"
+                onConfirm={() => cancelRun(run.id)}
+              >
+                <Button size="small" title="Cancel backup.">
+                  <Icon name="stop-circle" />
+                </Button>
+              </Popconfirm>
+            )}
+          </div>
+        </div>
+        {run.status === 'running' && (
+          <RunProgress id={run.id} onDone={reloadList} />
+        )}
+        {isExpanded && (
+          <div className="mt-2 space-y-2 text-sm">
+            {run.error && <div className="text-red">{run.error}</div>}
+            <div className="flex flex-wrap gap-2 text-gray-600">
+              {(run.stages || []).map((stage: any) => (
+                <span key={stage.name}>
+                  {stage.name}: {stage.duration.toFixed(1)}s
+                </span>
+              ))}
+            </div>
+            {run.log && <pre className="run-log">{run.log}</pre>}
+            <a
+              className="hover:text-blue"
+              href={`/api/runs/${run.id}/log`}
+              target="_blank"
+            >
+              Full log
+            </a>
+          </div>
+        )}
+      </div>
+    );
+  };
+
+  return (
+    <div>
+      <PageTitle
+        title={
+          <div className="flex lg:items-center flex-col lg:flex-row-reverse lg:gap-x-2">
+            <div className="text-xs text-gray-600">Runs</div>
+            <div className="uppercase text-base">{model}</div>
+          </div>
+        }
+        backTo={`/`}
+        extra={
+          <>
+            <Button size="small" onClick={reloadList} title="Refresh">
+              <Icon name="refresh" loading={loading} />
+            </Button>
+          </>
+        }
+      />
+      <div className="file-browser-container">
+        {loading && <Skeleton active />}
+        {!loading && (
+          <>
+            {runs.length === 0 && <Empty className="pt-10" />}
+            {runs.map((run) => (
+              <RunItem key={run.id} run={run} />
+            ))}
+          </>
+        )}
+      </div>
+    </div>
+  );
+};
+
+export default RunList;
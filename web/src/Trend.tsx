@@ -0,0 +1,44 @@
+const WIDTH = 120;
+const HEIGHT = 24;
+
+/**
+ * Trend draws a sparkline of values, from the oldest to the newest
+ */
+const Trend = ({
+  label,
+  values,
+  format,
+}: {
+  label: string;
+  values: number[];
+  format: (value: number) => string;
+}) => {
+  if (values.length < 2) return <></>;
+
+  const max = Math.max(...values);
+  const min = Math.min(...values);
+  const range = max - min || 1;
+  const points = values
+    .map((value, i) => {
+      const x = (i / (values.length - 1)) * WIDTH;
+      const y = HEIGHT - 2 - ((value - min) / range) * (HEIGHT - 4);
+      return `${x.toFixed(1)},${y.toFixed(1)}`;
+    })
+    .join(' ');
+  const last = values[values.length - 1];
+
+  return (
+    <div
+      className="flex items-center space-x-1 text-xs text-gray-400"
+      title={`${label}: ${format(min)} - ${format(max)}`}
+    >
+      <span>{label}</span>
+      <svg width={WIDTH} height={HEIGHT} className="trend">
+        <polyline points={points} />
+      </svg>
+      <span>{format(last)}</span>
+    </div>
+  );
+};
+
+export default Trend;
@@ -5,6 +5,7 @@ import ReactDOM from 'react-dom/client';
 import { createBrowserRouter, RouterProvider } from 'react-router-dom';
 import App from './App';
 import FileList from './FileList';
+import RunList from './RunList';
 
 import 'remixicon/fonts/remixicon.css';
 import Icon from './icon';
@@ -19,6 +20,10 @@ const router = createBrowserRouter([
     path: `/browser/:model`,
     element: <FileList />,
   },
+  {
+    path: `/runs/:model`,
+    element: <RunList />,
+  },
 ]);
 
 ReactDOM.createRoot(document.getElementById('root') as HTMLElement).render(
@@ -0,0 +1,266 @@
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/logger"
+)
+
+const (
+	selfSignedValidity = 365 * 24 * time.Hour
+	// selfSignedRenewBefore regenerates the self-signed certificate before it expires
+	selfSignedRenewBefore = 30 * 24 * time.Hour
+)
+
+// certReloader serves the certificate and client CA of the files,
+// they are reloaded in the next handshake after the files are changed, e.g. renewed by certbot.
+type certReloader struct {
+	lock       sync.Mutex
+	certFile   string
+	keyFile    string
+	caFile     string
+	clientAuth tls.ClientAuthType
+
+	modTimes  []time.Time
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+func newCertReloader(tlsConfig *config.WebTLSConfig) (*certReloader, error) {
+	certFile, keyFile := tlsConfig.CertFile, tlsConfig.KeyFile
+	if len(certFile) == 0 {
+		var err error
+		if certFile, keyFile, err = ensureSelfSigned(filepath.Join(config.GoBackupDir, "tls"), config.Web.Host); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &certReloader{
+		certFile:   certFile,
+		keyFile:    keyFile,
+		caFile:     tlsConfig.ClientCAFile,
+		clientAuth: tls.NoClientCert,
+	}
+	if len(r.caFile) > 0 {
+		r.clientAuth = tls.RequireAndVerifyClientCert
+		if tlsConfig.ClientAuth == "verify_if_given" {
+			r.clientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// files returns the files to watch
+func (r *certReloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if len(r.caFile) > 0 {
+		files = append(files, r.caFile)
+	}
+	return files
+}
+
+// reload the files if any of them is changed
+func (r *certReloader) reload() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	files := r.files()
+	modTimes := make([]time.Time, len(files))
+	changed := r.cert == nil
+	for i, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[i] = info.ModTime()
+		if len(r.modTimes) != len(files) || !r.modTimes[i].Equal(modTimes[i]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %v", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if len(r.caFile) > 0 {
+		data, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("load client CA: %v", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			return fmt.Errorf("load client CA: no certificate found in %s", r.caFile)
+		}
+	}
+
+	if r.cert != nil {
+		logger.Tag("API").Infof("Reloaded TLS certificate %s", r.certFile)
+	}
+	r.cert, r.clientCAs, r.modTimes = &cert, clientCAs, modTimes
+	return nil
+}
+
+// tlsConfig returns the config of the current certificate and client CA
+func (r *certReloader) tlsConfig() *tls.Config {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{*r.cert},
+		ClientCAs:    r.clientCAs,
+		ClientAuth:   r.clientAuth,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+}
+
+// GetCertificate returns the current certificate, for the clients without SNI
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.cert, nil
+}
+
+// GetConfigForClient reloads the changed files and returns the config of handshake.
+// The previous certificate is used if the files are broken, e.g. in the middle of renewal.
+func (r *certReloader) GetConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	if err := r.reload(); err != nil {
+		logger.Tag("API").Errorf("Failed to reload TLS certificate, keep the previous one: %v", err)
+	}
+	return r.tlsConfig(), nil
+}
+
+// newServer returns the HTTP server, with TLS if `web.tls` is configured
+func newServer(addr string, handler http.Handler) (*http.Server, error) {
+	server := &http.Server{Addr: addr, Handler: handler}
+	if config.Web.TLS == nil {
+		return server, nil
+	}
+
+	reloader, err := newCertReloader(config.Web.TLS)
+	if err != nil {
+		return nil, err
+	}
+	server.TLSConfig = &tls.Config{
+		GetConfigForClient: reloader.GetConfigForClient,
+		GetCertificate:     reloader.GetCertificate,
+	}
+	return server, nil
+}
+
+// ensureSelfSigned returns the self-signed certificate in dir, it's generated if not exist or about to expire
+func ensureSelfSigned(dir, host string) (certFile, keyFile string, err error) {
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	if cert, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+		if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil && time.Until(leaf.NotAfter) > selfSignedRenewBefore {
+			return certFile, keyFile, nil
+		}
+	}
+
+	logger.Tag("API").Infof("Generating self-signed certificate in %s", dir)
+	certPEM, keyPEM, err := generateSelfSigned(host, time.Now())
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+		return "", "", err
+	}
+
+	return certFile, keyFile, nil
+}
+
+// generateSelfSigned returns the PEM of certificate and key for localhost, the hostname and the host
+func generateSelfSigned(host string, now time.Time) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "GoBackup", Organization: []string{"GoBackup self-signed"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if hostname, err := os.Hostname(); err == nil && len(hostname) > 0 {
+		template.DNSNames = append(template.DNSNames, hostname)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if !ip.IsUnspecified() && !ip.IsLoopback() {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		}
+	} else if len(host) > 0 && host != "localhost" {
+		template.DNSNames = append(template.DNSNames, host)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// findClient returns the client of the verified certificate by the Common Name
+func findClient(r *http.Request) (config.WebUser, bool) {
+	if config.Web.TLS == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return config.WebUser{}, false
+	}
+
+	commonName := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	for _, client := range config.Web.TLS.Clients {
+		if client.Name == commonName {
+			return client, true
+		}
+	}
+	return config.WebUser{}, false
+}
@@ -0,0 +1,182 @@
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/longbridgeapp/assert"
+)
+
+// issueCert returns the PEM of certificate and key signed by parent, self-signed if parent is nil
+func issueCert(t *testing.T, commonName string, parent *tls.Certificate) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+
+	issuer, signer := template, any(key)
+	if parent != nil {
+		issuer, err = x509.ParseCertificate(parent.Certificate[0])
+		assert.NoError(t, err)
+		signer = parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func Test_ensureSelfSigned(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tls")
+
+	certFile, keyFile, err := ensureSelfSigned(dir, "192.168.1.10")
+	assert.NoError(t, err)
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	assert.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+	assert.NoError(t, leaf.VerifyHostname("localhost"))
+	assert.NoError(t, leaf.VerifyHostname("127.0.0.1"))
+	assert.NoError(t, leaf.VerifyHostname("192.168.1.10"))
+	assert.True(t, leaf.NotAfter.After(time.Now().Add(360*24*time.Hour)))
+
+	info, err := os.Stat(keyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Keep the valid one
+	data, _ := os.ReadFile(certFile)
+	_, _, err = ensureSelfSigned(dir, "192.168.1.10")
+	assert.NoError(t, err)
+	reused, _ := os.ReadFile(certFile)
+	assert.Equal(t, data, reused)
+
+	// Renew the one about to expire
+	certPEM, keyPEM, err := generateSelfSigned("", time.Now().Add(-350*24*time.Hour))
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(certFile, certPEM, 0644))
+	assert.NoError(t, os.WriteFile(keyFile, keyPEM, 0600))
+	_, _, err = ensureSelfSigned(dir, "")
+	assert.NoError(t, err)
+	renewed, _ := os.ReadFile(certFile)
+	assert.NotEqual(t, certPEM, renewed)
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	certPEM, keyPEM, err := generateSelfSigned("", time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(certFile, certPEM, 0644))
+	assert.NoError(t, os.WriteFile(keyFile, keyPEM, 0600))
+
+	reloader, err := newCertReloader(&config.WebTLSConfig{CertFile: certFile, KeyFile: keyFile})
+	assert.NoError(t, err)
+	tlsConfig, err := reloader.GetConfigForClient(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, tlsConfig.ClientAuth)
+	first := tlsConfig.Certificates[0].Certificate[0]
+
+	// Broken files keep the previous certificate
+	assert.NoError(t, os.WriteFile(certFile, []byte("broken"), 0644))
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(certFile, future, future))
+	tlsConfig, _ = reloader.GetConfigForClient(nil)
+	assert.Equal(t, first, tlsConfig.Certificates[0].Certificate[0])
+
+	certPEM, keyPEM, err = generateSelfSigned("", time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(certFile, certPEM, 0644))
+	assert.NoError(t, os.WriteFile(keyFile, keyPEM, 0600))
+	future = future.Add(time.Minute)
+	assert.NoError(t, os.Chtimes(certFile, future, future))
+	tlsConfig, _ = reloader.GetConfigForClient(nil)
+	assert.NotEqual(t, first, tlsConfig.Certificates[0].Certificate[0])
+
+	_, err = newCertReloader(&config.WebTLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: filepath.Join(dir, "not-exist.pem")})
+	assert.Error(t, err)
+}
+
+func TestAuthenticate_clientCert(t *testing.T) {
+	dir := t.TempDir()
+	caPEM, caKeyPEM := issueCert(t, "GoBackup Test CA", nil)
+	ca, err := tls.X509KeyPair(caPEM, caKeyPEM)
+	assert.NoError(t, err)
+	caFile := filepath.Join(dir, "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, caPEM, 0644))
+
+	certPEM, keyPEM, err := generateSelfSigned("", time.Now())
+	assert.NoError(t, err)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	assert.NoError(t, os.WriteFile(certFile, certPEM, 0644))
+	assert.NoError(t, os.WriteFile(keyFile, keyPEM, 0600))
+
+	webConfig := config.Web
+	defer func() {
+		config.Web = webConfig
+	}()
+	config.Web.TLS = &config.WebTLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+		ClientAuth:   "verify_if_given",
+		Clients:      []config.WebUser{{Name: "platform", Scopes: []string{config.ScopeRead}, Models: []string{"test_model"}}},
+	}
+
+	reloader, err := newCertReloader(config.Web.TLS)
+	assert.NoError(t, err)
+	server := httptest.NewUnstartedServer(setupRouter("master"))
+	server.TLS = &tls.Config{GetConfigForClient: reloader.GetConfigForClient}
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(certPEM)
+	get := func(path string, certs ...tls.Certificate) int {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+		res, err := client.Get(server.URL + path)
+		assert.NoError(t, err)
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	platformPEM, platformKeyPEM := issueCert(t, "platform", &ca)
+	platform, err := tls.X509KeyPair(platformPEM, platformKeyPEM)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, get("/api/v1/models/test_model", platform))
+	assert.Equal(t, 403, get("/api/v1/models/base_test", platform))
+	assert.Equal(t, 403, get("/metrics", platform))
+
+	unknownPEM, unknownKeyPEM := issueCert(t, "unknown", &ca)
+	unknown, err := tls.X509KeyPair(unknownPEM, unknownKeyPEM)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, get("/api/v1/models/test_model", unknown))
+	assert.Equal(t, 401, get("/api/v1/models/test_model"))
+}